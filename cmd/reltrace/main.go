@@ -5,18 +5,16 @@ import (
 	"os"
 
 	"github.com/antoniosarro/reltrace/internal/app"
-	tea "github.com/charmbracelet/bubbletea"
+	"github.com/antoniosarro/reltrace/internal/cli"
 )
 
 func main() {
-	app := app.New()
-
-	program := tea.NewProgram(
-		app,
-		tea.WithAltScreen(),
-	)
+	// Subcommands run non-interactively, for scripts and CI pipelines
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
 
-	if _, err := program.Run(); err != nil {
+	if err := app.Run(nil); err != nil {
 		log.Printf("error running application: %v", err)
 		os.Exit(1)
 	}
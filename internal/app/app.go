@@ -2,6 +2,7 @@ package app
 
 import (
 	"github.com/antoniosarro/reltrace/internal/config"
+	"github.com/antoniosarro/reltrace/internal/database/models"
 	"github.com/antoniosarro/reltrace/internal/ui"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -22,6 +23,22 @@ func New() *App {
 	}
 }
 
+// Run starts the interactive interface, pre-filled with job when it is not nil
+func Run(job *models.DumpConfig) error {
+	app := New()
+	if job != nil {
+		app.ui.Prefill(*job)
+	}
+
+	program := tea.NewProgram(
+		app,
+		tea.WithAltScreen(),
+	)
+
+	_, err := program.Run()
+	return err
+}
+
 // Init initializes the application
 func (a *App) Init() tea.Cmd {
 	return a.ui.Init()
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/antoniosarro/reltrace/internal/config"
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK          = 0
+	ExitFailure     = 1
+	ExitUsage       = 2
+	ExitConnection  = 3
+	ExitInterrupted = 130
+)
+
+// command is a subcommand of the command line interface
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, e *env, args []string) error
+}
+
+var commands = []command{
+	{"dump", "dump structure and data to a SQL file or another database", runDump},
+	{"plan", "show the rows a dump would export, without writing anything", runPlan},
+	{"explain", "show the chain of rows through which a row joins the subset", runExplain},
+	{"restore", "execute a SQL script against a database", runRestore},
+	{"inspect", "list the tables of a database with their row counts", runInspect},
+	{"schema", "print the introspected schema as JSON or SQL", runSchema},
+	{"infer", "propose virtual foreign keys from column names", runInfer},
+	{"jobs", "list the jobs of a job file", runJobs},
+	{"tui", "start the interactive interface, optionally pre-filled with a job", runTUI},
+}
+
+// env holds the streams and settings shared by every command
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
+	config config.AppConfig
+}
+
+// usageError reports invalid command line arguments
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// Run executes the command line and returns the process exit code. Results
+// are written to stdout, progress and errors to stderr.
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return ExitUsage
+		}
+		return ExitOK
+	}
+
+	cmd := lookup(args[0])
+	if cmd == nil {
+		fmt.Fprintf(stderr, "reltrace: unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return ExitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	e := &env{stdout: stdout, stderr: stderr, config: config.DefaultConfig()}
+	err := cmd.run(ctx, e, args[1:])
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	fmt.Fprintf(stderr, "reltrace %s: %v\n", cmd.name, err)
+	return exitCode(ctx, err)
+}
+
+// exitCode maps an error to the process exit code
+func exitCode(ctx context.Context, err error) int {
+	var usage *usageError
+	switch {
+	case errors.As(err, &usage):
+		return ExitUsage
+	case ctx.Err() != nil || errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, adapters.ErrConnectionFailed):
+		return ExitConnection
+	default:
+		return ExitFailure
+	}
+}
+
+func lookup(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: reltrace [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command reltrace starts the interactive interface.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'reltrace <command> -h' for the flags of a command.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Exit codes: 0 success, 1 failure, 2 invalid usage, 3 connection failure, 130 interrupted.")
+}
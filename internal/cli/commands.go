@@ -0,0 +1,468 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/app"
+	"github.com/antoniosarro/reltrace/internal/config"
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/engine"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+func runDump(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "dump", "[flags]")
+	var df dumpFlags
+	df.register(fs)
+	quiet := fs.Bool("quiet", false, "do not report progress on stderr")
+	report := fs.String("report", "", "JSON report file (default next to the output file)")
+	noReport := fs.Bool("no-report", false, "do not write a JSON report")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	cfg, err := df.config(e.config, fs)
+	if err != nil {
+		return err
+	}
+
+	opts := engine.Options{ReportPath: *report}
+	if opts.ReportPath == "" {
+		opts.ReportPath = engine.DefaultReportPath(cfg)
+	}
+	if *noReport {
+		opts.ReportPath = ""
+	}
+	if !*quiet {
+		opts.Progress = progressPrinter(e.stderr)
+	}
+	if cfg.Target == models.ToFile && cfg.OutputPath == "-" {
+		opts.Output = e.stdout
+	}
+
+	res, err := engine.Run(ctx, cfg, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range res.Warnings {
+		fmt.Fprintf(e.stderr, "warning: %s\n", w)
+	}
+	if !*quiet {
+		output := res.Output
+		if output == "" {
+			output = "stdout"
+		}
+		fmt.Fprintf(e.stderr, "dumped %d rows from %d tables to %s in %s\n",
+			res.Rows, len(res.Tables), output, res.Duration.Round(time.Millisecond))
+		if res.Report != "" {
+			fmt.Fprintf(e.stderr, "report written to %s\n", res.Report)
+		}
+	}
+	return nil
+}
+
+func runPlan(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "plan", "[flags]")
+	var df dumpFlags
+	df.register(fs)
+	asJSON := fs.Bool("json", false, "print the plan as JSON")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	cfg, err := df.config(e.config, fs)
+	if err != nil {
+		return err
+	}
+
+	sess, err := engine.OpenDump(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+
+	plan, err := sess.Plan(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(e.stdout, plan)
+	}
+	if err := printPlan(e.stdout, plan); err != nil {
+		return err
+	}
+	for _, w := range plan.Warnings {
+		fmt.Fprintf(e.stderr, "warning: %s\n", w)
+	}
+	return nil
+}
+
+func runExplain(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "explain", "[flags] --table TABLE --pk KEY")
+	var df dumpFlags
+	df.register(fs)
+	table := fs.String("table", "", "table of the row to explain")
+	pk := fs.String("pk", "", "primary key value of the row, or col=value&col=value for a composite key")
+	asJSON := fs.Bool("json", false, "print the paths as JSON")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *table == "" || *pk == "" {
+		return usagef("explain needs the --table and --pk of a row")
+	}
+	cfg, err := df.config(e.config, fs)
+	if err != nil {
+		return err
+	}
+	// The including mode pulls the rows related to the seeds both ways and the
+	// excluding mode only their dependents, so the mode must be given
+	if cfg.Mode != models.StructureAndDataExcluding && cfg.Mode != models.StructureAndDataIncludingOnly {
+		return usagef("explain needs the %s or %s mode, from --mode or the job",
+			models.StructureAndDataIncludingOnly, models.StructureAndDataExcluding)
+	}
+
+	sess, err := engine.OpenDump(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+
+	explained, err := sess.Explain(ctx, cfg, *table, *pk)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(e.stdout, explained)
+	}
+	fmt.Fprintf(e.stdout, "mode: %s\n", cfg.Mode)
+	for _, x := range explained {
+		fmt.Fprintln(e.stdout)
+		if !x.Reached() {
+			fmt.Fprintf(e.stdout, "%s is not reached from the seeds\n", x.Row)
+			continue
+		}
+		fmt.Fprintln(e.stdout, x.Chain())
+		tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+		for _, s := range x.Path {
+			fmt.Fprintf(tw, "  %s\t%s\n", s, s.Via())
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printPlan writes the per table outcome of a plan and the relationships
+// its traversal followed. Rows left out are shown only in the excluding mode.
+func printPlan(w io.Writer, plan *engine.Plan) error {
+	excluding := plan.Mode == models.StructureAndDataExcluding
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if excluding {
+		fmt.Fprintln(tw, "TABLE\tFILTER\tROWS\tLEFT OUT\tDEPTH\tEST. SIZE")
+	} else {
+		fmt.Fprintln(tw, "TABLE\tFILTER\tROWS\tDEPTH\tEST. SIZE")
+	}
+	for _, t := range plan.Tables {
+		depth := "-"
+		if t.Depth >= 0 {
+			depth = strconv.Itoa(t.Depth)
+		}
+		if excluding {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\n", t.Name, t.Filter, t.Rows, t.Excluded, depth, engine.FormatBytes(t.Bytes))
+		} else {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", t.Name, t.Filter, t.Rows, depth, engine.FormatBytes(t.Bytes))
+		}
+	}
+	if excluding {
+		fmt.Fprintf(tw, "total\t\t%d\t%d\t\t%s\n", plan.Rows, plan.Excluded, engine.FormatBytes(plan.Bytes))
+	} else {
+		fmt.Fprintf(tw, "total\t\t%d\t\t%s\n", plan.Rows, engine.FormatBytes(plan.Bytes))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(plan.Edges) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RELATIONSHIP\tPULLS\tROWS")
+	for _, u := range plan.Edges {
+		fmt.Fprintf(tw, "%s\t%s of %s\t%d\n", u.Edge, u.Direction, u.Table, u.Rows)
+	}
+	return tw.Flush()
+}
+
+func runRestore(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "restore", "[flags] FILE")
+	var conn connFlags
+	conn.register(fs, "", "target", "RELTRACE_PASSWORD")
+	quiet := fs.Bool("quiet", false, "do not report progress on stderr")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("restore expects exactly one script file, or '-' for stdin")
+	}
+	cfg, err := conn.config(models.DatabaseConfig{}, nil)
+	if err != nil {
+		return err
+	}
+
+	in, err := openInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var progress engine.ProgressFunc
+	if !*quiet {
+		progress = func(p engine.Progress) {
+			fmt.Fprintf(e.stderr, "restore: %s\n", p.Message)
+		}
+	}
+
+	start := time.Now()
+	n, err := engine.Restore(ctx, cfg, in, progress)
+	if err != nil {
+		return err
+	}
+	if !*quiet {
+		fmt.Fprintf(e.stderr, "restored %d statements in %s\n", n, time.Since(start).Round(time.Millisecond))
+	}
+	return nil
+}
+
+func runInspect(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "inspect", "[flags]")
+	var conn connFlags
+	conn.register(fs, "", "source", "RELTRACE_PASSWORD")
+	asJSON := fs.Bool("json", false, "print the tables as JSON")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	cfg, err := conn.config(models.DatabaseConfig{}, nil)
+	if err != nil {
+		return err
+	}
+
+	sess, err := engine.Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+
+	type tableInfo struct {
+		Name        string   `json:"name"`
+		Rows        int64    `json:"rows"`
+		Columns     int      `json:"columns"`
+		PrimaryKey  []string `json:"primary_key,omitempty"`
+		ForeignKeys int      `json:"foreign_keys"`
+	}
+	tables := make([]tableInfo, 0, len(sess.Schema.Tables))
+	for _, t := range sess.Schema.Tables {
+		rows, err := sess.RowCount(ctx, t.Name)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, tableInfo{
+			Name:        t.Name,
+			Rows:        rows,
+			Columns:     len(t.Columns),
+			PrimaryKey:  t.PrimaryKey,
+			ForeignKeys: len(t.ForeignKeys),
+		})
+	}
+
+	if *asJSON {
+		return writeJSON(e.stdout, tables)
+	}
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tROWS\tCOLUMNS\tPRIMARY KEY\tFOREIGN KEYS")
+	for _, t := range tables {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%d\n", t.Name, t.Rows, t.Columns, strings.Join(t.PrimaryKey, ", "), t.ForeignKeys)
+	}
+	return tw.Flush()
+}
+
+func runSchema(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "schema", "[flags]")
+	var conn connFlags
+	conn.register(fs, "", "source", "RELTRACE_PASSWORD")
+	format := fs.String("format", "json", "output format: json or sql")
+	dialect := fs.String("dialect", "", "SQL dialect of the sql format (default the source type)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	cfg, err := conn.config(models.DatabaseConfig{}, nil)
+	if err != nil {
+		return err
+	}
+	if *format != "json" && *format != "sql" {
+		return usagef("unknown format %q (expected json or sql)", *format)
+	}
+
+	sess, err := engine.Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+
+	if *format == "json" {
+		return writeJSON(e.stdout, sess.Schema)
+	}
+
+	renderer := sess.Source
+	if *dialect != "" {
+		typ, err := models.ParseDatabaseType(*dialect)
+		if err != nil {
+			return &usageError{msg: err.Error()}
+		}
+		if renderer, err = adapters.New(models.DatabaseConfig{Type: typ}); err != nil {
+			return err
+		}
+	}
+	for i := range sess.Schema.Tables {
+		t := &sess.Schema.Tables[i]
+		stmts := append(renderer.CreateTable(t, sess.Schema.Type), renderer.FinishTable(t)...)
+		for _, stmt := range stmts {
+			fmt.Fprintf(e.stdout, "%s;\n", stmt)
+		}
+		fmt.Fprintln(e.stdout)
+	}
+	return nil
+}
+
+func runInfer(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "infer", "[flags]")
+	var conn connFlags
+	conn.register(fs, "", "source", "RELTRACE_PASSWORD")
+	var plurals, suffixes stringMapFlag
+	sample := fs.Int("sample", engine.DefaultInferSample, "distinct values sampled to check each relationship")
+	overlap := fs.Float64("min-overlap", engine.DefaultInferOverlap, "share of sampled values that must exist in the referenced table")
+	fs.Var(&plurals, "plural", "irregular plural of a table name as singular=plural, e.g. person=people, repeatable")
+	fs.Var(&suffixes, "plural-suffix", "plural ending of table names by singular ending, e.g. f=ves; the longest ending applies, repeatable")
+	asJSON := fs.Bool("json", false, "print every candidate with its sample counts as JSON")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	cfg, err := conn.config(models.DatabaseConfig{}, nil)
+	if err != nil {
+		return err
+	}
+	if *sample <= 0 || *overlap <= 0 || *overlap > 1 {
+		return usagef("--sample must be positive and --min-overlap between 0 and 1")
+	}
+
+	sess, err := engine.Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+
+	inferred, err := sess.Engine.Infer(ctx, engine.InferOptions{Sample: *sample, MinOverlap: *overlap, Plurals: plurals, Suffixes: suffixes})
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(e.stdout, inferred)
+	}
+
+	// Rejected candidates are kept as comments, for review
+	fmt.Fprintln(e.stdout, "# Relationships inferred from column names; review them before adding them to a job")
+	if len(inferred) == 0 {
+		fmt.Fprintln(e.stdout, "virtual_foreign_keys: []")
+		return nil
+	}
+	fmt.Fprintln(e.stdout, "virtual_foreign_keys:")
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	for _, r := range inferred {
+		prefix := "  - "
+		if !r.Valid {
+			prefix = "  # - "
+		}
+		fmt.Fprintf(tw, "%s%s\t# %s\n", prefix, r.ForeignKey.Declaration(), r.Evidence())
+	}
+	return tw.Flush()
+}
+
+func runJobs(_ context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "jobs", "[flags]")
+	jobsFile := fs.String("jobs-file", "", "job file (default "+strings.Join(config.DefaultJobFiles, ", ")+")")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	path := *jobsFile
+	if path == "" {
+		found, err := config.FindJobFile()
+		if err != nil {
+			return &usageError{msg: err.Error()}
+		}
+		path = found
+	}
+	f, err := config.LoadJobFile(path)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tSOURCE\tMODE\tTARGET")
+	for _, name := range f.Names() {
+		job := f.Jobs[name]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, job.SourceConfig.Type, job.Mode, job.Target)
+	}
+	return tw.Flush()
+}
+
+func runTUI(_ context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "tui", "[flags]")
+	job := fs.String("job", "", "named job used to pre-fill the configuration")
+	jobsFile := fs.String("jobs-file", "", "job file (default "+strings.Join(config.DefaultJobFiles, ", ")+")")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if *job == "" {
+		return app.Run(nil)
+	}
+	cfg, err := loadJob(*jobsFile, *job)
+	if err != nil {
+		return err
+	}
+	return app.Run(&cfg)
+}
+
+// progressPrinter reports dump progress on w, one line per phase and table
+func progressPrinter(w io.Writer) engine.ProgressFunc {
+	var last engine.Progress
+	return func(p engine.Progress) {
+		if p.Phase == last.Phase && p.Table == last.Table && p.Message == last.Message {
+			return
+		}
+		last = p
+		switch {
+		case p.Table != "" && p.Message != "":
+			fmt.Fprintf(w, "%s: %s (%d/%d) %s\n", p.Phase, p.Table, p.Tables+1, p.Total, p.Message)
+		case p.Table != "":
+			fmt.Fprintf(w, "%s: %s (%d/%d) %d rows\n", p.Phase, p.Table, p.Tables+1, p.Total, p.Expected)
+		case p.Message != "":
+			fmt.Fprintf(w, "%s: %s\n", p.Phase, p.Message)
+		}
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
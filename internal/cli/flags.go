@@ -0,0 +1,520 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/config"
+	"github.com/antoniosarro/reltrace/internal/database/engine"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// newFlagSet creates the flag set of a command, reporting errors instead of exiting
+func newFlagSet(e *env, name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: reltrace %s %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags of a command, turning parse failures into usage errors
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return &usageError{msg: err.Error()}
+	}
+	return nil
+}
+
+// connFlags holds the flags describing a database connection
+type connFlags struct {
+	prefix   string
+	env      string
+	typ      string
+	host     string
+	port     string
+	user     string
+	password string
+	database string
+	file     string
+}
+
+// register adds the connection flags to fs, named with the given prefix. The
+// password falls back to the passwordEnv environment variable, so it does
+// not have to appear in the process list.
+func (c *connFlags) register(fs *flag.FlagSet, prefix, what, passwordEnv string) {
+	c.prefix = prefix
+	c.env = passwordEnv
+	fs.StringVar(&c.typ, prefix+"type", "", what+" database type: mysql, postgresql or sqlite3")
+	fs.StringVar(&c.host, prefix+"host", "localhost", what+" database host")
+	fs.StringVar(&c.port, prefix+"port", "", what+" database port (default depends on the type)")
+	fs.StringVar(&c.user, prefix+"user", "", what+" database user")
+	fs.StringVar(&c.password, prefix+"password", "", what+" database password (or $"+passwordEnv+")")
+	fs.StringVar(&c.database, prefix+"database", "", what+" database name")
+	fs.StringVar(&c.file, prefix+"file", "", what+" SQLite database file")
+}
+
+// config returns the connection configuration described by the flags. Values
+// of base, usually read from a job file, are kept unless the flag was given.
+func (c *connFlags) config(base models.DatabaseConfig, set map[string]bool) (models.DatabaseConfig, error) {
+	cfg := base
+	pick := func(dst *string, name, value string) {
+		if *dst == "" || set[c.prefix+name] {
+			*dst = value
+		}
+	}
+
+	if cfg.Type == "" || set[c.prefix+"type"] {
+		if c.typ == "" {
+			return cfg, usagef("--%stype is required", c.prefix)
+		}
+		typ, err := models.ParseDatabaseType(c.typ)
+		if err != nil {
+			return cfg, &usageError{msg: err.Error()}
+		}
+		cfg.Type = typ
+	}
+	pick(&cfg.Host, "host", c.host)
+	pick(&cfg.Port, "port", c.port)
+	pick(&cfg.User, "user", c.user)
+	pick(&cfg.Password, "password", c.password)
+	pick(&cfg.Database, "database", c.database)
+	pick(&cfg.FilePath, "file", c.file)
+
+	if cfg.Password == "" {
+		cfg.Password = os.Getenv(c.env)
+	}
+	if cfg.Port == "" {
+		cfg.Port = cfg.Type.DefaultPort()
+	}
+
+	if cfg.Type == models.SQLite3 {
+		if cfg.FilePath == "" && cfg.Database == "" {
+			return cfg, usagef("--%sfile is required for sqlite3", c.prefix)
+		}
+	} else if cfg.Database == "" {
+		return cfg, usagef("--%sdatabase is required for %s", c.prefix, cfg.Type)
+	}
+	return cfg, nil
+}
+
+// dumpFlags holds the flags mapping onto models.DumpConfig
+type dumpFlags struct {
+	source    connFlags
+	target    connFlags
+	job       string
+	jobsFile  string
+	mode      string
+	to        string
+	output    string
+	rootTable string
+	rootPK    string
+	seeds     seedFlag
+	include   listFlag
+	exclude   listFlag
+	boundary  listFlag
+	lookup    listFlag
+	follow    followFlag
+	maxDepth  int
+	depths    intMapFlag
+	maxRows   int
+	budgets   intMapFlag
+	virtual   repeatedFlag
+	poly      polymorphicFlag
+	snapshot  string
+	workers   int
+	chunkRows int
+	buffer    int
+}
+
+func (d *dumpFlags) register(fs *flag.FlagSet) {
+	d.source.register(fs, "", "source", "RELTRACE_PASSWORD")
+	d.target.register(fs, "target-", "target", "RELTRACE_TARGET_PASSWORD")
+	fs.StringVar(&d.job, "job", "", "named job of the job file to run; other flags override its settings, repeatable flags add to its lists and by-name settings")
+	fs.StringVar(&d.jobsFile, "jobs-file", "", "job file (default "+strings.Join(config.DefaultJobFiles, ", ")+")")
+	fs.StringVar(&d.mode, "mode", models.StructureAndData.String(),
+		"dump mode: structure-only, structure-and-data, structure-and-data-excluding or structure-and-data-including-only")
+	fs.StringVar(&d.to, "target", models.ToFile.String(), "dump target: file or database")
+	fs.StringVar(&d.output, "output", "", "output file, '-' for stdout (default <database>_<timestamp>.sql)")
+	fs.StringVar(&d.rootTable, "root-table", "", "root table of the excluding and including-only modes")
+	fs.StringVar(&d.rootPK, "root-pk", "", "primary key value of the root row, col=value&col=value for a composite key, or a comma separated list of several roots")
+	fs.Var(seedKeysFlag{&d.seeds}, "seed", "further root rows as table=key[,key...], with col=value&col=value keys for composite keys, repeatable")
+	fs.Var(seedWhereFlag{&d.seeds}, "where", "further root rows as 'table:predicate', e.g. \"companies:industry = 'Technology'\", repeatable")
+	fs.Var(&d.include, "include", "only dump these tables; comma separated names or globs such as 'crm_*', repeatable")
+	fs.Var(&d.exclude, "exclude", "leave these tables out; comma separated names or globs such as 'audit_*', repeatable")
+	fs.Var(&d.boundary, "boundary", "dump the referenced rows of these tables without expanding from them; names or globs, repeatable")
+	fs.Var(&d.lookup, "lookup", "dump these tables in full and never traverse them; names or globs, repeatable")
+	fs.Var(&d.follow, "follow", "traversal rule of a foreign key as name=rule or table.column=rule, with rule default, children, parents or none, repeatable")
+	fs.IntVar(&d.maxDepth, "max-depth", -1, "stop pulling referencing rows this many relationships away from the seeds, 0 at the seeds themselves; -1 is unlimited")
+	fs.Var(&d.depths, "depth-limit", "max depth of a foreign key as name=n or table.column=n, read like --max-depth, repeatable")
+	fs.IntVar(&d.maxRows, "max-rows", 0, "stop pulling referencing rows once the subset holds this many rows; 0 is unlimited")
+	fs.Var(&d.budgets, "table-max-rows", "row budget of a table as table=n, repeatable")
+	fs.Var(&d.virtual, "virtual-fk", "relationship the database does not declare, as 'orders.customer_ref -> customers.id', or 'activity_log.new_values->$.client_id -> clients.id' inside a JSON column, repeatable")
+	fs.StringVar(&d.snapshot, "snapshot", string(models.SnapshotTransaction),
+		"how reads of a live source stay consistent: transaction (a single snapshot), locks (read locks on every table) or none")
+	fs.IntVar(&d.workers, "workers", 1, "tables and key ranges of big tables read concurrently, each on its own connection sharing the snapshot; MySQL and SQLite need --snapshot locks or none")
+	fs.IntVar(&d.chunkRows, "chunk-rows", engine.DefaultChunkRows, "split tables of more rows than this into key ranges read by several workers")
+	fs.IntVar(&d.buffer, "buffer", engine.DefaultBufferRows, "rows every worker reads ahead of the writer")
+	fs.Var(&d.poly, "polymorphic", "type/id column pair pointing at the table named by the type, as 'activity_log.entity_type,entity_id: employees=employees,projects=projects', repeatable")
+}
+
+// config returns the dump configuration described by the flags, starting
+// from the selected job when there is one
+func (d *dumpFlags) config(app config.AppConfig, fs *flag.FlagSet) (models.DumpConfig, error) {
+	set := explicit(fs)
+
+	var base models.DumpConfig
+	if d.job != "" {
+		job, err := loadJob(d.jobsFile, d.job)
+		if err != nil {
+			return base, err
+		}
+		base = job
+	}
+	fromJob := d.job != ""
+
+	cfg := base
+	source, err := d.source.config(base.SourceConfig, set)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.SourceConfig = source
+
+	if !fromJob || set["mode"] {
+		if cfg.Mode, err = models.ParseDumpMode(d.mode); err != nil {
+			return cfg, &usageError{msg: err.Error()}
+		}
+	}
+	if !fromJob || set["target"] {
+		if cfg.Target, err = models.ParseDumpTarget(d.to); err != nil {
+			return cfg, &usageError{msg: err.Error()}
+		}
+	}
+	if cfg.OutputPath == "" || set["output"] {
+		cfg.OutputPath = d.output
+	}
+	if cfg.RootTable == "" || set["root-table"] {
+		cfg.RootTable = d.rootTable
+	}
+	if cfg.RootPrimaryKey == "" || set["root-pk"] {
+		cfg.RootPrimaryKey = d.rootPK
+	}
+
+	// Repeatable flags add to the lists of the job
+	if set["seed"] || set["where"] {
+		cfg.Seeds = append(slices.Clone(cfg.Seeds), d.seeds...)
+	}
+	if set["include"] {
+		cfg.IncludeTables = append(slices.Clone(cfg.IncludeTables), d.include...)
+	}
+	if set["exclude"] {
+		cfg.ExcludeTables = append(slices.Clone(cfg.ExcludeTables), d.exclude...)
+	}
+	if set["boundary"] {
+		cfg.BoundaryTables = append(slices.Clone(cfg.BoundaryTables), d.boundary...)
+	}
+	if set["lookup"] {
+		cfg.LookupTables = append(slices.Clone(cfg.LookupTables), d.lookup...)
+	}
+	if set["follow"] {
+		cfg.Follow = maps.Clone(cfg.Follow)
+		if cfg.Follow == nil {
+			cfg.Follow = make(map[string]models.FollowRule)
+		}
+		maps.Copy(cfg.Follow, d.follow)
+	}
+	if set["virtual-fk"] {
+		cfg.VirtualForeignKeys = append(slices.Clone(cfg.VirtualForeignKeys), d.virtual...)
+	}
+	for _, decl := range cfg.VirtualForeignKeys {
+		if _, err := models.ParseForeignKey(decl); err != nil {
+			return cfg, &usageError{msg: err.Error()}
+		}
+	}
+	if set["polymorphic"] {
+		cfg.Polymorphic = append(slices.Clone(cfg.Polymorphic), d.poly...)
+	}
+	if !fromJob || set["snapshot"] {
+		if cfg.Snapshot, err = models.ParseSnapshotMode(d.snapshot); err != nil {
+			return cfg, &usageError{msg: err.Error()}
+		}
+	}
+	if set["workers"] {
+		cfg.Workers = d.workers
+	}
+	if set["chunk-rows"] {
+		cfg.ChunkRows = d.chunkRows
+	}
+	if set["buffer"] {
+		cfg.BufferRows = d.buffer
+	}
+	if set["max-depth"] {
+		cfg.MaxDepth = nil
+		if d.maxDepth != -1 {
+			cfg.MaxDepth = &d.maxDepth
+		}
+	}
+	if set["depth-limit"] {
+		cfg.DepthLimits = mergeLimits(cfg.DepthLimits, d.depths)
+	}
+	if set["max-rows"] {
+		cfg.MaxRows = d.maxRows
+	}
+	if set["table-max-rows"] {
+		cfg.TableMaxRows = mergeLimits(cfg.TableMaxRows, d.budgets)
+	}
+	if err := cfg.ValidateLimits(); err != nil {
+		return cfg, &usageError{msg: err.Error()}
+	}
+	if err := cfg.ValidateWorkers(); err != nil {
+		return cfg, &usageError{msg: err.Error()}
+	}
+	for _, patterns := range [][]string{cfg.IncludeTables, cfg.ExcludeTables, cfg.BoundaryTables, cfg.LookupTables} {
+		if err := models.ValidateTablePatterns(patterns); err != nil {
+			return cfg, &usageError{msg: err.Error()}
+		}
+	}
+
+	if cfg.Mode == models.StructureAndDataExcluding || cfg.Mode == models.StructureAndDataIncludingOnly {
+		seeds, err := cfg.AllSeeds()
+		if err != nil {
+			return cfg, &usageError{msg: err.Error()}
+		}
+		if len(seeds) == 0 {
+			return cfg, usagef("mode %s requires --root-table and --root-pk, --seed or --where", cfg.Mode)
+		}
+	}
+
+	switch cfg.Target {
+	case models.ToDatabase:
+		var targetBase models.DatabaseConfig
+		if cfg.TargetConfig != nil {
+			targetBase = *cfg.TargetConfig
+		}
+		targetCfg, err := d.target.config(targetBase, set)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.TargetConfig = &targetCfg
+	case models.ToFile:
+		// A target type alone selects the SQL dialect of the file
+		if set["target-type"] || (d.target.typ != "" && cfg.TargetConfig == nil) {
+			typ, err := models.ParseDatabaseType(d.target.typ)
+			if err != nil {
+				return cfg, &usageError{msg: err.Error()}
+			}
+			cfg.TargetConfig = &models.DatabaseConfig{Type: typ}
+		}
+		if cfg.OutputPath == "" {
+			name := source.Database
+			if name == "" {
+				name = source.FilePath
+			}
+			cfg.OutputPath = app.Output.DumpPath(name, time.Now())
+		}
+	}
+	return cfg, nil
+}
+
+// loadJob reads a named job from path, or from the default job file when path is empty
+func loadJob(path, name string) (models.DumpConfig, error) {
+	if path == "" {
+		found, err := config.FindJobFile()
+		if err != nil {
+			return models.DumpConfig{}, &usageError{msg: err.Error()}
+		}
+		path = found
+	}
+	f, err := config.LoadJobFile(path)
+	if err != nil {
+		return models.DumpConfig{}, err
+	}
+	job, err := f.Job(name)
+	if err != nil {
+		return job, &usageError{msg: err.Error()}
+	}
+	return job, nil
+}
+
+// listFlag collects comma separated values of a flag that may be repeated
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// followFlag collects name=rule traversal rules; later flags win
+type followFlag map[string]models.FollowRule
+
+func (f *followFlag) String() string { return "" }
+
+func (f *followFlag) Set(value string) error {
+	i := strings.LastIndex(value, "=")
+	if i <= 0 {
+		return fmt.Errorf("expected name=rule or table.column=rule, got %q", value)
+	}
+	rule, err := models.ParseFollowRule(strings.TrimSpace(value[i+1:]))
+	if err != nil {
+		return err
+	}
+	if *f == nil {
+		*f = make(followFlag)
+	}
+	(*f)[strings.TrimSpace(value[:i])] = rule
+	return nil
+}
+
+// repeatedFlag collects the values of a flag that may be repeated, as given
+type repeatedFlag []string
+
+func (r *repeatedFlag) String() string {
+	return strings.Join(*r, "; ")
+}
+
+func (r *repeatedFlag) Set(value string) error {
+	*r = append(*r, strings.TrimSpace(value))
+	return nil
+}
+
+// polymorphicFlag collects polymorphic relations, in command line order
+type polymorphicFlag []models.PolymorphicRelation
+
+func (f *polymorphicFlag) String() string { return "" }
+
+func (f *polymorphicFlag) Set(value string) error {
+	r, err := models.ParsePolymorphicRelation(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, r)
+	return nil
+}
+
+// stringMapFlag collects name=value settings; later flags win
+type stringMapFlag map[string]string
+
+func (f *stringMapFlag) String() string { return "" }
+
+func (f *stringMapFlag) Set(value string) error {
+	name, v, ok := strings.Cut(value, "=")
+	name, v = strings.TrimSpace(name), strings.TrimSpace(v)
+	if !ok || name == "" || v == "" {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	if *f == nil {
+		*f = make(stringMapFlag)
+	}
+	(*f)[name] = v
+	return nil
+}
+
+// intMapFlag collects name=n settings; later flags win
+type intMapFlag map[string]int
+
+func (f *intMapFlag) String() string { return "" }
+
+func (f *intMapFlag) Set(value string) error {
+	i := strings.LastIndex(value, "=")
+	if i <= 0 {
+		return fmt.Errorf("expected name=n, got %q", value)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value[i+1:]))
+	if err != nil {
+		return fmt.Errorf("expected name=n, got %q", value)
+	}
+	if *f == nil {
+		*f = make(intMapFlag)
+	}
+	(*f)[strings.TrimSpace(value[:i])] = n
+	return nil
+}
+
+// mergeLimits returns the limits of a job overridden by the ones of the flags
+func mergeLimits(base map[string]int, flags intMapFlag) map[string]int {
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = make(map[string]int)
+	}
+	maps.Copy(merged, flags)
+	return merged
+}
+
+// seedFlag collects the seeds given by --seed and --where, in command line order
+type seedFlag []models.Seed
+
+// seedKeysFlag parses table=key[,key...] seeds, where a key is a value or a
+// set of col=value pairs
+type seedKeysFlag struct{ seeds *seedFlag }
+
+func (f seedKeysFlag) String() string { return "" }
+
+func (f seedKeysFlag) Set(value string) error {
+	table, keys, ok := strings.Cut(value, "=")
+	var list listFlag
+	list.Set(keys)
+	if !ok || strings.TrimSpace(table) == "" || len(list) == 0 {
+		return fmt.Errorf("expected table=key[,key...], got %q", value)
+	}
+	seed, err := models.ParseSeed(strings.TrimSpace(table), list)
+	if err != nil {
+		return err
+	}
+	*f.seeds = append(*f.seeds, seed)
+	return nil
+}
+
+// seedWhereFlag parses table:predicate seeds
+type seedWhereFlag struct{ seeds *seedFlag }
+
+func (f seedWhereFlag) String() string { return "" }
+
+func (f seedWhereFlag) Set(value string) error {
+	table, where, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(table) == "" || strings.TrimSpace(where) == "" {
+		return fmt.Errorf("expected table:predicate, got %q", value)
+	}
+	*f.seeds = append(*f.seeds, models.Seed{Table: strings.TrimSpace(table), Where: strings.TrimSpace(where)})
+	return nil
+}
+
+// explicit returns the names of the flags given on the command line
+func explicit(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// openInput opens a script file, or stdin for "-"
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening script: %w", err)
+	}
+	return f, nil
+}
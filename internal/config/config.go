@@ -1,5 +1,11 @@
 package config
 
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
 // AppConfig holds the application configuration
 type AppConfig struct {
 	Output OutputConfig
@@ -13,6 +19,18 @@ type OutputConfig struct {
 	Timestamp bool
 }
 
+// DumpPath returns the default output file for a dump of the named database
+func (o OutputConfig) DumpPath(database string, now time.Time) string {
+	name := strings.TrimSuffix(filepath.Base(database), filepath.Ext(database))
+	if name == "" || name == "." {
+		name = "dump"
+	}
+	if o.Timestamp {
+		name += "_" + now.Format("20060102_150405")
+	}
+	return filepath.Join(o.Directory, name+"."+o.Format)
+}
+
 // UIConfig defines UI settings
 type UIConfig struct {
 	Theme           string
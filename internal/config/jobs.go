@@ -0,0 +1,170 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultJobFiles are the job files looked up in the working directory, in order
+var DefaultJobFiles = []string{"reltrace.yaml", "reltrace.yml", "reltrace.json"}
+
+// ErrNoJobFile is returned when no job file is given and none of the defaults exist
+var ErrNoJobFile = errors.New("no job file found")
+
+// Passwords are saved as references to these environment variables, so job
+// files can be checked into version control
+const (
+	SourcePasswordRef = "${RELTRACE_PASSWORD}"
+	TargetPasswordRef = "${RELTRACE_TARGET_PASSWORD}"
+)
+
+// JobFile holds named dump configurations, usually checked in as reltrace.yaml
+type JobFile struct {
+	Jobs map[string]models.DumpConfig `json:"jobs" yaml:"jobs"`
+}
+
+// FindJobFile returns the first default job file present in the working directory
+func FindJobFile() (string, error) {
+	for _, name := range DefaultJobFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%w (looked for %s)", ErrNoJobFile, strings.Join(DefaultJobFiles, ", "))
+}
+
+// LoadJobFile reads a job file; files ending in .json are parsed as JSON, any
+// other file as YAML
+func LoadJobFile(path string) (*JobFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading job file: %w", err)
+	}
+
+	var f JobFile
+	if isJSON(path) {
+		err = json.Unmarshal(data, &f)
+	} else {
+		err = yaml.Unmarshal(data, &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing job file %s: %w", path, err)
+	}
+	if f.Jobs == nil {
+		f.Jobs = make(map[string]models.DumpConfig)
+	}
+	return &f, nil
+}
+
+// Names returns the job names in alphabetical order
+func (f *JobFile) Names() []string {
+	names := make([]string, 0, len(f.Jobs))
+	for name := range f.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Job returns a named job. Connection settings that are exactly an environment
+// reference such as ${DB_PASSWORD} are expanded, so secrets can stay out of
+// the file; any other value is taken as it is, dollar signs included.
+func (f *JobFile) Job(name string) (models.DumpConfig, error) {
+	cfg, ok := f.Jobs[name]
+	if !ok {
+		return cfg, fmt.Errorf("unknown job %q (available: %s)", name, strings.Join(f.Names(), ", "))
+	}
+
+	cfg.SourceConfig = expandEnv(cfg.SourceConfig)
+	if cfg.TargetConfig != nil {
+		target := expandEnv(*cfg.TargetConfig)
+		cfg.TargetConfig = &target
+	}
+	return cfg, nil
+}
+
+// Save writes the job file, in the format matching the extension of path
+func (f *JobFile) Save(path string) error {
+	var buf bytes.Buffer
+	if isJSON(path) {
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f); err != nil {
+			return fmt.Errorf("encoding job file: %w", err)
+		}
+	} else {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(f); err != nil {
+			return fmt.Errorf("encoding job file: %w", err)
+		}
+		enc.Close()
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing job file: %w", err)
+	}
+	return nil
+}
+
+// SaveJob adds or replaces a named job in the job file at path, creating the
+// file when it does not exist
+func SaveJob(path, name string, cfg models.DumpConfig) error {
+	f, err := LoadJobFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = &JobFile{Jobs: make(map[string]models.DumpConfig)}, nil
+	}
+	if err != nil {
+		return err
+	}
+	f.Jobs[name] = cfg
+	return f.Save(path)
+}
+
+// WithPasswordRefs returns a configuration with its passwords replaced by
+// environment references, ready to be saved as a job
+func WithPasswordRefs(cfg models.DumpConfig) models.DumpConfig {
+	if cfg.SourceConfig.Password != "" {
+		cfg.SourceConfig.Password = SourcePasswordRef
+	}
+	if cfg.TargetConfig != nil && cfg.TargetConfig.Password != "" {
+		target := *cfg.TargetConfig
+		target.Password = TargetPasswordRef
+		cfg.TargetConfig = &target
+	}
+	return cfg
+}
+
+// expandEnv expands the fields of a connection that are environment references
+func expandEnv(cfg models.DatabaseConfig) models.DatabaseConfig {
+	for _, field := range []*string{&cfg.Host, &cfg.Port, &cfg.User, &cfg.Password, &cfg.Database, &cfg.FilePath} {
+		*field = expandRef(*field)
+	}
+	return cfg
+}
+
+// expandRef returns the value of the environment variable a ${NAME}
+// reference names, or any other value unchanged
+func expandRef(value string) string {
+	name, ok := strings.CutPrefix(value, "${")
+	if !ok {
+		return value
+	}
+	name, ok = strings.CutSuffix(name, "}")
+	if !ok || name == "" || strings.ContainsAny(name, "${} ") {
+		return value
+	}
+	return os.Getenv(name)
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
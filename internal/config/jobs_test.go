@@ -0,0 +1,89 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+func TestSaveJobRoundTrip(t *testing.T) {
+	t.Setenv("RELTRACE_PASSWORD", "from-env")
+	t.Setenv("RELTRACE_TEST_HOST", "db.internal")
+	tests := []struct {
+		name     string
+		source   models.DatabaseConfig
+		password string
+		host     string
+	}{
+		{"literal with dollar signs", models.DatabaseConfig{Host: "localhost", Password: "pa$$word"}, "pa$$word", "localhost"},
+		{"literal with a positional", models.DatabaseConfig{Host: "localhost", Password: "abc$1"}, "abc$1", "localhost"},
+		{"literal with a variable inside", models.DatabaseConfig{Host: "localhost", Password: "x${RELTRACE_PASSWORD}"}, "x${RELTRACE_PASSWORD}", "localhost"},
+		{"reference", models.DatabaseConfig{Host: "${RELTRACE_TEST_HOST}", Password: SourcePasswordRef}, "from-env", "db.internal"},
+	}
+	for _, ext := range []string{".yaml", ".json"} {
+		for _, tt := range tests {
+			t.Run(ext+" "+tt.name, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "reltrace"+ext)
+				cfg := models.DumpConfig{SourceConfig: tt.source, Mode: models.StructureAndData}
+				if err := SaveJob(path, "job", cfg); err != nil {
+					t.Fatal(err)
+				}
+				f, err := LoadJobFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := f.Job("job")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.SourceConfig.Password != tt.password {
+					t.Errorf("password = %q, want %q", got.SourceConfig.Password, tt.password)
+				}
+				if got.SourceConfig.Host != tt.host {
+					t.Errorf("host = %q, want %q", got.SourceConfig.Host, tt.host)
+				}
+				if f.Jobs["job"].SourceConfig != tt.source {
+					t.Errorf("saved source = %+v, want %+v", f.Jobs["job"].SourceConfig, tt.source)
+				}
+			})
+		}
+	}
+}
+
+func TestWithPasswordRefs(t *testing.T) {
+	t.Setenv("RELTRACE_PASSWORD", "source-secret")
+	t.Setenv("RELTRACE_TARGET_PASSWORD", "target-secret")
+	cfg := models.DumpConfig{
+		SourceConfig: models.DatabaseConfig{Password: "pa$$word"},
+		TargetConfig: &models.DatabaseConfig{Password: "abc$1"},
+	}
+	saved := WithPasswordRefs(cfg)
+	if saved.SourceConfig.Password != SourcePasswordRef || saved.TargetConfig.Password != TargetPasswordRef {
+		t.Errorf("passwords = %q, %q, want references", saved.SourceConfig.Password, saved.TargetConfig.Password)
+	}
+	if cfg.TargetConfig.Password != "abc$1" {
+		t.Error("the target of the original configuration was changed")
+	}
+
+	path := filepath.Join(t.TempDir(), "reltrace.yaml")
+	if err := SaveJob(path, "job", saved); err != nil {
+		t.Fatal(err)
+	}
+	f, err := LoadJobFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.Job("job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.SourceConfig.Password != "source-secret" || got.TargetConfig.Password != "target-secret" {
+		t.Errorf("passwords = %q, %q, want the environment values", got.SourceConfig.Password, got.TargetConfig.Password)
+	}
+
+	// Empty passwords stay empty rather than becoming references
+	if empty := WithPasswordRefs(models.DumpConfig{}); empty.SourceConfig.Password != "" || empty.TargetConfig != nil {
+		t.Errorf("empty configuration became %+v", empty)
+	}
+}
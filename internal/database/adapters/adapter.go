@@ -0,0 +1,370 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+var (
+	// ErrUnsupportedDatabase is returned for database types without an adapter
+	ErrUnsupportedDatabase = errors.New("unsupported database type")
+	// ErrNotConnected is returned when an adapter is used before Connect
+	ErrNotConnected = errors.New("adapter is not connected")
+	// ErrConnectionFailed is returned when the database cannot be reached
+	ErrConnectionFailed = errors.New("connection failed")
+)
+
+// RowFunc receives the values of a single row, in the order of the requested columns
+type RowFunc func(values []any) error
+
+// RowQuery describes the rows to read from a single table
+type RowQuery struct {
+	Table   string
+	Columns []string
+	Where   string // optional predicate, already rendered for the adapter dialect
+	Args    []any
+	OrderBy []string
+	Limit   int     // maximum number of rows, zero for no limit
+	From    Querier // connection to read from, the adapter reader when nil
+}
+
+// Adapter is the database specific implementation every feature is written against
+type Adapter interface {
+	// Type returns the database type handled by the adapter
+	Type() models.DatabaseType
+	// Connect opens the connection and verifies it is reachable
+	Connect(ctx context.Context) error
+	// Close releases the connection
+	Close() error
+	// DB returns the underlying connection pool
+	DB() *sql.DB
+	// Config returns the connection configuration of the adapter
+	Config() models.DatabaseConfig
+
+	// Tables lists the base tables of the connected database
+	Tables(ctx context.Context) ([]string, error)
+	// Describe returns the definition of a table: columns, keys, indexes and constraints
+	Describe(ctx context.Context, table string) (*models.Table, error)
+	// RowEstimates returns the approximate number of rows of every table,
+	// from catalog statistics where the database keeps them
+	RowEstimates(ctx context.Context) (map[string]int64, error)
+	// StreamRows reads rows one by one without buffering the whole result
+	StreamRows(ctx context.Context, q RowQuery, fn RowFunc) error
+
+	// BeginSnapshot makes every following read see the database as it was
+	// at one point in time, until EndSnapshot. The locks mode locks tables
+	// instead, for databases or privileges without snapshot isolation.
+	BeginSnapshot(ctx context.Context, mode models.SnapshotMode, tables []string) error
+	// EndSnapshot releases the open snapshot, if any
+	EndSnapshot() error
+	// ShareSnapshot opens another connection reading the open snapshot, for
+	// parallel readers, or returns ErrSnapshotNotShared. The caller commits
+	// and closes it.
+	ShareSnapshot(ctx context.Context) (*sql.Conn, error)
+	// Reader returns where reads run: the connection holding the open
+	// snapshot, or the connection pool
+	Reader() Querier
+
+	// QuoteIdentifier quotes a table or column name
+	QuoteIdentifier(name string) string
+	// Placeholder returns the bind parameter marker for the n-th argument, starting at 1
+	Placeholder(n int) string
+	// Literal renders a Go value as a SQL literal
+	Literal(v any) string
+	// JSONValue renders the scalar at a path of a quoted JSON column, as
+	// validated by models.ParseJSONPath
+	JSONValue(column, path string) string
+
+	// Preamble returns the session statements starting a dump script
+	Preamble() []string
+	// Postamble returns the session statements ending a dump script
+	Postamble() []string
+	// DropTable renders the statement removing a table if it exists
+	DropTable(table string) string
+	// CreateTable renders the statements creating a table and its indexes,
+	// translating column types declared in the from dialect
+	CreateTable(t *models.Table, from models.DatabaseType) []string
+	// FinishTable renders the statements run once the table data is loaded,
+	// such as adding foreign keys and resetting sequences
+	FinishTable(t *models.Table) []string
+	// Insert renders a multi row INSERT statement
+	Insert(table string, columns []string, rows [][]any) string
+	// Update renders the statement setting columns of the row with a primary key
+	Update(table string, columns []string, values []any, key []string, keyValues []any) string
+}
+
+// New returns the adapter matching the configured database type
+func New(cfg models.DatabaseConfig) (Adapter, error) {
+	switch cfg.Type {
+	case models.MySQL:
+		return NewMySQL(cfg), nil
+	case models.PostgreSQL:
+		return NewPostgreSQL(cfg), nil
+	case models.SQLite3:
+		return NewSQLite(cfg), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedDatabase, cfg.Type)
+	}
+}
+
+// Open creates the adapter for cfg and connects it
+func Open(ctx context.Context, cfg models.DatabaseConfig) (Adapter, error) {
+	a, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Introspect describes every table of the connected database
+func Introspect(ctx context.Context, a Adapter) (*models.Schema, error) {
+	tables, err := a.Tables(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing tables: %w", err)
+	}
+
+	cfg := a.Config()
+	schema := &models.Schema{
+		Type:   a.Type(),
+		Name:   cfg.Database,
+		Tables: make([]models.Table, 0, len(tables)),
+	}
+	if schema.Name == "" {
+		schema.Name = cfg.FilePath
+	}
+	for _, name := range tables {
+		t, err := a.Describe(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("describing %s: %w", name, err)
+		}
+		schema.Tables = append(schema.Tables, *t)
+	}
+	return schema, nil
+}
+
+// base holds the connection state shared by every adapter
+type base struct {
+	cfg  models.DatabaseConfig
+	db   *sql.DB
+	snap *snapshot
+}
+
+// open connects to the database with the given driver and verifies the connection
+func (b *base) open(ctx context.Context, driver, dsn string) error {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return fmt.Errorf("%w: opening %s connection: %w", ErrConnectionFailed, b.cfg.Type, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("%w: connecting to %s: %w", ErrConnectionFailed, b.cfg.Type, err)
+	}
+	b.db = db
+	return nil
+}
+
+// Config returns the connection configuration of the adapter
+func (b *base) Config() models.DatabaseConfig {
+	return b.cfg
+}
+
+// DB returns the underlying connection pool
+func (b *base) DB() *sql.DB {
+	return b.db
+}
+
+// Close releases the snapshot, if any, and the connection
+func (b *base) Close() error {
+	if b.db == nil {
+		return nil
+	}
+	err := errors.Join(b.EndSnapshot(), b.db.Close())
+	b.db = nil
+	return err
+}
+
+// queryStrings runs a query returning a single text column
+func (b *base) queryStrings(ctx context.Context, query string, args ...any) ([]string, error) {
+	if b.db == nil {
+		return nil, ErrNotConnected
+	}
+	rows, err := b.reader().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// queryCounts runs a query returning (name, count) rows
+func (b *base) queryCounts(ctx context.Context, query string, args ...any) (map[string]int64, error) {
+	if b.db == nil {
+		return nil, ErrNotConnected
+	}
+	rows, err := b.reader().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var (
+			name  string
+			count sql.NullInt64
+		)
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, err
+		}
+		counts[name] = count.Int64
+	}
+	return counts, rows.Err()
+}
+
+// streamRows builds the SELECT for q and hands every row to fn. Byte slices
+// are converted to strings unless isBinary reports the column type as binary.
+func (b *base) streamRows(ctx context.Context, a Adapter, q RowQuery, isBinary func(typeName string) bool, fn RowFunc) error {
+	if b.db == nil {
+		return ErrNotConnected
+	}
+
+	from := q.From
+	if from == nil {
+		from = b.reader()
+	}
+	rows, err := from.QueryContext(ctx, selectQuery(a, q), q.Args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	binary := make([]bool, len(types))
+	for i, t := range types {
+		binary[i] = isBinary(strings.ToUpper(t.DatabaseTypeName()))
+	}
+
+	values := make([]any, len(types))
+	ptrs := make([]any, len(types))
+	for rows.Next() {
+		for i := range values {
+			values[i] = nil
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+
+		row := make([]any, len(values))
+		for i, v := range values {
+			if raw, ok := v.([]byte); ok && !binary[i] {
+				row[i] = string(raw)
+			} else {
+				row[i] = v
+			}
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// selectQuery renders the SELECT statement described by q
+func selectQuery(a Adapter, q RowQuery) string {
+	columns := "*"
+	if len(q.Columns) > 0 {
+		quoted := make([]string, len(q.Columns))
+		for i, c := range q.Columns {
+			quoted[i] = a.QuoteIdentifier(c)
+		}
+		columns = strings.Join(quoted, ", ")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "SELECT %s FROM %s", columns, a.QuoteIdentifier(q.Table))
+	if q.Where != "" {
+		b.WriteString(" WHERE " + q.Where)
+	}
+	if len(q.OrderBy) > 0 {
+		quoted := make([]string, len(q.OrderBy))
+		for i, c := range q.OrderBy {
+			quoted[i] = a.QuoteIdentifier(c)
+		}
+		b.WriteString(" ORDER BY " + strings.Join(quoted, ", "))
+	}
+	if q.Limit > 0 {
+		fmt.Fprintf(&b, " LIMIT %d", q.Limit)
+	}
+	return b.String()
+}
+
+// quoteWith wraps an identifier in quote, doubling any embedded quote character
+func quoteWith(name, quote string) string {
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
+
+// literal renders the values every dialect agrees on, delegating strings,
+// booleans and binary data to the dialect specific callbacks
+func literal(v any, str func(string) string, boolean func(bool) string, bin func([]byte) string) string {
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return str(val)
+	case []byte:
+		return bin(val)
+	case bool:
+		return boolean(val)
+	case int:
+		return strconv.Itoa(val)
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(val)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case time.Time:
+		return str(formatTime(val))
+	default:
+		return str(fmt.Sprint(val))
+	}
+}
+
+// formatTime renders a timestamp, dropping the time of day for pure dates
+func formatTime(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+	if t.Nanosecond() != 0 {
+		return t.Format("2006-01-02 15:04:05.999999")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// standardString quotes a string the ANSI way, doubling single quotes
+func standardString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
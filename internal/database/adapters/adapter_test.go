@@ -0,0 +1,162 @@
+package adapters
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// dialects returns an unconnected adapter of every database type
+func dialects() map[models.DatabaseType]Adapter {
+	return map[models.DatabaseType]Adapter{
+		models.MySQL:      NewMySQL(models.DatabaseConfig{Type: models.MySQL}),
+		models.PostgreSQL: NewPostgreSQL(models.DatabaseConfig{Type: models.PostgreSQL}),
+		models.SQLite3:    NewSQLite(models.DatabaseConfig{Type: models.SQLite3}),
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	day := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value any
+		want  map[models.DatabaseType]string
+	}{
+		{nil, same("NULL")},
+		{
+			"O'Brien",
+			map[models.DatabaseType]string{models.MySQL: `'O\'Brien'`, models.PostgreSQL: `'O''Brien'`, models.SQLite3: `'O''Brien'`},
+		},
+		{
+			"a\\b\nc\x00",
+			map[models.DatabaseType]string{
+				models.MySQL:      `'a\\b\nc\0'`,
+				models.PostgreSQL: "'a\\b\nc\x00'",
+				models.SQLite3:    "'a\\b\nc\x00'",
+			},
+		},
+		{
+			[]byte{0xca, 0xfe},
+			map[models.DatabaseType]string{
+				models.MySQL:      "0xcafe",
+				models.PostgreSQL: `'\xcafe'::bytea`,
+				models.SQLite3:    "X'cafe'",
+			},
+		},
+		{
+			[]byte{},
+			map[models.DatabaseType]string{
+				models.MySQL:      "''",
+				models.PostgreSQL: `'\x'::bytea`,
+				models.SQLite3:    "X''",
+			},
+		},
+		{
+			true,
+			map[models.DatabaseType]string{models.MySQL: "1", models.PostgreSQL: "TRUE", models.SQLite3: "1"},
+		},
+		{
+			false,
+			map[models.DatabaseType]string{models.MySQL: "0", models.PostgreSQL: "FALSE", models.SQLite3: "0"},
+		},
+		{42, same("42")},
+		{int64(-7), same("-7")},
+		{uint8(255), same("255")},
+		{float32(0.1), same("0.1")},
+		{1.5e300, same("1.5e+300")},
+		{day, same("'2023-03-01'")},
+		{day.Add(13*time.Hour + 5*time.Second), same("'2023-03-01 13:00:05'")},
+		{day.Add(time.Second + 250*time.Millisecond), same("'2023-03-01 00:00:01.25'")},
+		{models.MySQL, same("'mysql'")},
+	}
+	for _, tt := range tests {
+		for typ, a := range dialects() {
+			if got := a.Literal(tt.value); got != tt.want[typ] {
+				t.Errorf("%s: Literal(%#v) = %s, want %s", typ, tt.value, got, tt.want[typ])
+			}
+		}
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want map[models.DatabaseType]string
+	}{
+		{
+			"orders",
+			map[models.DatabaseType]string{models.MySQL: "`orders`", models.PostgreSQL: `"orders"`, models.SQLite3: `"orders"`},
+		},
+		{
+			"order items",
+			map[models.DatabaseType]string{models.MySQL: "`order items`", models.PostgreSQL: `"order items"`, models.SQLite3: `"order items"`},
+		},
+		{
+			"my`table",
+			map[models.DatabaseType]string{models.MySQL: "`my``table`", models.PostgreSQL: "\"my`table\"", models.SQLite3: "\"my`table\""},
+		},
+		{
+			`say "hi"`,
+			map[models.DatabaseType]string{models.MySQL: "`say \"hi\"`", models.PostgreSQL: `"say ""hi"""`, models.SQLite3: `"say ""hi"""`},
+		},
+	}
+	for _, tt := range tests {
+		for typ, a := range dialects() {
+			if got := a.QuoteIdentifier(tt.name); got != tt.want[typ] {
+				t.Errorf("%s: QuoteIdentifier(%s) = %s, want %s", typ, tt.name, got, tt.want[typ])
+			}
+		}
+	}
+}
+
+// same returns the rendering expected from every dialect
+func same(s string) map[models.DatabaseType]string {
+	return map[models.DatabaseType]string{models.MySQL: s, models.PostgreSQL: s, models.SQLite3: s}
+}
+
+func TestJSONValue(t *testing.T) {
+	tests := []struct {
+		path string
+		want map[models.DatabaseType]string
+	}{
+		{
+			path: "$.client_id",
+			want: map[models.DatabaseType]string{
+				models.MySQL:      `JSON_UNQUOTE(JSON_EXTRACT(c, '$.client_id'))`,
+				models.PostgreSQL: `(c::jsonb ->> 'client_id')`,
+				models.SQLite3:    `json_extract(c, '$.client_id')`,
+			},
+		},
+		{
+			path: "$.customer.id",
+			want: map[models.DatabaseType]string{
+				models.MySQL:      `JSON_UNQUOTE(JSON_EXTRACT(c, '$.customer.id'))`,
+				models.PostgreSQL: `(c::jsonb #>> '{customer,id}')`,
+				models.SQLite3:    `json_extract(c, '$.customer.id')`,
+			},
+		},
+		{
+			path: "$.items[0].sku",
+			want: map[models.DatabaseType]string{
+				models.MySQL:      `JSON_UNQUOTE(JSON_EXTRACT(c, '$.items[0].sku'))`,
+				models.PostgreSQL: `(c::jsonb #>> '{items,0,sku}')`,
+				models.SQLite3:    `json_extract(c, '$.items[0].sku')`,
+			},
+		},
+		{
+			path: "$[2]",
+			want: map[models.DatabaseType]string{
+				models.MySQL:      `JSON_UNQUOTE(JSON_EXTRACT(c, '$[2]'))`,
+				models.PostgreSQL: `(c::jsonb #>> '{2}')`,
+				models.SQLite3:    `json_extract(c, '$[2]')`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		for typ, a := range dialects() {
+			if got := a.JSONValue("c", tt.path); got != tt.want[typ] {
+				t.Errorf("%s: JSONValue(%s) = %s, want %s", typ, tt.path, got, tt.want[typ])
+			}
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package adapters
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// tableOptions controls the dialect specific parts of a CREATE TABLE statement
+type tableOptions struct {
+	inlinePrimaryKey  bool   // the primary key is part of a column definition
+	inlineForeignKeys bool   // foreign keys are declared in the table body
+	suffix            string // appended after the closing parenthesis
+}
+
+// createTable renders the CREATE TABLE statement of t followed by its indexes.
+// Checks and constraint names are only kept when the table comes from the
+// same dialect, as their expressions and naming scopes are not portable.
+func createTable(a Adapter, t *models.Table, from models.DatabaseType, column func(models.Column) string, opts tableOptions) []string {
+	same := from == a.Type()
+
+	var lines []string
+	for _, c := range t.Columns {
+		lines = append(lines, a.QuoteIdentifier(c.Name)+" "+column(c))
+	}
+	if len(t.PrimaryKey) > 0 && !opts.inlinePrimaryKey {
+		lines = append(lines, "PRIMARY KEY ("+quoteList(a, t.PrimaryKey)+")")
+	}
+	for _, u := range t.Uniques {
+		lines = append(lines, constraintName(a, u.Name, same)+"UNIQUE ("+quoteList(a, u.Columns)+")")
+	}
+	for _, c := range t.Columns {
+		if len(c.EnumValues) > 0 && a.Type() != models.MySQL {
+			lines = append(lines, "CHECK ("+enumCheck(a, c)+")")
+		}
+	}
+	if same {
+		for _, c := range t.Checks {
+			lines = append(lines, constraintName(a, c.Name, same)+"CHECK ("+c.Expression+")")
+		}
+	}
+	if opts.inlineForeignKeys {
+		for _, fk := range t.ForeignKeys {
+			if fk.Virtual {
+				continue
+			}
+			lines = append(lines, foreignKeyClause(a, fk))
+		}
+	}
+
+	stmts := []string{"CREATE TABLE " + a.QuoteIdentifier(t.Name) + " (\n  " +
+		strings.Join(lines, ",\n  ") + "\n)" + opts.suffix}
+
+	for _, idx := range t.Indexes {
+		if stmt, ok := createIndex(a, t, idx, same); ok {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// createIndex renders a CREATE INDEX statement. Index names are prefixed with
+// the table name across dialects because some engines scope them per schema.
+func createIndex(a Adapter, t *models.Table, idx models.Index, same bool) (string, bool) {
+	parts := make([]string, len(idx.Columns))
+	for i, c := range idx.Columns {
+		if t.Column(c) != nil {
+			parts[i] = a.QuoteIdentifier(c)
+			continue
+		}
+		// Expression indexes are only portable within the same dialect
+		if !same {
+			return "", false
+		}
+		parts[i] = c
+	}
+
+	name := idx.Name
+	if !same {
+		name = t.Name + "_" + idx.Name
+	}
+	unique := ""
+	if idx.Unique {
+		unique = "UNIQUE "
+	}
+	return "CREATE " + unique + "INDEX " + a.QuoteIdentifier(name) + " ON " +
+		a.QuoteIdentifier(t.Name) + " (" + strings.Join(parts, ", ") + ")", true
+}
+
+// foreignKeyClause renders the constraint definition of a foreign key
+func foreignKeyClause(a Adapter, fk models.ForeignKey) string {
+	var b strings.Builder
+	b.WriteString(constraintName(a, fk.Name, true))
+	b.WriteString("FOREIGN KEY (" + quoteList(a, fk.Columns) + ") REFERENCES ")
+	b.WriteString(a.QuoteIdentifier(fk.RefTable) + " (" + quoteList(a, fk.RefColumns) + ")")
+	if fk.OnDelete != "" && fk.OnDelete != models.NoAction {
+		b.WriteString(" ON DELETE " + string(fk.OnDelete))
+	}
+	if fk.OnUpdate != "" && fk.OnUpdate != models.NoAction {
+		b.WriteString(" ON UPDATE " + string(fk.OnUpdate))
+	}
+	return b.String()
+}
+
+// addForeignKeys renders one ALTER TABLE statement per declared foreign key of t
+func addForeignKeys(a Adapter, t *models.Table) []string {
+	stmts := make([]string, 0, len(t.ForeignKeys))
+	for _, fk := range t.ForeignKeys {
+		if fk.Virtual {
+			continue
+		}
+		stmts = append(stmts, "ALTER TABLE "+a.QuoteIdentifier(t.Name)+" ADD "+foreignKeyClause(a, fk))
+	}
+	return stmts
+}
+
+// constraintName renders the optional CONSTRAINT prefix of a table constraint
+func constraintName(a Adapter, name string, keep bool) string {
+	if !keep || name == "" || strings.HasPrefix(name, "sqlite_") {
+		return ""
+	}
+	return "CONSTRAINT " + a.QuoteIdentifier(name) + " "
+}
+
+// enumCheck renders the predicate emulating an enum column in dialects without enums
+func enumCheck(a Adapter, c models.Column) string {
+	values := make([]string, len(c.EnumValues))
+	for i, v := range c.EnumValues {
+		values[i] = a.Literal(v)
+	}
+	return a.QuoteIdentifier(c.Name) + " IN (" + strings.Join(values, ", ") + ")"
+}
+
+// insert renders a multi row INSERT statement
+func insert(a Adapter, table string, columns []string, rows [][]any) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO " + a.QuoteIdentifier(table) + " (" + quoteList(a, columns) + ") VALUES")
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString("\n  (")
+		for j, v := range row {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(a.Literal(v))
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+// update renders an UPDATE statement setting columns of a single row
+func update(a Adapter, table string, columns []string, values []any, key []string, keyValues []any) string {
+	set := make([]string, len(columns))
+	for i, c := range columns {
+		set[i] = a.QuoteIdentifier(c) + " = " + a.Literal(values[i])
+	}
+	where := make([]string, len(key))
+	for i, c := range key {
+		where[i] = a.QuoteIdentifier(c) + " = " + a.Literal(keyValues[i])
+	}
+	return "UPDATE " + a.QuoteIdentifier(table) + " SET " + strings.Join(set, ", ") + " WHERE " + strings.Join(where, " AND ")
+}
+
+// indexed reports whether a column is part of a key or index of the table
+func indexed(t *models.Table, column string) bool {
+	if t.IsPrimaryKey(column) {
+		return true
+	}
+	for _, u := range t.Uniques {
+		for _, c := range u.Columns {
+			if c == column {
+				return true
+			}
+		}
+	}
+	for _, idx := range t.Indexes {
+		for _, c := range idx.Columns {
+			if c == column {
+				return true
+			}
+		}
+	}
+	for _, fk := range t.ForeignKeys {
+		if fk.Virtual {
+			continue
+		}
+		for _, c := range fk.Columns {
+			if c == column {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// translateDefault keeps a default untouched within a dialect and makes it
+// portable otherwise
+func translateDefault(expr string, from, to models.DatabaseType) (string, bool) {
+	if from == to {
+		return expr, true
+	}
+	return portableDefault(expr)
+}
+
+// quoteList quotes and joins a list of identifiers
+func quoteList(a Adapter, names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = a.QuoteIdentifier(n)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// typeModifiers returns the parenthesised modifiers of a declared type, e.g. "(10,2)"
+func typeModifiers(declared string) string {
+	open := strings.IndexByte(declared, '(')
+	end := strings.LastIndexByte(declared, ')')
+	if open < 0 || end < open {
+		return ""
+	}
+	return declared[open : end+1]
+}
+
+var (
+	castSuffix     = regexp.MustCompile(`^(.*?)::[a-zA-Z][\w ."\[\]()]*$`)
+	numericLiteral = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+)
+
+// portableDefault translates a default expression written for another dialect,
+// reporting false when it cannot be expressed portably and must be dropped
+func portableDefault(expr string) (string, bool) {
+	expr = strings.TrimSpace(expr)
+	if m := castSuffix.FindStringSubmatch(expr); m != nil {
+		expr = strings.TrimSpace(m[1])
+	}
+	for strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+
+	upper := strings.ToUpper(expr)
+	switch {
+	case upper == "NULL", upper == "TRUE", upper == "FALSE":
+		return upper, true
+	case upper == "CURRENT_DATE":
+		return upper, true
+	case strings.HasPrefix(upper, "CURRENT_TIMESTAMP"), upper == "NOW()", upper == "LOCALTIMESTAMP":
+		return "CURRENT_TIMESTAMP", true
+	case numericLiteral.MatchString(expr):
+		return expr, true
+	case len(expr) >= 2 && expr[0] == '\'' && expr[len(expr)-1] == '\'':
+		return expr, true
+	default:
+		return "", false
+	}
+}
+
+// isTextType reports whether a base type name holds character data
+func isTextType(dataType string) bool {
+	switch dataType {
+	case "char", "character", "varchar", "character varying", "nchar", "nvarchar",
+		"text", "tinytext", "mediumtext", "longtext", "clob", "string", "citext", "":
+		return true
+	default:
+		return false
+	}
+}
+
+// isBinaryType reports whether a base type name holds raw bytes
+func isBinaryType(dataType string) bool {
+	switch dataType {
+	case "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "bytea":
+		return true
+	default:
+		return false
+	}
+}
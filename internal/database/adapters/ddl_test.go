@@ -0,0 +1,184 @@
+package adapters
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// ordersTable returns a table declared in MySQL using every part of the DDL
+func ordersTable() *models.Table {
+	now, fresh, zero := "CURRENT_TIMESTAMP", "'new'", "0.00"
+	return &models.Table{
+		Name:    "orders",
+		Comment: "Customer orders",
+		Columns: []models.Column{
+			{Name: "id", Type: "int", DataType: "int", AutoIncrement: true},
+			{Name: "customer_id", Type: "int", DataType: "int"},
+			{Name: "status", Type: "enum('new','paid')", DataType: "enum", EnumValues: []string{"new", "paid"}, Default: &fresh},
+			{Name: "total", Type: "decimal(10,2)", DataType: "decimal", Default: &zero},
+			{Name: "note", Type: "text", DataType: "text", Nullable: true, Comment: "Free text"},
+			{Name: "created_at", Type: "datetime", DataType: "datetime", Default: &now},
+		},
+		PrimaryKey: []string{"id"},
+		ForeignKeys: []models.ForeignKey{
+			{Name: "fk_customer", Table: "orders", Columns: []string{"customer_id"}, RefTable: "customers", RefColumns: []string{"id"}, OnDelete: models.Cascade},
+		},
+		Uniques: []models.UniqueConstraint{{Name: "uq_customer_created", Columns: []string{"customer_id", "created_at"}}},
+		Indexes: []models.Index{{Name: "idx_status", Columns: []string{"status"}}, {Name: "idx_lower", Columns: []string{"lower(note)"}}},
+		Checks:  []models.CheckConstraint{{Name: "ck_total", Expression: "total >= 0"}},
+	}
+}
+
+func TestCreateTable(t *testing.T) {
+	tests := []struct {
+		name   string
+		typ    models.DatabaseType
+		from   models.DatabaseType
+		create []string
+		finish []string
+	}{
+		{
+			name: "mysql kept as declared",
+			typ:  models.MySQL,
+			from: models.MySQL,
+			create: []string{
+				"CREATE TABLE `orders` (\n" +
+					"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+					"  `customer_id` int NOT NULL,\n" +
+					"  `status` enum('new','paid') NOT NULL DEFAULT 'new',\n" +
+					"  `total` decimal(10,2) NOT NULL DEFAULT 0.00,\n" +
+					"  `note` text COMMENT 'Free text',\n" +
+					"  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+					"  PRIMARY KEY (`id`),\n" +
+					"  CONSTRAINT `uq_customer_created` UNIQUE (`customer_id`, `created_at`),\n" +
+					"  CONSTRAINT `ck_total` CHECK (total >= 0)\n" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Customer orders'",
+				"CREATE INDEX `idx_status` ON `orders` (`status`)",
+				"CREATE INDEX `idx_lower` ON `orders` (lower(note))",
+			},
+			finish: []string{
+				"ALTER TABLE `orders` ADD CONSTRAINT `fk_customer` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`) ON DELETE CASCADE",
+			},
+		},
+		{
+			name: "mysql from postgresql",
+			typ:  models.MySQL,
+			from: models.PostgreSQL,
+			create: []string{
+				"CREATE TABLE `orders` (\n" +
+					"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+					"  `customer_id` int NOT NULL,\n" +
+					"  `status` enum('new','paid') NOT NULL DEFAULT 'new',\n" +
+					"  `total` decimal(10,2) NOT NULL DEFAULT 0.00,\n" +
+					"  `note` longtext COMMENT 'Free text',\n" +
+					"  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+					"  PRIMARY KEY (`id`),\n" +
+					"  UNIQUE (`customer_id`, `created_at`)\n" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Customer orders'",
+				"CREATE INDEX `orders_idx_status` ON `orders` (`status`)",
+			},
+			finish: []string{
+				"ALTER TABLE `orders` ADD CONSTRAINT `fk_customer` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`) ON DELETE CASCADE",
+			},
+		},
+		{
+			name: "postgresql from mysql",
+			typ:  models.PostgreSQL,
+			from: models.MySQL,
+			create: []string{
+				`CREATE TABLE "orders" (` + "\n" +
+					`  "id" integer GENERATED BY DEFAULT AS IDENTITY NOT NULL,` + "\n" +
+					`  "customer_id" integer NOT NULL,` + "\n" +
+					`  "status" text DEFAULT 'new' NOT NULL,` + "\n" +
+					`  "total" numeric(10,2) DEFAULT 0.00 NOT NULL,` + "\n" +
+					`  "note" text,` + "\n" +
+					`  "created_at" timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,` + "\n" +
+					`  PRIMARY KEY ("id"),` + "\n" +
+					`  UNIQUE ("customer_id", "created_at"),` + "\n" +
+					`  CHECK ("status" IN ('new', 'paid'))` + "\n" +
+					`)`,
+				`CREATE INDEX "orders_idx_status" ON "orders" ("status")`,
+				`COMMENT ON TABLE "orders" IS 'Customer orders'`,
+				`COMMENT ON COLUMN "orders"."note" IS 'Free text'`,
+			},
+			finish: []string{
+				`ALTER TABLE "orders" ADD CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "customers" ("id") ON DELETE CASCADE`,
+				`SELECT setval(pg_get_serial_sequence('"orders"', 'id'), COALESCE((SELECT MAX("id") FROM "orders"), 0) + 1, false)`,
+			},
+		},
+		{
+			name: "sqlite from mysql",
+			typ:  models.SQLite3,
+			from: models.MySQL,
+			create: []string{
+				`CREATE TABLE "orders" (` + "\n" +
+					`  "id" INTEGER PRIMARY KEY,` + "\n" +
+					`  "customer_id" INTEGER NOT NULL,` + "\n" +
+					`  "status" TEXT NOT NULL DEFAULT 'new',` + "\n" +
+					`  "total" NUMERIC NOT NULL DEFAULT 0.00,` + "\n" +
+					`  "note" TEXT,` + "\n" +
+					`  "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,` + "\n" +
+					`  UNIQUE ("customer_id", "created_at"),` + "\n" +
+					`  CHECK ("status" IN ('new', 'paid')),` + "\n" +
+					`  CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "customers" ("id") ON DELETE CASCADE` + "\n" +
+					`)`,
+				`CREATE INDEX "orders_idx_status" ON "orders" ("status")`,
+			},
+		},
+		{
+			name: "sqlite kept as declared",
+			typ:  models.SQLite3,
+			from: models.SQLite3,
+			create: []string{
+				`CREATE TABLE "orders" (` + "\n" +
+					`  "id" INTEGER PRIMARY KEY,` + "\n" +
+					`  "customer_id" int NOT NULL,` + "\n" +
+					`  "status" enum('new','paid') NOT NULL DEFAULT 'new',` + "\n" +
+					`  "total" decimal(10,2) NOT NULL DEFAULT 0.00,` + "\n" +
+					`  "note" text,` + "\n" +
+					`  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,` + "\n" +
+					`  CONSTRAINT "uq_customer_created" UNIQUE ("customer_id", "created_at"),` + "\n" +
+					`  CHECK ("status" IN ('new', 'paid')),` + "\n" +
+					`  CONSTRAINT "ck_total" CHECK (total >= 0),` + "\n" +
+					`  CONSTRAINT "fk_customer" FOREIGN KEY ("customer_id") REFERENCES "customers" ("id") ON DELETE CASCADE` + "\n" +
+					`)`,
+				`CREATE INDEX "idx_status" ON "orders" ("status")`,
+				`CREATE INDEX "idx_lower" ON "orders" (lower(note))`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := dialects()[tt.typ]
+			if got := a.CreateTable(ordersTable(), tt.from); !slices.Equal(got, tt.create) {
+				t.Errorf("CreateTable:\n%s\nwant:\n%s", strings.Join(got, ";\n"), strings.Join(tt.create, ";\n"))
+			}
+			if got := a.FinishTable(ordersTable()); !slices.Equal(got, tt.finish) {
+				t.Errorf("FinishTable:\n%s\nwant:\n%s", strings.Join(got, ";\n"), strings.Join(tt.finish, ";\n"))
+			}
+		})
+	}
+}
+
+func TestCreateTableVirtualKeys(t *testing.T) {
+	// Virtual keys are not declared, and do not index their columns either:
+	// note stays unbounded text in MySQL
+	virtual := ordersTable()
+	virtual.ForeignKeys = append(virtual.ForeignKeys,
+		models.ForeignKey{Table: "orders", Columns: []string{"note"}, RefTable: "notes", RefColumns: []string{"id"}, Virtual: true},
+		models.ForeignKey{Table: "orders", Columns: []string{"customer_id"}, RefTable: "accounts", RefColumns: []string{"id"}, Virtual: true, TypeColumn: "status", TypeValue: "paid"},
+	)
+	for typ, a := range dialects() {
+		for _, from := range []models.DatabaseType{models.MySQL, models.PostgreSQL, models.SQLite3} {
+			if got, want := a.CreateTable(virtual, from), a.CreateTable(ordersTable(), from); !slices.Equal(got, want) {
+				t.Errorf("%s from %s: CreateTable:\n%s\nwant:\n%s", typ, from, strings.Join(got, ";\n"), strings.Join(want, ";\n"))
+			}
+		}
+		if got, want := a.FinishTable(virtual), a.FinishTable(ordersTable()); !slices.Equal(got, want) {
+			t.Errorf("%s: FinishTable:\n%s\nwant:\n%s", typ, strings.Join(got, ";\n"), strings.Join(want, ";\n"))
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package adapters
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQLAdapter implements Adapter for MySQL and MariaDB
+type MySQLAdapter struct {
+	base
+}
+
+// NewMySQL creates a new MySQL adapter
+func NewMySQL(cfg models.DatabaseConfig) *MySQLAdapter {
+	return &MySQLAdapter{base: base{cfg: cfg}}
+}
+
+// Type returns the database type handled by the adapter
+func (a *MySQLAdapter) Type() models.DatabaseType {
+	return models.MySQL
+}
+
+// Connect opens the connection and verifies it is reachable
+func (a *MySQLAdapter) Connect(ctx context.Context) error {
+	return a.open(ctx, "mysql", a.dsn())
+}
+
+// dsn builds the driver connection string from the configuration
+func (a *MySQLAdapter) dsn() string {
+	port := a.cfg.Port
+	if port == "" {
+		port = "3306"
+	}
+
+	c := mysql.NewConfig()
+	c.User = a.cfg.User
+	c.Passwd = a.cfg.Password
+	c.Net = "tcp"
+	c.Addr = net.JoinHostPort(a.cfg.Host, port)
+	c.DBName = a.cfg.Database
+	c.ParseTime = true
+	c.Params = map[string]string{"charset": "utf8mb4"}
+	return c.FormatDSN()
+}
+
+// Tables lists the base tables of the connected database
+func (a *MySQLAdapter) Tables(ctx context.Context) ([]string, error) {
+	return a.queryStrings(ctx, `
+		SELECT table_name FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'
+		ORDER BY table_name`)
+}
+
+// RowEstimates returns the row counts InnoDB keeps in information_schema, which
+// are sampled and may be off by a large margin on busy tables
+func (a *MySQLAdapter) RowEstimates(ctx context.Context) (map[string]int64, error) {
+	return a.queryCounts(ctx, `
+		SELECT table_name, table_rows FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'`)
+}
+
+// StreamRows reads rows one by one without buffering the whole result
+func (a *MySQLAdapter) StreamRows(ctx context.Context, q RowQuery, fn RowFunc) error {
+	return a.streamRows(ctx, a, q, isMySQLBinary, fn)
+}
+
+// isMySQLBinary reports whether a MySQL column type holds raw bytes
+func isMySQLBinary(typeName string) bool {
+	return strings.Contains(typeName, "BLOB") || strings.Contains(typeName, "BINARY") || typeName == "BIT" || typeName == "GEOMETRY"
+}
+
+// QuoteIdentifier quotes a table or column name
+func (a *MySQLAdapter) QuoteIdentifier(name string) string {
+	return quoteWith(name, "`")
+}
+
+// Placeholder returns the bind parameter marker for the n-th argument
+func (a *MySQLAdapter) Placeholder(n int) string {
+	return "?"
+}
+
+// JSONValue renders the scalar at a path of a JSON column
+func (a *MySQLAdapter) JSONValue(column, path string) string {
+	return "JSON_UNQUOTE(JSON_EXTRACT(" + column + ", " + a.Literal(path) + "))"
+}
+
+// Literal renders a Go value as a SQL literal
+func (a *MySQLAdapter) Literal(v any) string {
+	return literal(v, mysqlString, func(b bool) string {
+		if b {
+			return "1"
+		}
+		return "0"
+	}, func(b []byte) string {
+		if len(b) == 0 {
+			return "''"
+		}
+		return "0x" + hex.EncodeToString(b)
+	})
+}
+
+// mysqlString quotes a string, escaping the characters MySQL treats specially
+func mysqlString(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case 0:
+			b.WriteString(`\0`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\x1a':
+			b.WriteString(`\Z`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// BeginSnapshot makes every read of the MySQL adapter run in a single
+// REPEATABLE READ transaction started WITH CONSISTENT SNAPSHOT, or under
+// READ locks on tables, until EndSnapshot
+func (a *MySQLAdapter) BeginSnapshot(ctx context.Context, mode models.SnapshotMode, tables []string) error {
+	switch mode.OrDefault() {
+	case models.SnapshotTransaction:
+		return a.beginSnapshot(ctx, []string{
+			"SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ",
+			"START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY",
+		}, []string{"COMMIT"})
+	case models.SnapshotLocks:
+		if len(tables) == 0 {
+			return nil
+		}
+		return a.beginSnapshot(ctx, []string{"LOCK TABLES " + lockList(a, tables, " READ")}, []string{"UNLOCK TABLES"})
+	default:
+		return nil
+	}
+}
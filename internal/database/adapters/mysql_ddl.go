@@ -0,0 +1,133 @@
+package adapters
+
+import (
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Preamble returns the session statements starting a dump script
+func (a *MySQLAdapter) Preamble() []string {
+	return []string{
+		"SET NAMES utf8mb4",
+		"SET SQL_MODE = 'NO_AUTO_VALUE_ON_ZERO'",
+		"SET FOREIGN_KEY_CHECKS = 0",
+	}
+}
+
+// Postamble returns the session statements ending a dump script
+func (a *MySQLAdapter) Postamble() []string {
+	return []string{"SET FOREIGN_KEY_CHECKS = 1"}
+}
+
+// DropTable renders the statement removing a table if it exists
+func (a *MySQLAdapter) DropTable(table string) string {
+	return "DROP TABLE IF EXISTS " + a.QuoteIdentifier(table)
+}
+
+// CreateTable renders the statements creating a table and its indexes
+func (a *MySQLAdapter) CreateTable(t *models.Table, from models.DatabaseType) []string {
+	suffix := " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+	if t.Comment != "" {
+		suffix += " COMMENT=" + a.Literal(t.Comment)
+	}
+
+	return createTable(a, t, from, func(c models.Column) string {
+		var b strings.Builder
+		b.WriteString(mysqlColumnType(c, from, indexed(t, c.Name)))
+		if !c.Nullable {
+			b.WriteString(" NOT NULL")
+		}
+		if c.Default != nil {
+			if expr, ok := translateDefault(*c.Default, from, models.MySQL); ok {
+				b.WriteString(" DEFAULT " + expr)
+			}
+		}
+		if c.OnUpdate != "" {
+			b.WriteString(" ON UPDATE " + c.OnUpdate)
+		}
+		if c.AutoIncrement {
+			b.WriteString(" AUTO_INCREMENT")
+		}
+		if c.Comment != "" {
+			b.WriteString(" COMMENT " + a.Literal(c.Comment))
+		}
+		return b.String()
+	}, tableOptions{suffix: suffix})
+}
+
+// FinishTable adds the foreign keys once the data is loaded
+func (a *MySQLAdapter) FinishTable(t *models.Table) []string {
+	return addForeignKeys(a, t)
+}
+
+// Insert renders a multi row INSERT statement
+func (a *MySQLAdapter) Insert(table string, columns []string, rows [][]any) string {
+	return insert(a, table, columns, rows)
+}
+
+// Update renders the statement setting columns of the row with a primary key
+func (a *MySQLAdapter) Update(table string, columns []string, values []any, key []string, keyValues []any) string {
+	return update(a, table, columns, values, key, keyValues)
+}
+
+// mysqlColumnType translates a column type declared in another dialect.
+// Indexed text columns become VARCHAR, since MySQL cannot index unbounded TEXT.
+func mysqlColumnType(c models.Column, from models.DatabaseType, indexed bool) string {
+	if from == models.MySQL {
+		return c.Type
+	}
+	if len(c.EnumValues) > 0 {
+		values := make([]string, len(c.EnumValues))
+		for i, v := range c.EnumValues {
+			values[i] = mysqlString(v)
+		}
+		return "enum(" + strings.Join(values, ",") + ")"
+	}
+
+	mods := typeModifiers(c.Type)
+	switch dt := c.DataType; {
+	case dt == "boolean" || dt == "bool":
+		return "tinyint(1)"
+	case dt == "smallint" || dt == "int2" || dt == "smallserial":
+		return "smallint"
+	case dt == "bigint" || dt == "int8" || dt == "bigserial":
+		return "bigint"
+	case IsIntegerType(dt):
+		return "int"
+	case dt == "real" || dt == "float4" || dt == "float":
+		return "float"
+	case dt == "double precision" || dt == "float8" || dt == "double":
+		return "double"
+	case dt == "numeric" || dt == "decimal":
+		return "decimal" + mods
+	case dt == "character varying" || dt == "varchar":
+		if mods == "" {
+			return textType(indexed)
+		}
+		return "varchar" + mods
+	case dt == "character" || dt == "char":
+		return "char" + mods
+	case dt == "date":
+		return "date"
+	case strings.HasPrefix(dt, "timestamp") || dt == "datetime":
+		return "datetime"
+	case strings.HasPrefix(dt, "time"):
+		return "time"
+	case dt == "json" || dt == "jsonb":
+		return "json"
+	case dt == "uuid":
+		return "char(36)"
+	case isBinaryType(dt):
+		return "longblob"
+	default:
+		return textType(indexed)
+	}
+}
+
+func textType(indexed bool) string {
+	if indexed {
+		return "varchar(255)"
+	}
+	return "longtext"
+}
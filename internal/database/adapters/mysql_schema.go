@@ -0,0 +1,283 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Describe returns the full definition of a table read from information_schema
+func (a *MySQLAdapter) Describe(ctx context.Context, table string) (*models.Table, error) {
+	if a.db == nil {
+		return nil, ErrNotConnected
+	}
+	t := &models.Table{Name: table}
+
+	err := a.db.QueryRowContext(ctx, `
+		SELECT table_comment FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = ?`, table).Scan(&t.Comment)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Columns, err = a.columns(ctx, table); err != nil {
+		return nil, err
+	}
+
+	t.PrimaryKey, err = a.queryStrings(ctx, `
+		SELECT column_name FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE() AND table_name = ? AND constraint_name = 'PRIMARY'
+		ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.ForeignKeys, err = a.foreignKeys(ctx, table); err != nil {
+		return nil, err
+	}
+	if t.Uniques, err = a.uniques(ctx, table); err != nil {
+		return nil, err
+	}
+	if t.Indexes, err = a.indexes(ctx, table, t.Uniques); err != nil {
+		return nil, err
+	}
+	if t.Checks, err = a.checks(ctx, table); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// columns reads the ordered column definitions of a table
+func (a *MySQLAdapter) columns(ctx context.Context, table string) ([]models.Column, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT column_name, column_type, data_type, is_nullable, column_default, extra, column_comment
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ?
+		ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []models.Column
+	for rows.Next() {
+		var (
+			c               models.Column
+			nullable, extra string
+			dflt            sql.NullString
+		)
+		if err := rows.Scan(&c.Name, &c.Type, &c.DataType, &nullable, &dflt, &extra, &c.Comment); err != nil {
+			return nil, err
+		}
+		c.DataType = strings.ToLower(c.DataType)
+		c.Nullable = nullable == "YES"
+		c.Default = mysqlDefault(dflt, extra, c.DataType)
+
+		lowerExtra := strings.ToLower(extra)
+		c.AutoIncrement = strings.Contains(lowerExtra, "auto_increment")
+		if i := strings.Index(lowerExtra, "on update "); i >= 0 {
+			c.OnUpdate = strings.ToUpper(strings.TrimSpace(extra[i+len("on update "):]))
+		}
+		if c.DataType == "enum" || c.DataType == "set" {
+			c.EnumValues = parseEnumValues(c.Type)
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// mysqlDefault turns information_schema.columns.column_default into a SQL
+// expression. MySQL reports literal defaults unquoted, while expressions are
+// flagged with DEFAULT_GENERATED; MariaDB already reports quoted literals.
+func mysqlDefault(dflt sql.NullString, extra, dataType string) *string {
+	if !dflt.Valid {
+		return nil
+	}
+	v := dflt.String
+	upper := strings.ToUpper(v)
+
+	var expr string
+	switch {
+	case strings.HasPrefix(upper, "CURRENT_TIMESTAMP") || strings.HasPrefix(upper, "NOW("):
+		expr = v
+	case strings.Contains(strings.ToUpper(extra), "DEFAULT_GENERATED"):
+		expr = "(" + v + ")"
+	case strings.HasPrefix(v, "'") || upper == "NULL":
+		expr = v
+	case IsNumericType(dataType) || strings.HasPrefix(upper, "B'"):
+		expr = v
+	default:
+		expr = mysqlString(v)
+	}
+	return &expr
+}
+
+// foreignKeys reads the foreign keys declared on a table with their actions
+func (a *MySQLAdapter) foreignKeys(ctx context.Context, table string) ([]models.ForeignKey, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT k.constraint_name, k.column_name, k.referenced_table_name, k.referenced_column_name,
+			r.update_rule, r.delete_rule
+		FROM information_schema.key_column_usage k
+		JOIN information_schema.referential_constraints r
+			ON r.constraint_schema = k.constraint_schema
+			AND r.constraint_name = k.constraint_name
+			AND r.table_name = k.table_name
+		WHERE k.table_schema = DATABASE() AND k.table_name = ? AND k.referenced_table_name IS NOT NULL
+		ORDER BY k.constraint_name, k.ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []models.ForeignKey
+	for rows.Next() {
+		var name, column, refTable, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(&name, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+		if n := len(fks); n > 0 && fks[n-1].Name == name {
+			fks[n-1].Columns = append(fks[n-1].Columns, column)
+			fks[n-1].RefColumns = append(fks[n-1].RefColumns, refColumn)
+			continue
+		}
+		fks = append(fks, models.ForeignKey{
+			Name:       name,
+			Table:      table,
+			Columns:    []string{column},
+			RefTable:   refTable,
+			RefColumns: []string{refColumn},
+			OnDelete:   models.ParseReferentialAction(onDelete),
+			OnUpdate:   models.ParseReferentialAction(onUpdate),
+		})
+	}
+	return fks, rows.Err()
+}
+
+// uniques reads the unique constraints of a table
+func (a *MySQLAdapter) uniques(ctx context.Context, table string) ([]models.UniqueConstraint, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT tc.constraint_name, k.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage k
+			ON k.constraint_schema = tc.constraint_schema
+			AND k.constraint_name = tc.constraint_name
+			AND k.table_name = tc.table_name
+		WHERE tc.table_schema = DATABASE() AND tc.table_name = ? AND tc.constraint_type = 'UNIQUE'
+		ORDER BY tc.constraint_name, k.ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := groupColumns(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	uniques := make([]models.UniqueConstraint, len(groups))
+	for i, g := range groups {
+		uniques[i] = models.UniqueConstraint{Name: g.name, Columns: g.columns}
+	}
+	return uniques, nil
+}
+
+// indexes reads the secondary indexes of a table, skipping the primary key
+// and the indexes backing unique constraints
+func (a *MySQLAdapter) indexes(ctx context.Context, table string, uniques []models.UniqueConstraint) ([]models.Index, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT index_name, non_unique, column_name
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = ? AND index_name <> 'PRIMARY'
+			AND column_name IS NOT NULL
+		ORDER BY index_name, seq_in_index`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	backing := make(map[string]bool, len(uniques))
+	for _, u := range uniques {
+		backing[u.Name] = true
+	}
+
+	var indexes []models.Index
+	for rows.Next() {
+		var name, column string
+		var nonUnique int
+		if err := rows.Scan(&name, &nonUnique, &column); err != nil {
+			return nil, err
+		}
+		if backing[name] {
+			continue
+		}
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			continue
+		}
+		indexes = append(indexes, models.Index{Name: name, Columns: []string{column}, Unique: nonUnique == 0})
+	}
+	return indexes, rows.Err()
+}
+
+// checks reads the check constraints of a table. Servers older than MySQL
+// 8.0.16 have no check_constraints view and report none.
+func (a *MySQLAdapter) checks(ctx context.Context, table string) ([]models.CheckConstraint, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT cc.constraint_name, cc.check_clause
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.check_constraints cc
+			ON cc.constraint_schema = tc.constraint_schema
+			AND cc.constraint_name = tc.constraint_name
+		WHERE tc.table_schema = DATABASE() AND tc.table_name = ? AND tc.constraint_type = 'CHECK'
+		ORDER BY cc.constraint_name`, table)
+	if err != nil {
+		return nil, nil
+	}
+	defer rows.Close()
+
+	var checks []models.CheckConstraint
+	for rows.Next() {
+		var c models.CheckConstraint
+		if err := rows.Scan(&c.Name, &c.Expression); err != nil {
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
+
+// parseEnumValues extracts the values of an enum('a','b') or set(...) column type
+func parseEnumValues(columnType string) []string {
+	open := strings.IndexByte(columnType, '(')
+	end := strings.LastIndexByte(columnType, ')')
+	if open < 0 || end <= open {
+		return nil
+	}
+
+	var (
+		values  []string
+		current strings.Builder
+		quoted  bool
+	)
+	body := columnType[open+1 : end]
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '\'' && quoted && i+1 < len(body) && body[i+1] == '\'':
+			current.WriteByte('\'')
+			i++
+		case c == '\\' && quoted && i+1 < len(body):
+			current.WriteByte(body[i+1])
+			i++
+		case c == '\'':
+			if quoted {
+				values = append(values, current.String())
+				current.Reset()
+			}
+			quoted = !quoted
+		case quoted:
+			current.WriteByte(c)
+		}
+	}
+	return values
+}
@@ -0,0 +1,165 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	_ "github.com/lib/pq"
+)
+
+// PostgreSQLAdapter implements Adapter for PostgreSQL
+type PostgreSQLAdapter struct {
+	base
+}
+
+// NewPostgreSQL creates a new PostgreSQL adapter
+func NewPostgreSQL(cfg models.DatabaseConfig) *PostgreSQLAdapter {
+	return &PostgreSQLAdapter{base: base{cfg: cfg}}
+}
+
+// Type returns the database type handled by the adapter
+func (a *PostgreSQLAdapter) Type() models.DatabaseType {
+	return models.PostgreSQL
+}
+
+// Connect opens the connection and verifies it is reachable
+func (a *PostgreSQLAdapter) Connect(ctx context.Context) error {
+	return a.open(ctx, "postgres", a.dsn())
+}
+
+// dsn builds the driver connection string from the configuration. SSL is
+// disabled unless PGSSLMODE is set in the environment.
+func (a *PostgreSQLAdapter) dsn() string {
+	port := a.cfg.Port
+	if port == "" {
+		port = "5432"
+	}
+
+	params := []string{
+		"host=" + pqValue(a.cfg.Host),
+		"port=" + pqValue(port),
+		"user=" + pqValue(a.cfg.User),
+		"password=" + pqValue(a.cfg.Password),
+		"dbname=" + pqValue(a.cfg.Database),
+	}
+	if os.Getenv("PGSSLMODE") == "" {
+		params = append(params, "sslmode=disable")
+	}
+	return strings.Join(params, " ")
+}
+
+// pqValue quotes a value for a key=value connection string
+func pqValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// Tables lists the base tables of the current schema
+func (a *PostgreSQLAdapter) Tables(ctx context.Context) ([]string, error) {
+	return a.queryStrings(ctx, `
+		SELECT table_name FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'
+		ORDER BY table_name`)
+}
+
+// RowEstimates returns the row counts of the planner statistics. Tables that
+// were never analyzed report zero rows.
+func (a *PostgreSQLAdapter) RowEstimates(ctx context.Context) (map[string]int64, error) {
+	return a.queryCounts(ctx, `
+		SELECT c.relname, GREATEST(c.reltuples, 0)::bigint
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p')`)
+}
+
+// StreamRows reads rows one by one without buffering the whole result
+func (a *PostgreSQLAdapter) StreamRows(ctx context.Context, q RowQuery, fn RowFunc) error {
+	return a.streamRows(ctx, a, q, func(typeName string) bool {
+		return typeName == "BYTEA"
+	}, fn)
+}
+
+// QuoteIdentifier quotes a table or column name
+func (a *PostgreSQLAdapter) QuoteIdentifier(name string) string {
+	return quoteWith(name, `"`)
+}
+
+// Placeholder returns the bind parameter marker for the n-th argument
+func (a *PostgreSQLAdapter) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// JSONValue renders the scalar at a path of a JSON column as text; the
+// column is cast so that json, jsonb and text columns are all read
+func (a *PostgreSQLAdapter) JSONValue(column, path string) string {
+	segments, _ := models.ParseJSONPath(path)
+	if len(segments) == 1 && !strings.HasPrefix(path, "$[") {
+		return "(" + column + "::jsonb ->> " + a.Literal(segments[0]) + ")"
+	}
+	return "(" + column + "::jsonb #>> " + a.Literal("{"+strings.Join(segments, ",")+"}") + ")"
+}
+
+// Literal renders a Go value as a SQL literal
+func (a *PostgreSQLAdapter) Literal(v any) string {
+	return literal(v, standardString, func(b bool) string {
+		if b {
+			return "TRUE"
+		}
+		return "FALSE"
+	}, func(b []byte) string {
+		return `'\x` + hex.EncodeToString(b) + `'::bytea`
+	})
+}
+
+// BeginSnapshot makes every read of the PostgreSQL adapter run in a single
+// REPEATABLE READ, READ ONLY transaction, whose snapshot is exported for
+// ShareSnapshot, or under SHARE locks on tables, until EndSnapshot
+func (a *PostgreSQLAdapter) BeginSnapshot(ctx context.Context, mode models.SnapshotMode, tables []string) error {
+	switch mode.OrDefault() {
+	case models.SnapshotTransaction:
+		if err := a.beginSnapshot(ctx, []string{"BEGIN ISOLATION LEVEL REPEATABLE READ, READ ONLY"}, []string{"COMMIT"}); err != nil {
+			return err
+		}
+		if err := a.snap.conn.QueryRowContext(ctx, "SELECT pg_export_snapshot()").Scan(&a.snap.id); err != nil {
+			a.EndSnapshot()
+			return fmt.Errorf("exporting snapshot: %w", err)
+		}
+		return nil
+	case models.SnapshotLocks:
+		begin := []string{"BEGIN"}
+		if len(tables) > 0 {
+			begin = append(begin, "LOCK TABLE "+lockList(a, tables, "")+" IN SHARE MODE")
+		}
+		return a.beginSnapshot(ctx, begin, []string{"COMMIT"})
+	default:
+		return nil
+	}
+}
+
+// ShareSnapshot opens another connection in a transaction importing the
+// exported snapshot; the caller commits and closes it
+func (a *PostgreSQLAdapter) ShareSnapshot(ctx context.Context) (*sql.Conn, error) {
+	if a.snap == nil || a.snap.id == "" {
+		return nil, ErrSnapshotNotShared
+	}
+	conn, err := a.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("sharing snapshot: %w", err)
+	}
+	for _, stmt := range []string{
+		"BEGIN ISOLATION LEVEL REPEATABLE READ, READ ONLY",
+		"SET TRANSACTION SNAPSHOT " + a.Literal(a.snap.id),
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("sharing snapshot: %w", err)
+		}
+	}
+	return conn, nil
+}
@@ -0,0 +1,134 @@
+package adapters
+
+import (
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Preamble returns the session statements starting a dump script
+func (a *PostgreSQLAdapter) Preamble() []string {
+	return []string{
+		"SET client_encoding = 'UTF8'",
+		"SET standard_conforming_strings = on",
+	}
+}
+
+// Postamble returns the session statements ending a dump script
+func (a *PostgreSQLAdapter) Postamble() []string {
+	return nil
+}
+
+// DropTable renders the statement removing a table if it exists
+func (a *PostgreSQLAdapter) DropTable(table string) string {
+	return "DROP TABLE IF EXISTS " + a.QuoteIdentifier(table) + " CASCADE"
+}
+
+// CreateTable renders the statements creating a table, its indexes and comments.
+// Auto incremented columns become identity columns so no sequence has to exist.
+func (a *PostgreSQLAdapter) CreateTable(t *models.Table, from models.DatabaseType) []string {
+	stmts := createTable(a, t, from, func(c models.Column) string {
+		var b strings.Builder
+		b.WriteString(postgresColumnType(c, from))
+		if c.AutoIncrement {
+			b.WriteString(" GENERATED BY DEFAULT AS IDENTITY")
+		} else if c.Default != nil {
+			if expr, ok := translateDefault(*c.Default, from, models.PostgreSQL); ok {
+				b.WriteString(" DEFAULT " + expr)
+			}
+		}
+		if !c.Nullable {
+			b.WriteString(" NOT NULL")
+		}
+		return b.String()
+	}, tableOptions{})
+
+	if t.Comment != "" {
+		stmts = append(stmts, "COMMENT ON TABLE "+a.QuoteIdentifier(t.Name)+" IS "+a.Literal(t.Comment))
+	}
+	for _, c := range t.Columns {
+		if c.Comment != "" {
+			stmts = append(stmts, "COMMENT ON COLUMN "+a.QuoteIdentifier(t.Name)+"."+
+				a.QuoteIdentifier(c.Name)+" IS "+a.Literal(c.Comment))
+		}
+	}
+	return stmts
+}
+
+// FinishTable adds the foreign keys and moves identity sequences past the loaded rows
+func (a *PostgreSQLAdapter) FinishTable(t *models.Table) []string {
+	stmts := addForeignKeys(a, t)
+	for _, c := range t.Columns {
+		if !c.AutoIncrement {
+			continue
+		}
+		stmts = append(stmts, "SELECT setval(pg_get_serial_sequence("+
+			a.Literal(a.QuoteIdentifier(t.Name))+", "+a.Literal(c.Name)+"), "+
+			"COALESCE((SELECT MAX("+a.QuoteIdentifier(c.Name)+") FROM "+a.QuoteIdentifier(t.Name)+"), 0) + 1, false)")
+	}
+	return stmts
+}
+
+// Insert renders a multi row INSERT statement
+func (a *PostgreSQLAdapter) Insert(table string, columns []string, rows [][]any) string {
+	return insert(a, table, columns, rows)
+}
+
+// Update renders the statement setting columns of the row with a primary key
+func (a *PostgreSQLAdapter) Update(table string, columns []string, values []any, key []string, keyValues []any) string {
+	return update(a, table, columns, values, key, keyValues)
+}
+
+// postgresColumnType translates a column type declared in another dialect.
+// Enum columns become text guarded by a check, as their types are not dumped.
+func postgresColumnType(c models.Column, from models.DatabaseType) string {
+	if len(c.EnumValues) > 0 {
+		return "text"
+	}
+	if from == models.PostgreSQL {
+		return c.Type
+	}
+
+	mods := typeModifiers(c.Type)
+	unsigned := strings.Contains(strings.ToLower(c.Type), "unsigned")
+	switch dt := c.DataType; {
+	case dt == "boolean" || dt == "bool":
+		return "boolean"
+	case dt == "tinyint" || dt == "smallint":
+		if unsigned {
+			return "integer"
+		}
+		return "smallint"
+	case dt == "bigint":
+		return "bigint"
+	case IsIntegerType(dt):
+		if unsigned {
+			return "bigint"
+		}
+		return "integer"
+	case dt == "float" || dt == "real":
+		return "real"
+	case dt == "double" || dt == "double precision":
+		return "double precision"
+	case dt == "decimal" || dt == "numeric":
+		return "numeric" + mods
+	case dt == "varchar" || dt == "character varying" || dt == "nvarchar":
+		return "varchar" + mods
+	case dt == "char" || dt == "character" || dt == "nchar":
+		return "char" + mods
+	case dt == "date":
+		return "date"
+	case dt == "datetime" || strings.HasPrefix(dt, "timestamp"):
+		return "timestamp"
+	case strings.HasPrefix(dt, "time"):
+		return "time"
+	case dt == "year":
+		return "integer"
+	case dt == "json":
+		return "json"
+	case isBinaryType(dt):
+		return "bytea"
+	default:
+		return "text"
+	}
+}
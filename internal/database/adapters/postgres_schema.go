@@ -0,0 +1,223 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"github.com/lib/pq"
+)
+
+// Describe returns the full definition of a table read from pg_catalog
+func (a *PostgreSQLAdapter) Describe(ctx context.Context, table string) (*models.Table, error) {
+	if a.db == nil {
+		return nil, ErrNotConnected
+	}
+	t := &models.Table{Name: table}
+	relation := a.QuoteIdentifier(table)
+
+	var found bool
+	err := a.db.QueryRowContext(ctx, `
+		SELECT to_regclass($1) IS NOT NULL,
+			COALESCE(obj_description(to_regclass($1), 'pg_class'), '')`, relation).Scan(&found, &t.Comment)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+
+	if t.Columns, err = a.columns(ctx, relation); err != nil {
+		return nil, err
+	}
+
+	t.PrimaryKey, err = a.queryStrings(ctx, `
+		SELECT a.attname
+		FROM pg_constraint c
+		CROSS JOIN LATERAL unnest(c.conkey) WITH ORDINALITY AS k(attnum, ord)
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+		WHERE c.conrelid = to_regclass($1) AND c.contype = 'p'
+		ORDER BY k.ord`, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.ForeignKeys, err = a.foreignKeys(ctx, table); err != nil {
+		return nil, err
+	}
+	if t.Uniques, err = a.uniques(ctx, relation); err != nil {
+		return nil, err
+	}
+	if t.Indexes, err = a.indexes(ctx, relation); err != nil {
+		return nil, err
+	}
+	if t.Checks, err = a.checks(ctx, relation); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// columns reads the ordered column definitions of a relation
+func (a *PostgreSQLAdapter) columns(ctx context.Context, relation string) ([]models.Column, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT a.attname,
+			format_type(a.atttypid, a.atttypmod),
+			NOT a.attnotnull,
+			pg_get_expr(d.adbin, d.adrelid),
+			a.attidentity <> '',
+			COALESCE(col_description(a.attrelid, a.attnum), ''),
+			CASE WHEN t.typtype = 'e' THEN ARRAY(
+				SELECT e.enumlabel FROM pg_enum e
+				WHERE e.enumtypid = t.oid ORDER BY e.enumsortorder)
+			END
+		FROM pg_attribute a
+		JOIN pg_type t ON t.oid = a.atttypid
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, relation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []models.Column
+	for rows.Next() {
+		var (
+			c        models.Column
+			dflt     sql.NullString
+			identity bool
+			enum     pq.StringArray
+		)
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable, &dflt, &identity, &c.Comment, &enum); err != nil {
+			return nil, err
+		}
+		c.DataType = models.BaseType(c.Type)
+		if dflt.Valid {
+			c.Default = &dflt.String
+		}
+		c.AutoIncrement = identity || strings.HasPrefix(dflt.String, "nextval(")
+		c.EnumValues = enum
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// foreignKeys reads the foreign keys declared on a table from pg_constraint
+func (a *PostgreSQLAdapter) foreignKeys(ctx context.Context, table string) ([]models.ForeignKey, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT c.conname, a.attname, rc.relname, ra.attname, c.confupdtype, c.confdeltype
+		FROM pg_constraint c
+		CROSS JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refnum, ord)
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+		JOIN pg_class rc ON rc.oid = c.confrelid
+		JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refnum
+		WHERE c.conrelid = to_regclass($1) AND c.contype = 'f'
+		ORDER BY c.conname, k.ord`, a.QuoteIdentifier(table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []models.ForeignKey
+	for rows.Next() {
+		var name, column, refTable, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(&name, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+		if n := len(fks); n > 0 && fks[n-1].Name == name {
+			fks[n-1].Columns = append(fks[n-1].Columns, column)
+			fks[n-1].RefColumns = append(fks[n-1].RefColumns, refColumn)
+			continue
+		}
+		fks = append(fks, models.ForeignKey{
+			Name:       name,
+			Table:      table,
+			Columns:    []string{column},
+			RefTable:   refTable,
+			RefColumns: []string{refColumn},
+			OnDelete:   models.ParseReferentialAction(onDelete),
+			OnUpdate:   models.ParseReferentialAction(onUpdate),
+		})
+	}
+	return fks, rows.Err()
+}
+
+// uniques reads the unique constraints of a relation
+func (a *PostgreSQLAdapter) uniques(ctx context.Context, relation string) ([]models.UniqueConstraint, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT c.conname, a.attname
+		FROM pg_constraint c
+		CROSS JOIN LATERAL unnest(c.conkey) WITH ORDINALITY AS k(attnum, ord)
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+		WHERE c.conrelid = to_regclass($1) AND c.contype = 'u'
+		ORDER BY c.conname, k.ord`, relation)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := groupColumns(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	uniques := make([]models.UniqueConstraint, len(groups))
+	for i, g := range groups {
+		uniques[i] = models.UniqueConstraint{Name: g.name, Columns: g.columns}
+	}
+	return uniques, nil
+}
+
+// indexes reads the indexes of a relation that do not back a constraint
+func (a *PostgreSQLAdapter) indexes(ctx context.Context, relation string) ([]models.Index, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT i.relname, ix.indisunique, pg_get_indexdef(ix.indexrelid, k.n, true)
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		CROSS JOIN LATERAL generate_series(1, ix.indnatts) AS k(n)
+		WHERE ix.indrelid = to_regclass($1) AND NOT ix.indisprimary
+			AND NOT EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conindid = ix.indexrelid)
+		ORDER BY i.relname, k.n`, relation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []models.Index
+	for rows.Next() {
+		var name, column string
+		var unique bool
+		if err := rows.Scan(&name, &unique, &column); err != nil {
+			return nil, err
+		}
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			continue
+		}
+		indexes = append(indexes, models.Index{Name: name, Columns: []string{column}, Unique: unique})
+	}
+	return indexes, rows.Err()
+}
+
+// checks reads the check constraints of a relation
+func (a *PostgreSQLAdapter) checks(ctx context.Context, relation string) ([]models.CheckConstraint, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT conname, pg_get_expr(conbin, conrelid)
+		FROM pg_constraint
+		WHERE conrelid = to_regclass($1) AND contype = 'c'
+		ORDER BY conname`, relation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []models.CheckConstraint
+	for rows.Next() {
+		var c models.CheckConstraint
+		if err := rows.Scan(&c.Name, &c.Expression); err != nil {
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
@@ -0,0 +1,55 @@
+package adapters
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// columnGroup is a named, ordered list of columns such as a constraint
+type columnGroup struct {
+	name    string
+	columns []string
+}
+
+// groupColumns collects (name, column) rows ordered by name into groups
+func groupColumns(rows *sql.Rows) ([]columnGroup, error) {
+	defer rows.Close()
+
+	var groups []columnGroup
+	for rows.Next() {
+		var name, column string
+		if err := rows.Scan(&name, &column); err != nil {
+			return nil, err
+		}
+		if n := len(groups); n > 0 && groups[n-1].name == name {
+			groups[n-1].columns = append(groups[n-1].columns, column)
+			continue
+		}
+		groups = append(groups, columnGroup{name: name, columns: []string{column}})
+	}
+	return groups, rows.Err()
+}
+
+// IsIntegerType reports whether a base type name holds whole numbers
+func IsIntegerType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint",
+		"int2", "int4", "int8", "serial", "bigserial", "smallserial":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsNumericType reports whether a base type name holds numbers
+func IsNumericType(dataType string) bool {
+	if IsIntegerType(dataType) {
+		return true
+	}
+	switch strings.ToLower(dataType) {
+	case "decimal", "numeric", "float", "double", "real", "double precision", "float4", "float8":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,103 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrSnapshotOpen is returned when a snapshot is started while another one is open
+	ErrSnapshotOpen = errors.New("a snapshot is already open")
+	// ErrSnapshotNotShared is returned when the open snapshot cannot be read from other connections
+	ErrSnapshotNotShared = errors.New("snapshot cannot be shared with other connections")
+)
+
+// Querier runs read queries; it is satisfied by *sql.DB, *sql.Conn and *sql.Tx
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// snapshot is a connection set aside to hold a read transaction or table
+// locks, on which every read of the adapter runs while it is open
+type snapshot struct {
+	conn *sql.Conn
+	end  []string // statements releasing the transaction or the locks
+	id   string   // exported PostgreSQL snapshot, empty elsewhere
+}
+
+// reader returns where reads run: the open snapshot, or the connection pool
+func (b *base) reader() Querier {
+	if b.snap != nil {
+		return b.snap.conn
+	}
+	return b.db
+}
+
+// Reader returns where reads run: the connection holding the open snapshot,
+// or the connection pool when there is none
+func (b *base) Reader() Querier {
+	return b.reader()
+}
+
+// beginSnapshot sets a connection aside and runs the statements starting a
+// snapshot on it; end are the statements releasing it
+func (b *base) beginSnapshot(ctx context.Context, begin, end []string) error {
+	if b.db == nil {
+		return ErrNotConnected
+	}
+	if b.snap != nil {
+		return ErrSnapshotOpen
+	}
+	conn, err := b.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("starting snapshot: %w", err)
+	}
+	for _, stmt := range begin {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			conn.Close()
+			return fmt.Errorf("starting snapshot: %w", err)
+		}
+	}
+	b.snap = &snapshot{conn: conn, end: end}
+	return nil
+}
+
+// EndSnapshot releases the open snapshot, if any; reads then run on the
+// connection pool again
+func (b *base) EndSnapshot() error {
+	if b.snap == nil {
+		return nil
+	}
+	var errs []error
+	for _, stmt := range b.snap.end {
+		// Released even when the dump was cancelled
+		if _, err := b.snap.conn.ExecContext(context.Background(), stmt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, b.snap.conn.Close())
+	b.snap = nil
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("ending snapshot: %w", err)
+	}
+	return nil
+}
+
+// ShareSnapshot opens another connection reading the open snapshot, for
+// parallel readers. Only PostgreSQL can share a snapshot.
+func (b *base) ShareSnapshot(ctx context.Context) (*sql.Conn, error) {
+	return nil, ErrSnapshotNotShared
+}
+
+// lockList renders the quoted names of tables, comma separated, with a suffix after each
+func lockList(a Adapter, tables []string, suffix string) string {
+	quoted := make([]string, len(tables))
+	for i, t := range tables {
+		quoted[i] = a.QuoteIdentifier(t) + suffix
+	}
+	return strings.Join(quoted, ", ")
+}
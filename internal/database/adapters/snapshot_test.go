@@ -0,0 +1,210 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// recorder is a database connector logging the statements run on its
+// connections; queries return a single row holding an exported snapshot id
+type recorder struct {
+	mu    sync.Mutex
+	stmts []string
+}
+
+func (r *recorder) Connect(context.Context) (driver.Conn, error) { return recordingConn{r}, nil }
+func (r *recorder) Driver() driver.Driver                        { return nil }
+
+func (r *recorder) log(query string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stmts = append(r.stmts, query)
+}
+
+// take returns the statements logged since the last call
+func (r *recorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stmts := r.stmts
+	r.stmts = nil
+	return stmts
+}
+
+type recordingConn struct{ r *recorder }
+
+func (c recordingConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c recordingConn) Close() error                        { return nil }
+func (c recordingConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c recordingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.r.log(query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c recordingConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.r.log(query)
+	return &snapshotRows{}, nil
+}
+
+type snapshotRows struct{ done bool }
+
+func (r *snapshotRows) Columns() []string { return []string{"snapshot"} }
+func (r *snapshotRows) Close() error      { return nil }
+
+func (r *snapshotRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = "00000003-1"
+	return nil
+}
+
+// recorded returns an adapter of the type whose statements are logged
+func recorded(t *testing.T, typ models.DatabaseType) (Adapter, *recorder) {
+	t.Helper()
+	r := &recorder{}
+	db := sql.OpenDB(r)
+	t.Cleanup(func() { db.Close() })
+	switch a := dialects()[typ].(type) {
+	case *MySQLAdapter:
+		a.db = db
+		return a, r
+	case *PostgreSQLAdapter:
+		a.db = db
+		return a, r
+	case *SQLiteAdapter:
+		a.db = db
+		return a, r
+	}
+	t.Fatalf("no adapter for %s", typ)
+	return nil, nil
+}
+
+func TestBeginSnapshot(t *testing.T) {
+	tables := []string{"orders", "order items"}
+	tests := []struct {
+		typ   models.DatabaseType
+		mode  models.SnapshotMode
+		begin []string
+		end   []string
+	}{
+		{
+			models.MySQL, models.SnapshotTransaction,
+			[]string{"SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ", "START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY"},
+			[]string{"COMMIT"},
+		},
+		{
+			models.MySQL, models.SnapshotLocks,
+			[]string{"LOCK TABLES `orders` READ, `order items` READ"},
+			[]string{"UNLOCK TABLES"},
+		},
+		{
+			models.PostgreSQL, models.SnapshotTransaction,
+			[]string{"BEGIN ISOLATION LEVEL REPEATABLE READ, READ ONLY", "SELECT pg_export_snapshot()"},
+			[]string{"COMMIT"},
+		},
+		{
+			models.PostgreSQL, models.SnapshotLocks,
+			[]string{"BEGIN", `LOCK TABLE "orders", "order items" IN SHARE MODE`},
+			[]string{"COMMIT"},
+		},
+		{
+			models.SQLite3, models.SnapshotTransaction,
+			[]string{"BEGIN", "SELECT COUNT(*) FROM sqlite_master"},
+			[]string{"COMMIT"},
+		},
+		{
+			models.SQLite3, models.SnapshotLocks,
+			[]string{"BEGIN IMMEDIATE"},
+			[]string{"COMMIT"},
+		},
+		{models.MySQL, models.SnapshotNone, nil, nil},
+		{models.PostgreSQL, models.SnapshotNone, nil, nil},
+		{models.SQLite3, models.SnapshotNone, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.typ)+"/"+string(tt.mode), func(t *testing.T) {
+			a, r := recorded(t, tt.typ)
+			ctx := context.Background()
+			if err := a.BeginSnapshot(ctx, tt.mode, tables); err != nil {
+				t.Fatal(err)
+			}
+			if got := r.take(); !slices.Equal(got, tt.begin) {
+				t.Errorf("begin ran %q, want %q", got, tt.begin)
+			}
+			if tt.begin != nil {
+				if err := a.BeginSnapshot(ctx, tt.mode, tables); !errors.Is(err, ErrSnapshotOpen) {
+					t.Errorf("second snapshot: err = %v, want %v", err, ErrSnapshotOpen)
+				}
+			}
+			if err := a.EndSnapshot(); err != nil {
+				t.Fatal(err)
+			}
+			if got := r.take(); !slices.Equal(got, tt.end) {
+				t.Errorf("end ran %q, want %q", got, tt.end)
+			}
+		})
+	}
+}
+
+func TestBeginSnapshotNoTables(t *testing.T) {
+	tests := []struct {
+		typ   models.DatabaseType
+		begin []string
+	}{
+		{models.MySQL, nil},
+		{models.PostgreSQL, []string{"BEGIN"}},
+		{models.SQLite3, []string{"BEGIN IMMEDIATE"}},
+	}
+	for _, tt := range tests {
+		a, r := recorded(t, tt.typ)
+		if err := a.BeginSnapshot(context.Background(), models.SnapshotLocks, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got := r.take(); !slices.Equal(got, tt.begin) {
+			t.Errorf("%s: begin ran %q, want %q", tt.typ, got, tt.begin)
+		}
+		a.EndSnapshot()
+	}
+}
+
+func TestShareSnapshot(t *testing.T) {
+	ctx := context.Background()
+	for typ := range dialects() {
+		for _, mode := range []models.SnapshotMode{models.SnapshotTransaction, models.SnapshotLocks} {
+			a, r := recorded(t, typ)
+			if err := a.BeginSnapshot(ctx, mode, []string{"orders"}); err != nil {
+				t.Fatal(err)
+			}
+			r.take()
+
+			// Only an exported PostgreSQL snapshot is imported by other connections
+			conn, err := a.ShareSnapshot(ctx)
+			if typ != models.PostgreSQL || mode != models.SnapshotTransaction {
+				if !errors.Is(err, ErrSnapshotNotShared) {
+					t.Errorf("%s %s: err = %v, want %v", typ, mode, err, ErrSnapshotNotShared)
+				}
+				a.EndSnapshot()
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.Close()
+			want := []string{"BEGIN ISOLATION LEVEL REPEATABLE READ, READ ONLY", "SET TRANSACTION SNAPSHOT '00000003-1'"}
+			if got := r.take(); !slices.Equal(got, want) {
+				t.Errorf("shared snapshot ran %q, want %q", got, want)
+			}
+			a.EndSnapshot()
+		}
+	}
+}
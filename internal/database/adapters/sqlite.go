@@ -0,0 +1,112 @@
+package adapters
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteAdapter implements Adapter for SQLite3 database files
+type SQLiteAdapter struct {
+	base
+}
+
+// NewSQLite creates a new SQLite3 adapter
+func NewSQLite(cfg models.DatabaseConfig) *SQLiteAdapter {
+	return &SQLiteAdapter{base: base{cfg: cfg}}
+}
+
+// Type returns the database type handled by the adapter
+func (a *SQLiteAdapter) Type() models.DatabaseType {
+	return models.SQLite3
+}
+
+// Connect opens the database file and verifies it is readable
+func (a *SQLiteAdapter) Connect(ctx context.Context) error {
+	path := a.cfg.FilePath
+	if path == "" {
+		path = a.cfg.Database
+	}
+	if path == "" {
+		return fmt.Errorf("%w: connecting to %s: no database file given", ErrConnectionFailed, a.cfg.Type)
+	}
+	return a.open(ctx, "sqlite3", path)
+}
+
+// Tables lists the tables of the database file, skipping SQLite internals
+func (a *SQLiteAdapter) Tables(ctx context.Context) ([]string, error) {
+	return a.queryStrings(ctx, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name`)
+}
+
+// RowEstimates counts the rows of every table. SQLite keeps no row statistics,
+// but counting a local file is cheap enough.
+func (a *SQLiteAdapter) RowEstimates(ctx context.Context) (map[string]int64, error) {
+	tables, err := a.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(tables))
+	for _, t := range tables {
+		var n int64
+		if err := a.reader().QueryRowContext(ctx, "SELECT COUNT(*) FROM "+a.QuoteIdentifier(t)).Scan(&n); err != nil {
+			return nil, fmt.Errorf("counting rows of %s: %w", t, err)
+		}
+		counts[t] = n
+	}
+	return counts, nil
+}
+
+// StreamRows reads rows one by one without buffering the whole result. The
+// SQLite driver only returns byte slices for BLOB values, so they are kept as is.
+func (a *SQLiteAdapter) StreamRows(ctx context.Context, q RowQuery, fn RowFunc) error {
+	return a.streamRows(ctx, a, q, func(string) bool { return true }, fn)
+}
+
+// QuoteIdentifier quotes a table or column name
+func (a *SQLiteAdapter) QuoteIdentifier(name string) string {
+	return quoteWith(name, `"`)
+}
+
+// Placeholder returns the bind parameter marker for the n-th argument
+func (a *SQLiteAdapter) Placeholder(n int) string {
+	return "?"
+}
+
+// JSONValue renders the scalar at a path of a JSON column
+func (a *SQLiteAdapter) JSONValue(column, path string) string {
+	return "json_extract(" + column + ", " + a.Literal(path) + ")"
+}
+
+// Literal renders a Go value as a SQL literal
+func (a *SQLiteAdapter) Literal(v any) string {
+	return literal(v, standardString, func(b bool) string {
+		if b {
+			return "1"
+		}
+		return "0"
+	}, func(b []byte) string {
+		return "X'" + hex.EncodeToString(b) + "'"
+	})
+}
+
+// BeginSnapshot makes every read of the SQLite adapter run in a single read
+// transaction, started at once so that it sees the file as of now. Writers
+// are blocked by it in rollback journal mode and kept out of its view in WAL
+// mode. SQLite has no table locks: the locks mode takes the write lock of the
+// whole file instead.
+func (a *SQLiteAdapter) BeginSnapshot(ctx context.Context, mode models.SnapshotMode, tables []string) error {
+	switch mode.OrDefault() {
+	case models.SnapshotTransaction:
+		return a.beginSnapshot(ctx, []string{"BEGIN", "SELECT COUNT(*) FROM sqlite_master"}, []string{"COMMIT"})
+	case models.SnapshotLocks:
+		return a.beginSnapshot(ctx, []string{"BEGIN IMMEDIATE"}, []string{"COMMIT"})
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,107 @@
+package adapters
+
+import (
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Preamble returns the session statements starting a dump script
+func (a *SQLiteAdapter) Preamble() []string {
+	return []string{
+		"PRAGMA foreign_keys = OFF",
+		"BEGIN TRANSACTION",
+	}
+}
+
+// Postamble returns the session statements ending a dump script
+func (a *SQLiteAdapter) Postamble() []string {
+	return []string{"COMMIT"}
+}
+
+// DropTable renders the statement removing a table if it exists
+func (a *SQLiteAdapter) DropTable(table string) string {
+	return "DROP TABLE IF EXISTS " + a.QuoteIdentifier(table)
+}
+
+// CreateTable renders the statements creating a table and its indexes. SQLite
+// cannot add constraints later, so foreign keys are declared in the table body.
+func (a *SQLiteAdapter) CreateTable(t *models.Table, from models.DatabaseType) []string {
+	rowid := rowidColumn(t)
+
+	return createTable(a, t, from, func(c models.Column) string {
+		if c.Name == rowid {
+			return "INTEGER PRIMARY KEY"
+		}
+
+		var b strings.Builder
+		b.WriteString(sqliteColumnType(c, from))
+		if !c.Nullable {
+			b.WriteString(" NOT NULL")
+		}
+		if c.Default != nil {
+			if expr, ok := translateDefault(*c.Default, from, models.SQLite3); ok {
+				b.WriteString(" DEFAULT " + expr)
+			}
+		}
+		return b.String()
+	}, tableOptions{inlinePrimaryKey: rowid != "", inlineForeignKeys: true})
+}
+
+// FinishTable has nothing to do, as constraints are part of CREATE TABLE
+func (a *SQLiteAdapter) FinishTable(t *models.Table) []string {
+	return nil
+}
+
+// Insert renders a multi row INSERT statement
+func (a *SQLiteAdapter) Insert(table string, columns []string, rows [][]any) string {
+	return insert(a, table, columns, rows)
+}
+
+// Update renders the statement setting columns of the row with a primary key
+func (a *SQLiteAdapter) Update(table string, columns []string, values []any, key []string, keyValues []any) string {
+	return update(a, table, columns, values, key, keyValues)
+}
+
+// rowidColumn returns the auto incremented integer primary key column that
+// becomes the rowid alias, or an empty string
+func rowidColumn(t *models.Table) string {
+	if len(t.PrimaryKey) != 1 {
+		return ""
+	}
+	c := t.Column(t.PrimaryKey[0])
+	if c == nil || !c.AutoIncrement || !IsIntegerType(c.DataType) {
+		return ""
+	}
+	return c.Name
+}
+
+// sqliteColumnType translates a column type declared in another dialect. The
+// type names chosen keep the driver conversions for booleans and timestamps.
+func sqliteColumnType(c models.Column, from models.DatabaseType) string {
+	if from == models.SQLite3 {
+		return c.Type
+	}
+	if len(c.EnumValues) > 0 {
+		return "TEXT"
+	}
+
+	switch dt := c.DataType; {
+	case dt == "boolean" || dt == "bool":
+		return "BOOLEAN"
+	case IsIntegerType(dt):
+		return "INTEGER"
+	case dt == "float" || dt == "real" || dt == "double" || dt == "double precision":
+		return "REAL"
+	case dt == "decimal" || dt == "numeric":
+		return "NUMERIC"
+	case dt == "date":
+		return "DATE"
+	case dt == "datetime" || strings.HasPrefix(dt, "timestamp"):
+		return "DATETIME"
+	case isBinaryType(dt):
+		return "BLOB"
+	default:
+		return "TEXT"
+	}
+}
@@ -0,0 +1,378 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Describe returns the full definition of a table read through PRAGMA calls
+func (a *SQLiteAdapter) Describe(ctx context.Context, table string) (*models.Table, error) {
+	if a.db == nil {
+		return nil, ErrNotConnected
+	}
+	t := &models.Table{Name: table}
+
+	var (
+		err         error
+		createTable string
+	)
+	if t.Columns, t.PrimaryKey, err = a.columns(ctx, table); err != nil {
+		return nil, err
+	}
+	if len(t.Columns) == 0 {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+
+	err = a.db.QueryRowContext(ctx,
+		`SELECT COALESCE(sql, '') FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&createTable)
+	if err != nil {
+		return nil, err
+	}
+	// An INTEGER PRIMARY KEY column aliases the rowid and is assigned automatically
+	if len(t.PrimaryKey) == 1 {
+		if c := t.Column(t.PrimaryKey[0]); c != nil && strings.EqualFold(c.Type, "INTEGER") {
+			c.AutoIncrement = true
+		}
+	}
+
+	if t.ForeignKeys, err = a.foreignKeys(ctx, table); err != nil {
+		return nil, err
+	}
+	if t.Uniques, t.Indexes, err = a.indexes(ctx, table); err != nil {
+		return nil, err
+	}
+	t.Checks = parseSQLiteChecks(createTable)
+	return t, nil
+}
+
+// columns reads the ordered columns and the primary key of a table
+func (a *SQLiteAdapter) columns(ctx context.Context, table string) ([]models.Column, []string, error) {
+	rows, err := a.db.QueryContext(ctx, "PRAGMA table_info("+a.QuoteIdentifier(table)+")")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	type pkColumn struct {
+		name string
+		pos  int
+	}
+	var (
+		columns []models.Column
+		pk      []pkColumn
+	)
+	for rows.Next() {
+		var (
+			cid, notNull, pkPos int
+			c                   models.Column
+			dflt                sql.NullString
+		)
+		if err := rows.Scan(&cid, &c.Name, &c.Type, &notNull, &dflt, &pkPos); err != nil {
+			return nil, nil, err
+		}
+		c.DataType = models.BaseType(c.Type)
+		c.Nullable = notNull == 0 && pkPos == 0
+		if dflt.Valid {
+			c.Default = &dflt.String
+		}
+		columns = append(columns, c)
+		if pkPos > 0 {
+			pk = append(pk, pkColumn{name: c.Name, pos: pkPos})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(pk, func(i, j int) bool { return pk[i].pos < pk[j].pos })
+	names := make([]string, len(pk))
+	for i, c := range pk {
+		names[i] = c.name
+	}
+	return columns, names, nil
+}
+
+// foreignKeys reads the foreign keys declared on a table. SQLite constraints
+// are unnamed, so a name is derived from the table and constraint id.
+func (a *SQLiteAdapter) foreignKeys(ctx context.Context, table string) ([]models.ForeignKey, error) {
+	rows, err := a.db.QueryContext(ctx, "PRAGMA foreign_key_list("+a.QuoteIdentifier(table)+")")
+	if err != nil {
+		return nil, err
+	}
+
+	var fks []models.ForeignKey
+	lastID := -1
+	for rows.Next() {
+		var (
+			id, seq                  int
+			refTable, from           string
+			to                       sql.NullString
+			onUpdate, onDelete, mtch string
+		)
+		if err := rows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &mtch); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if id == lastID {
+			fk := &fks[len(fks)-1]
+			fk.Columns = append(fk.Columns, from)
+			fk.RefColumns = append(fk.RefColumns, to.String)
+			continue
+		}
+		lastID = id
+		fks = append(fks, models.ForeignKey{
+			Name:       fmt.Sprintf("fk_%s_%d", table, id),
+			Table:      table,
+			Columns:    []string{from},
+			RefTable:   refTable,
+			RefColumns: []string{to.String},
+			OnDelete:   models.ParseReferentialAction(onDelete),
+			OnUpdate:   models.ParseReferentialAction(onUpdate),
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// PRAGMA lists constraints in reverse declaration order
+	for i, j := 0, len(fks)-1; i < j; i, j = i+1, j-1 {
+		fks[i], fks[j] = fks[j], fks[i]
+	}
+
+	// A reference without target columns points at the parent primary key
+	for i := range fks {
+		if fks[i].RefColumns[0] != "" {
+			continue
+		}
+		_, pk, err := a.columns(ctx, fks[i].RefTable)
+		if err != nil {
+			return nil, err
+		}
+		fks[i].RefColumns = pk
+	}
+	return fks, nil
+}
+
+// indexes reads the unique constraints and secondary indexes of a table
+func (a *SQLiteAdapter) indexes(ctx context.Context, table string) ([]models.UniqueConstraint, []models.Index, error) {
+	rows, err := a.db.QueryContext(ctx, "PRAGMA index_list("+a.QuoteIdentifier(table)+")")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type indexInfo struct {
+		name   string
+		unique bool
+		origin string
+	}
+	var list []indexInfo
+	for rows.Next() {
+		var (
+			seq, unique, partial int
+			info                 indexInfo
+		)
+		if err := rows.Scan(&seq, &info.name, &unique, &info.origin, &partial); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		info.unique = unique == 1
+		list = append(list, info)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		uniques []models.UniqueConstraint
+		indexes []models.Index
+	)
+	for i := len(list) - 1; i >= 0; i-- {
+		info := list[i]
+		if info.origin == "pk" {
+			continue
+		}
+		columns, err := a.indexColumns(ctx, info.name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if info.origin == "u" {
+			uniques = append(uniques, models.UniqueConstraint{Name: info.name, Columns: columns})
+			continue
+		}
+		indexes = append(indexes, models.Index{Name: info.name, Columns: columns, Unique: info.unique})
+	}
+	return uniques, indexes, nil
+}
+
+// indexColumns returns the ordered columns of an index. PRAGMA index_info
+// names no column for an expression, which is read from CREATE INDEX instead.
+func (a *SQLiteAdapter) indexColumns(ctx context.Context, index string) ([]string, error) {
+	rows, err := a.db.QueryContext(ctx, "PRAGMA index_info("+a.QuoteIdentifier(index)+")")
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		columns     []string
+		expressions bool
+	)
+	for rows.Next() {
+		var (
+			seqno, cid int
+			name       sql.NullString
+		)
+		if err := rows.Scan(&seqno, &cid, &name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		expressions = expressions || !name.Valid
+		columns = append(columns, name.String)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || !expressions {
+		return columns, err
+	}
+
+	var createIndex string
+	err = a.db.QueryRowContext(ctx,
+		`SELECT COALESCE(sql, '') FROM sqlite_master WHERE type = 'index' AND name = ?`, index).Scan(&createIndex)
+	if err != nil {
+		return nil, err
+	}
+	terms := parseIndexTerms(createIndex)
+	if len(terms) != len(columns) {
+		return nil, fmt.Errorf("reading the expressions of index %s", index)
+	}
+	for i, c := range columns {
+		if c == "" {
+			columns[i] = terms[i]
+		}
+	}
+	return columns, nil
+}
+
+// parseIndexTerms returns the indexed columns and expressions of a CREATE
+// INDEX statement, as written
+func parseIndexTerms(createIndex string) []string {
+	on := indexKeyword(strings.ToUpper(createIndex), "ON", 0)
+	if on < 0 {
+		return nil
+	}
+	open := strings.IndexByte(createIndex[on:], '(')
+	if open < 0 {
+		return nil
+	}
+	open += on
+	end := matchParen(createIndex, open)
+	if end < 0 {
+		return nil
+	}
+
+	var terms []string
+	depth, start := 0, open+1
+	var quote byte
+	for i := open + 1; i < end; i++ {
+		switch c := createIndex[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			terms = append(terms, strings.TrimSpace(createIndex[start:i]))
+			start = i + 1
+		}
+	}
+	return append(terms, strings.TrimSpace(createIndex[start:end]))
+}
+
+// parseSQLiteChecks extracts CHECK constraints from a CREATE TABLE statement,
+// as SQLite keeps no catalog of them
+func parseSQLiteChecks(createTable string) []models.CheckConstraint {
+	var checks []models.CheckConstraint
+	upper := strings.ToUpper(createTable)
+
+	for pos := 0; ; {
+		i := indexKeyword(upper, "CHECK", pos)
+		if i < 0 {
+			return checks
+		}
+		open := strings.IndexByte(createTable[i:], '(')
+		if open < 0 {
+			return checks
+		}
+		open += i
+		end := matchParen(createTable, open)
+		if end < 0 {
+			return checks
+		}
+
+		check := models.CheckConstraint{Expression: strings.TrimSpace(createTable[open+1 : end])}
+		// A named constraint reads "CONSTRAINT name CHECK (...)"
+		before := strings.Fields(createTable[:i])
+		if n := len(before); n >= 2 && strings.EqualFold(before[n-2], "CONSTRAINT") {
+			check.Name = strings.Trim(before[n-1], "\"`[]")
+		}
+		checks = append(checks, check)
+		pos = end + 1
+	}
+}
+
+// indexKeyword finds keyword in s at or after pos, only as a whole word
+func indexKeyword(s, keyword string, pos int) int {
+	for pos < len(s) {
+		i := strings.Index(s[pos:], keyword)
+		if i < 0 {
+			return -1
+		}
+		i += pos
+		end := i + len(keyword)
+		if (i == 0 || !isWordByte(s[i-1])) && (end >= len(s) || !isWordByte(s[end])) {
+			return i
+		}
+		pos = end
+	}
+	return -1
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// matchParen returns the index of the parenthesis closing the one at open,
+// skipping over quoted strings
+func matchParen(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
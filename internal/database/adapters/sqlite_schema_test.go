@@ -0,0 +1,127 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// sqliteSchema is a script declaring every part of a table SQLite keeps
+var sqliteSchema = []string{
+	`CREATE TABLE categories (id INTEGER PRIMARY KEY, parent_id INT REFERENCES categories(id) ON DELETE SET NULL, name TEXT NOT NULL UNIQUE)`,
+	`CREATE TABLE items (
+  order_id INT NOT NULL,
+  line INT NOT NULL,
+  category_id INT NOT NULL,
+  status TEXT DEFAULT 'new' CHECK (status IN ('new', 'paid')),
+  price DECIMAL(10,2) DEFAULT 0,
+  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (order_id, line),
+  CONSTRAINT fk_category FOREIGN KEY (category_id) REFERENCES categories (id) ON DELETE RESTRICT ON UPDATE CASCADE,
+  CONSTRAINT ck_price CHECK (price >= 0)
+)`,
+	`CREATE INDEX idx_items_category ON items (category_id, status)`,
+	`CREATE UNIQUE INDEX idx_items_lower ON items (lower(status), substr(status, 1, 2), line)`,
+}
+
+// openSQLite creates a SQLite database from statements and connects to it
+func openSQLite(t *testing.T, stmts []string) Adapter {
+	t.Helper()
+	a, err := Open(context.Background(), models.DatabaseConfig{Type: models.SQLite3, FilePath: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	for _, stmt := range stmts {
+		if _, err := a.DB().Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	return a
+}
+
+func TestIntrospectSQLite(t *testing.T) {
+	ctx := context.Background()
+	schema, err := Introspect(ctx, openSQLite(t, sqliteSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	str := func(s string) *string { return &s }
+	want := []models.Table{
+		{
+			Name: "categories",
+			Columns: []models.Column{
+				{Name: "id", Type: "INTEGER", DataType: "integer", AutoIncrement: true},
+				{Name: "parent_id", Type: "INT", DataType: "int", Nullable: true},
+				{Name: "name", Type: "TEXT", DataType: "text"},
+			},
+			PrimaryKey: []string{"id"},
+			ForeignKeys: []models.ForeignKey{{
+				Name: "fk_categories_0", Table: "categories", Columns: []string{"parent_id"},
+				RefTable: "categories", RefColumns: []string{"id"}, OnDelete: models.SetNull, OnUpdate: models.NoAction,
+			}},
+			Uniques: []models.UniqueConstraint{{Name: "sqlite_autoindex_categories_1", Columns: []string{"name"}}},
+		},
+		{
+			Name: "items",
+			Columns: []models.Column{
+				{Name: "order_id", Type: "INT", DataType: "int"},
+				{Name: "line", Type: "INT", DataType: "int"},
+				{Name: "category_id", Type: "INT", DataType: "int"},
+				{Name: "status", Type: "TEXT", DataType: "text", Nullable: true, Default: str("'new'")},
+				{Name: "price", Type: "DECIMAL(10,2)", DataType: "decimal", Nullable: true, Default: str("0")},
+				{Name: "created_at", Type: "DATETIME", DataType: "datetime", Nullable: true, Default: str("CURRENT_TIMESTAMP")},
+			},
+			PrimaryKey: []string{"order_id", "line"},
+			ForeignKeys: []models.ForeignKey{{
+				Name: "fk_items_0", Table: "items", Columns: []string{"category_id"},
+				RefTable: "categories", RefColumns: []string{"id"}, OnDelete: models.Restrict, OnUpdate: models.Cascade,
+			}},
+			Indexes: []models.Index{
+				{Name: "idx_items_category", Columns: []string{"category_id", "status"}},
+				{Name: "idx_items_lower", Columns: []string{"lower(status)", "substr(status, 1, 2)", "line"}, Unique: true},
+			},
+			Checks: []models.CheckConstraint{
+				{Expression: "status IN ('new', 'paid')"},
+				{Name: "ck_price", Expression: "price >= 0"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(schema.Tables, want) {
+		got, _ := json.MarshalIndent(schema.Tables, "", "  ")
+		t.Errorf("tables:\n%s", got)
+	}
+
+	// The schema serialises to JSON and back unchanged
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded models.Schema
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, schema) {
+		t.Errorf("schema reads back as %+v", decoded)
+	}
+
+	// The tables are created again from their description
+	var stmts []string
+	for i := range schema.Tables {
+		stmts = append(stmts, NewSQLite(models.DatabaseConfig{}).CreateTable(&schema.Tables[i], models.SQLite3)...)
+	}
+	again, err := Introspect(ctx, openSQLite(t, stmts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range again.Tables {
+		if got := again.Tables[i]; !reflect.DeepEqual(got.Indexes, want[i].Indexes) || !reflect.DeepEqual(got.ForeignKeys, want[i].ForeignKeys) {
+			t.Errorf("%s created again with indexes %+v and foreign keys %+v", got.Name, got.Indexes, got.ForeignKeys)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// compositeSchema has a table keyed by two columns, referenced through a
+// composite foreign key
+const compositeSchema = `
+CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT NOT NULL);
+CREATE TABLE order_lines (
+    order_id INT NOT NULL,
+    line INT NOT NULL,
+    product TEXT,
+    PRIMARY KEY (order_id, line),
+    FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+CREATE TABLE shipments (
+    id INTEGER PRIMARY KEY,
+    order_id INT,
+    line INT,
+    FOREIGN KEY (order_id, line) REFERENCES order_lines(order_id, line)
+);
+INSERT INTO orders VALUES (1, 'acme'), (2, 'globex');
+INSERT INTO order_lines VALUES (1, 1, 'bolts'), (1, 2, 'nuts'), (2, 1, 'gears');
+INSERT INTO shipments VALUES (10, 1, 2), (11, 2, 1), (12, NULL, NULL);
+`
+
+func TestTraverseCompositeKeys(t *testing.T) {
+	sess, _ := openScript(t, compositeSchema)
+	tests := []struct {
+		name  string
+		seeds []models.Seed
+		want  map[string][]string
+	}{
+		{
+			name:  "seed by primary key columns",
+			seeds: []models.Seed{{Table: "order_lines", Rows: []map[string]string{{"order_id": "1", "line": "2"}}}},
+			want: map[string][]string{
+				"order_lines": {"1,2"},
+				"orders":      {"1"},
+				"shipments":   {"10"},
+			},
+		},
+		{
+			name:  "parent through a composite foreign key",
+			seeds: []models.Seed{{Table: "shipments", Keys: []string{"11"}}},
+			want: map[string][]string{
+				"shipments":   {"11"},
+				"order_lines": {"2,1"},
+				"orders":      {"2"},
+			},
+		},
+		{
+			name:  "NULL composite reference",
+			seeds: []models.Seed{{Table: "shipments", Keys: []string{"12"}}},
+			want:  map[string][]string{"shipments": {"12"}},
+		},
+		{
+			name:  "seed by business key",
+			seeds: []models.Seed{{Table: "orders", Rows: []map[string]string{{"customer": "acme"}}}},
+			want: map[string][]string{
+				"orders":      {"1"},
+				"order_lines": {"1,1", "1,2"},
+				"shipments":   {"10"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subset, err := sess.Engine.Traverse(context.Background(), nil, tt.seeds...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(subset); !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraverseCompositeSeedErrors(t *testing.T) {
+	sess, _ := openScript(t, compositeSchema)
+	tests := []struct {
+		name     string
+		seed     models.Seed
+		notFound bool
+	}{
+		{"single value for a composite key", models.Seed{Table: "order_lines", Keys: []string{"1"}}, false},
+		{"unknown column", models.Seed{Table: "order_lines", Rows: []map[string]string{{"order": "1"}}}, false},
+		{"missing row", models.Seed{Table: "order_lines", Rows: []map[string]string{{"order_id": "2", "line": "2"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := sess.Engine.Traverse(context.Background(), nil, tt.seed)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if errors.Is(err, ErrRootNotFound) != tt.notFound {
+				t.Errorf("err = %v, not found %v", err, tt.notFound)
+			}
+		})
+	}
+}
+
+func TestDumpCompositeKeys(t *testing.T) {
+	_, source := openScript(t, compositeSchema)
+	tests := []struct {
+		mode models.DumpMode
+		want map[string]int64
+	}{
+		{models.StructureAndDataIncludingOnly, map[string]int64{"orders": 1, "order_lines": 1, "shipments": 1}},
+		{models.StructureAndDataExcluding, map[string]int64{"orders": 2, "order_lines": 2, "shipments": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			cfg := models.DumpConfig{SourceConfig: source, Mode: tt.mode, RootTable: "order_lines", RootPrimaryKey: "order_id=1&line=2"}
+			script, res := dumpScript(t, cfg)
+			got := make(map[string]int64)
+			for _, table := range res.Tables {
+				got[table.Name] = table.Rows
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+			if violations := foreignKeyViolations(t, script); len(violations) > 0 {
+				t.Errorf("dangling references: %v", violations)
+			}
+		})
+	}
+}
@@ -0,0 +1,541 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// DefaultInsertBatch is the number of rows rendered in a single INSERT statement
+const DefaultInsertBatch = 100
+
+// Phase is a step of a dump operation
+type Phase int
+
+const (
+	// PhaseIntrospection reads the schema of the source database
+	PhaseIntrospection Phase = iota
+	// PhaseTraversal computes the rows selected by the dump mode
+	PhaseTraversal
+	// PhaseExtraction copies the selected rows of every table
+	PhaseExtraction
+	// PhaseWriting writes the table definitions and, once the data is loaded, their constraints
+	PhaseWriting
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseIntrospection:
+		return "introspection"
+	case PhaseTraversal:
+		return "traversal"
+	case PhaseExtraction:
+		return "extraction"
+	case PhaseWriting:
+		return "writing"
+	default:
+		return "unknown"
+	}
+}
+
+// Progress reports the state of a running operation
+type Progress struct {
+	Phase    Phase
+	Table    string // table being processed, if any
+	Tables   int    // tables processed so far in the phase
+	Total    int    // tables to process in the phase
+	Rows     int64  // rows written for Table so far
+	Expected int64  // rows expected for Table, -1 when unknown
+	Message  string
+}
+
+// ProgressFunc receives progress updates; it is called from the goroutine running the operation
+type ProgressFunc func(Progress)
+
+// Options customises a dump run
+type Options struct {
+	// Progress receives progress updates, may be nil
+	Progress ProgressFunc
+	// Output overrides the output file of file dumps, may be nil
+	Output io.Writer
+	// ReportPath is where the JSON report of a successful dump is written, if set
+	ReportPath string
+}
+
+// TableResult holds the outcome of a dump for one table
+type TableResult struct {
+	Name     string `json:"name"`
+	Filter   Filter `json:"filter"`
+	Rows     int64  `json:"rows"`
+	Deferred int64  `json:"deferred,omitempty"` // references restored by UPDATE once every table is loaded, to break cycles
+}
+
+// Result summarises a completed dump
+type Result struct {
+	Source   string              `json:"source"`
+	Mode     models.DumpMode     `json:"mode"`
+	Snapshot models.SnapshotMode `json:"snapshot"`
+	Target   models.DumpTarget   `json:"target"`
+	Output   string              `json:"output,omitempty"`
+	Report   string              `json:"-"` // path of the JSON report, if one was written
+	Tables   []TableResult       `json:"tables"`
+	Rows     int64               `json:"rows"`
+	Bytes    int64               `json:"bytes"`
+	Started  time.Time           `json:"started"`
+	Duration time.Duration       `json:"-"`
+	Cuts     []Cut               `json:"cuts,omitempty"`
+	Warnings []string            `json:"warnings,omitempty"`
+}
+
+// Run executes a dump: it introspects the source, computes the selected rows
+// and writes structure and data to the configured target
+func Run(ctx context.Context, cfg models.DumpConfig, opts Options) (*Result, error) {
+	res := &Result{
+		Source:   describeDatabase(cfg.SourceConfig),
+		Mode:     cfg.Mode,
+		Snapshot: cfg.Snapshot.OrDefault(),
+		Target:   cfg.Target,
+		Started:  time.Now(),
+	}
+	report := func(p Progress) {
+		if opts.Progress != nil {
+			opts.Progress(p)
+		}
+	}
+
+	if err := cfg.ValidateWorkers(); err != nil {
+		return nil, err
+	}
+
+	report(Progress{Phase: PhaseIntrospection, Message: "introspecting source schema"})
+	sess, err := OpenDump(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Close()
+
+	report(Progress{Phase: PhaseTraversal, Message: "computing selected rows"})
+	sel, err := sess.Engine.Select(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	res.Cuts = sel.Cuts
+	res.Warnings = append(res.Warnings, sel.Warnings...)
+
+	out, dialect, err := openTarget(ctx, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &dumper{
+		session:    sess,
+		selection:  sel,
+		sink:       out,
+		dialect:    dialect,
+		report:     report,
+		snapshot:   cfg.Snapshot,
+		workers:    cfg.Workers,
+		chunkRows:  cfg.ChunkRows,
+		bufferRows: cfg.BufferRows,
+	}
+	if d.chunkRows == 0 {
+		d.chunkRows = DefaultChunkRows
+	}
+	if d.bufferRows == 0 {
+		d.bufferRows = DefaultBufferRows
+	}
+	err = d.run(ctx, res)
+	if cerr := out.close(); err == nil && cerr != nil {
+		err = fmt.Errorf("closing output: %w", cerr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res.Bytes = out.written()
+	if cfg.Target == models.ToFile && opts.Output == nil {
+		res.Output = cfg.OutputPath
+	} else if cfg.Target == models.ToDatabase {
+		res.Output = describeDatabase(*cfg.TargetConfig)
+	}
+	res.Duration = time.Since(res.Started)
+
+	if path := opts.ReportPath; path != "" {
+		if err := res.WriteReport(path); err != nil {
+			res.Warnings = append(res.Warnings, err.Error())
+		} else {
+			res.Report = path
+		}
+	}
+	return res, nil
+}
+
+// openTarget opens the sink of a dump and returns the adapter rendering its
+// statements. File dumps are written in the target dialect when one is
+// configured and in the source dialect otherwise.
+func openTarget(ctx context.Context, cfg models.DumpConfig, opts Options) (sink, adapters.Adapter, error) {
+	switch cfg.Target {
+	case models.ToFile:
+		dialectCfg := cfg.SourceConfig
+		if cfg.TargetConfig != nil && cfg.TargetConfig.Type != "" {
+			dialectCfg = *cfg.TargetConfig
+		}
+		dialect, err := adapters.New(dialectCfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cfg.OutputPath == "" && opts.Output == nil {
+			return nil, nil, errors.New("no output path configured")
+		}
+		out, err := newFileSink(cfg.OutputPath, opts.Output)
+		if err != nil {
+			return nil, nil, err
+		}
+		return out, dialect, nil
+	case models.ToDatabase:
+		if cfg.TargetConfig == nil {
+			return nil, nil, errors.New("no target database configured")
+		}
+		target, err := adapters.Open(ctx, *cfg.TargetConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("target database: %w", err)
+		}
+		out, err := newDatabaseSink(ctx, target)
+		if err != nil {
+			return nil, nil, err
+		}
+		return out, target, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported dump target %s", cfg.Target)
+	}
+}
+
+// describeDatabase returns a short human readable name of a database
+func describeDatabase(cfg models.DatabaseConfig) string {
+	if cfg.Type == models.SQLite3 {
+		if cfg.FilePath != "" {
+			return cfg.FilePath
+		}
+		return cfg.Database
+	}
+	return fmt.Sprintf("%s://%s:%s/%s", cfg.Type, cfg.Host, cfg.Port, cfg.Database)
+}
+
+// dumper writes the selected structure and rows of a session to a sink
+type dumper struct {
+	session   *Session
+	selection *Selection
+	sink      sink
+	dialect   adapters.Adapter
+	report    ProgressFunc
+	deferred  map[string][]models.ForeignKey
+	updates   spool // statements restoring the deferred references, run once every table is loaded
+
+	snapshot   models.SnapshotMode
+	workers    int // tables and key ranges read concurrently, one at a time when below 2
+	chunkRows  int
+	bufferRows int
+	extractor  *extractor       // workers reading the rows, nil when read one table at a time
+	counts     map[string]int64 // rows of the tables counted while splitting them into key ranges
+}
+
+func (d *dumper) run(ctx context.Context, res *Result) error {
+	schema := d.session.Schema
+	from := schema.Type
+	order := orderTables(d.selection.Scope.Tables)
+	tables := order.Tables
+	d.deferred = order.Deferred
+	res.Warnings = append(res.Warnings, order.Warnings...)
+	defer d.updates.close()
+
+	if err := d.sink.comment(fmt.Sprintf("reltrace dump of %s (%s)\nmode: %s\ncreated: %s",
+		schema.Name, from, d.selection.Mode, res.Started.UTC().Format(time.RFC3339))); err != nil {
+		return err
+	}
+	if err := d.execAll(ctx, d.dialect.Preamble()); err != nil {
+		return err
+	}
+
+	total := len(tables)
+	for i := range tables {
+		t := &tables[i]
+		d.report(Progress{Phase: PhaseWriting, Table: t.Name, Tables: i, Total: total, Message: "creating table"})
+		if err := d.sink.comment("\nTable structure for " + t.Name); err != nil {
+			return err
+		}
+		if err := d.sink.exec(ctx, d.dialect.DropTable(t.Name)); err != nil {
+			return err
+		}
+		if err := d.execAll(ctx, d.dialect.CreateTable(t, from)); err != nil {
+			return err
+		}
+	}
+
+	extractor, err := d.startExtraction(ctx, tables)
+	if err != nil {
+		return err
+	}
+	if extractor != nil {
+		defer extractor.stop()
+		d.extractor = extractor
+	}
+
+	for i := range tables {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		t := &tables[i]
+		filter := d.selection.Filter(t.Name)
+		expected, err := d.expectedRows(ctx, t.Name, filter)
+		if err != nil {
+			return err
+		}
+		d.report(Progress{Phase: PhaseExtraction, Table: t.Name, Tables: i, Total: total, Expected: expected})
+
+		queued := d.updates.len()
+		rows, err := d.copyRows(ctx, t, filter, func(n int64) {
+			d.report(Progress{Phase: PhaseExtraction, Table: t.Name, Tables: i, Total: total, Rows: n, Expected: expected})
+		})
+		if err != nil {
+			return fmt.Errorf("dumping %s: %w", t.Name, err)
+		}
+		res.Tables = append(res.Tables, TableResult{Name: t.Name, Filter: filter, Rows: rows, Deferred: d.updates.len() - queued})
+		res.Rows += rows
+	}
+
+	if n := d.updates.len(); n > 0 {
+		d.report(Progress{Phase: PhaseWriting, Message: fmt.Sprintf("restoring %d references deferred to break cycles", n)})
+		if err := d.sink.comment("\nReferences deferred to break cycles"); err != nil {
+			return err
+		}
+		if err := d.updates.replay(ctx, d.sink); err != nil {
+			return err
+		}
+	}
+
+	for i := range tables {
+		t := &tables[i]
+		d.report(Progress{Phase: PhaseWriting, Table: t.Name, Tables: i, Total: total, Message: "adding constraints"})
+		if err := d.execAll(ctx, d.dialect.FinishTable(t)); err != nil {
+			return err
+		}
+	}
+	return d.execAll(ctx, d.dialect.Postamble())
+}
+
+// expectedRows returns the number of rows a table contributes to the dump
+func (d *dumper) expectedRows(ctx context.Context, table string, filter Filter) (int64, error) {
+	switch filter {
+	case FilterOnly:
+		return int64(d.selection.Subset.Rows(table).Len()), nil
+	case FilterAll, FilterExcept:
+		n, ok := d.counts[table]
+		if !ok {
+			var err error
+			if n, err = d.session.RowCount(ctx, table); err != nil {
+				return 0, err
+			}
+		}
+		if filter == FilterExcept {
+			n -= int64(d.selection.Subset.Rows(table).Len())
+		}
+		return n, nil
+	default:
+		return 0, nil
+	}
+}
+
+// copyRows writes the selected rows of a table as batched INSERT statements
+func (d *dumper) copyRows(ctx context.Context, t *models.Table, filter Filter, progress func(int64)) (int64, error) {
+	if filter == FilterNone {
+		return 0, nil
+	}
+
+	columns := t.ColumnNames()
+	pk := keyPositions(t)
+	breaker := newCycleBreaker(t, d.deferred[t.Name])
+	var (
+		batch   [][]any
+		written int64
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		// File sinks never look at the context, so check it between batches
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := d.sink.exec(ctx, d.dialect.Insert(t.Name, columns, batch)); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		progress(written)
+		return nil
+	}
+	add := func(values []any) error {
+		if filter == FilterExcept && pk != nil && !d.selection.Includes(t.Name, rowKey(values, pk)) {
+			return nil
+		}
+		if breaker != nil {
+			if err := d.updates.add(breaker.row(d.dialect, values)...); err != nil {
+				return err
+			}
+		}
+		batch = append(batch, values)
+		written++
+		if len(batch) >= DefaultInsertBatch {
+			return flush()
+		}
+		return nil
+	}
+
+	if err := d.sink.comment("\nData for " + t.Name); err != nil {
+		return 0, err
+	}
+
+	if err := d.readRows(ctx, t, filter, add); err != nil {
+		return 0, err
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// readRows passes the selected rows of a table to fn in primary key order,
+// from the extraction workers when there are some
+func (d *dumper) readRows(ctx context.Context, t *models.Table, filter Filter, fn adapters.RowFunc) error {
+	if d.extractor != nil {
+		return d.extractor.rows(ctx, t.Name, fn)
+	}
+
+	q := adapters.RowQuery{Table: t.Name, Columns: t.ColumnNames(), OrderBy: t.PrimaryKey}
+	if filter != FilterOnly {
+		return d.session.Source.StreamRows(ctx, q, fn)
+	}
+	for _, q := range d.subsetQueries(t, q) {
+		if err := d.session.Source.StreamRows(ctx, q, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subsetQueries returns the queries reading the subset rows of a table by
+// primary key, in batches
+func (d *dumper) subsetQueries(t *models.Table, q adapters.RowQuery) []adapters.RowQuery {
+	if len(t.PrimaryKey) == 0 {
+		return nil
+	}
+
+	src := d.session.Source
+	keys := d.selection.Subset.Rows(t.Name).Keys()
+	batchSize := max(1, d.session.Engine.batchSize/len(t.PrimaryKey))
+	var queries []adapters.RowQuery
+	for start := 0; start < len(keys); start += batchSize {
+		batch := keys[start:min(start+batchSize, len(keys))]
+		q.Where = inPredicate(src, t.PrimaryKey, len(batch))
+		q.Args = flatten(batch)
+		queries = append(queries, q)
+	}
+	return queries
+}
+
+// cycleBreaker writes the deferred foreign key columns of a table as NULL and
+// renders the statements restoring them. A self reference to a row that is
+// already written is kept as it is.
+type cycleBreaker struct {
+	table   *models.Table
+	keys    []models.ForeignKey
+	columns [][]int // positions of the columns of every deferred key
+	pk      []int
+	written *RowSet // rows written so far, when a key references the table itself
+}
+
+// newCycleBreaker returns the breaker of the deferred keys of a table, or nil
+func newCycleBreaker(t *models.Table, keys []models.ForeignKey) *cycleBreaker {
+	if len(keys) == 0 {
+		return nil
+	}
+	b := &cycleBreaker{table: t, keys: keys, pk: keyPositions(t)}
+	names := t.ColumnNames()
+	for _, fk := range keys {
+		positions := make([]int, len(fk.Columns))
+		for i, c := range fk.Columns {
+			positions[i] = slices.Index(names, c)
+		}
+		b.columns = append(b.columns, positions)
+		if fk.RefTable == t.Name && slices.Equal(fk.RefColumns, t.PrimaryKey) {
+			b.written = newRowSet()
+		}
+	}
+	return b
+}
+
+// row clears the deferred references of a row and returns the UPDATE
+// statements restoring them
+func (b *cycleBreaker) row(a adapters.Adapter, values []any) []string {
+	var stmts []string
+	key := rowKey(values, b.pk)
+	for i, fk := range b.keys {
+		ref := rowKey(values, b.columns[i])
+		if slices.Contains(ref, nil) {
+			continue
+		}
+		if b.written != nil && fk.RefTable == b.table.Name && b.written.Contains(ref) {
+			continue
+		}
+		stmts = append(stmts, a.Update(b.table.Name, fk.Columns, ref, b.table.PrimaryKey, key))
+		for _, p := range b.columns[i] {
+			values[p] = nil
+		}
+	}
+	if b.written != nil {
+		b.written.Add(key)
+	}
+	return stmts
+}
+
+func (d *dumper) execAll(ctx context.Context, stmts []string) error {
+	for _, stmt := range stmts {
+		if err := d.sink.exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyPositions returns the positions of the primary key columns within the
+// column list of a table, or nil when the table has no primary key
+func keyPositions(t *models.Table) []int {
+	if len(t.PrimaryKey) == 0 {
+		return nil
+	}
+	names := t.ColumnNames()
+	positions := make([]int, 0, len(t.PrimaryKey))
+	for _, col := range t.PrimaryKey {
+		for i, name := range names {
+			if name == col {
+				positions = append(positions, i)
+				break
+			}
+		}
+	}
+	return positions
+}
+
+// rowKey extracts the primary key of a row read with all table columns
+func rowKey(values []any, positions []int) Key {
+	key := make(Key, len(positions))
+	for i, p := range positions {
+		key[i] = normalizeValue(values[p])
+	}
+	return key
+}
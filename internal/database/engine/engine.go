@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// DefaultBatchSize is the number of key values sent in a single IN list
+const DefaultBatchSize = 500
+
+var (
+	// ErrUnknownTable is returned when a table is not part of the schema
+	ErrUnknownTable = errors.New("unknown table")
+	// ErrNoPrimaryKey is returned when a table cannot be traversed because it has no primary key
+	ErrNoPrimaryKey = errors.New("table has no primary key")
+	// ErrRootNotFound is returned when the root row does not exist
+	ErrRootNotFound = errors.New("root row not found")
+)
+
+// Querier runs read queries; it is satisfied by *sql.DB, *sql.Conn and *sql.Tx
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Dialect renders the dialect specific parts of the queries built by the engine
+type Dialect interface {
+	QuoteIdentifier(name string) string
+	Placeholder(n int) string
+	JSONValue(column, path string) string
+}
+
+// Engine walks the relationships of a schema to compute row subsets
+type Engine struct {
+	db        Querier
+	dialect   Dialect
+	schema    *models.Schema
+	graph     *Graph
+	batchSize int
+	origins   bool // record the origin of every row reached by a traversal
+}
+
+// New creates a new traversal engine over an introspected schema
+func New(db Querier, dialect Dialect, schema *models.Schema) *Engine {
+	return &Engine{
+		db:        db,
+		dialect:   dialect,
+		schema:    schema,
+		graph:     NewGraph(schema),
+		batchSize: DefaultBatchSize,
+	}
+}
+
+// RecordOrigins makes traversals record, for every row, the row and
+// relationship it was first reached through, which Subset.Path reports. It
+// costs memory per row and wider queries, so it is off by default.
+func (e *Engine) RecordOrigins(record bool) {
+	e.origins = record
+}
+
+// Graph returns the relationship graph used by the engine
+func (e *Engine) Graph() *Graph {
+	return e.graph
+}
+
+// Schema returns the schema the engine was built from
+func (e *Engine) Schema() *models.Schema {
+	return e.schema
+}
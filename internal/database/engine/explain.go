@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Explanation tells how a traversal reached a row
+type Explanation struct {
+	Row  Step   `json:"row"`
+	Path []Step `json:"path,omitempty"` // from a seed to the row, empty when the row was not reached
+}
+
+// Reached reports whether the row is part of the subset
+func (x Explanation) Reached() bool {
+	return len(x.Path) > 0
+}
+
+// Chain renders the path as seed → ... → row
+func (x Explanation) Chain() string {
+	steps := make([]string, len(x.Path))
+	for i, s := range x.Path {
+		steps[i] = s.String()
+	}
+	return strings.Join(steps, " → ")
+}
+
+// Explain computes the subset of a dump configuration, recording the origin
+// of every row, and returns the paths through which the rows of table
+// identified by key were first reached. The key is a primary key value, or
+// column=value pairs for a composite key.
+func (s *Session) Explain(ctx context.Context, cfg models.DumpConfig, table, key string) ([]Explanation, error) {
+	seed, err := models.ParseSeed(table, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Mode != models.StructureAndDataExcluding && cfg.Mode != models.StructureAndDataIncludingOnly {
+		return nil, fmt.Errorf("mode %s does not compute a subset", cfg.Mode)
+	}
+	keys, err := s.Engine.seedKeys(ctx, seed)
+	if errors.Is(err, ErrRootNotFound) {
+		return nil, fmt.Errorf("%s has no row %s", table, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.Engine.RecordOrigins(true)
+	defer s.Engine.RecordOrigins(false)
+	sel, err := s.Engine.Select(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	explained := make([]Explanation, len(keys))
+	for i, k := range keys {
+		explained[i] = Explanation{
+			Row:  Step{Table: table, Key: k},
+			Path: sel.Subset.Path(table, k),
+		}
+	}
+	return explained, nil
+}
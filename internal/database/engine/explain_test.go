@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+func TestExplain(t *testing.T) {
+	sess, _ := openFixture(t)
+	root := []models.Seed{{Table: "employees", Keys: []string{"3"}}}
+	tests := []struct {
+		name       string
+		mode       models.DumpMode
+		table, key string
+		chain      string
+		via        []string
+	}{
+		{
+			name:  "parent in the including mode",
+			mode:  models.StructureAndDataIncludingOnly,
+			table: "employees", key: "1",
+			chain: "employees#3 → employees#2 → employees#1",
+			via: []string{
+				"seed",
+				"parent via employees(manager_id) -> employees(id)",
+				"parent via employees(manager_id) -> employees(id)",
+			},
+		},
+		{
+			name:  "child in the including mode",
+			mode:  models.StructureAndDataIncludingOnly,
+			table: "expense_categories", key: "1",
+			chain: "employees#3 → expenses#1 → expense_categories#2 → expense_categories#1",
+			via: []string{
+				"seed",
+				"child via expenses(employee_id) -> employees(id)",
+				"parent via expenses(category_id) -> expense_categories(id)",
+				"parent via expense_categories(parent_category_id) -> expense_categories(id)",
+			},
+		},
+		{
+			name:  "dependent in the excluding mode",
+			mode:  models.StructureAndDataExcluding,
+			table: "expenses", key: "1",
+			chain: "employees#3 → expenses#1",
+			via:   []string{"seed", "child via expenses(employee_id) -> employees(id)"},
+		},
+		{
+			name:  "parent kept by the excluding mode",
+			mode:  models.StructureAndDataExcluding,
+			table: "employees", key: "1",
+		},
+		{
+			name:  "row out of the subset",
+			mode:  models.StructureAndDataIncludingOnly,
+			table: "companies", key: "5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, err := sess.Explain(context.Background(), models.DumpConfig{Mode: tt.mode, Seeds: root}, tt.table, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(explained) != 1 {
+				t.Fatalf("%d explanations, want 1", len(explained))
+			}
+			x := explained[0]
+			if x.Reached() != (tt.chain != "") {
+				t.Fatalf("reached = %v, want %v", x.Reached(), tt.chain != "")
+			}
+			if got := x.Chain(); got != tt.chain {
+				t.Errorf("chain = %q, want %q", got, tt.chain)
+			}
+			var via []string
+			for _, s := range x.Path {
+				via = append(via, s.Via())
+			}
+			if !slices.Equal(via, tt.via) {
+				t.Errorf("via = %q, want %q", via, tt.via)
+			}
+		})
+	}
+}
+
+func TestExplainErrors(t *testing.T) {
+	sess, _ := openFixture(t)
+	root := []models.Seed{{Table: "employees", Keys: []string{"3"}}}
+	tests := []struct {
+		name       string
+		mode       models.DumpMode
+		table, key string
+	}{
+		{"mode without a subset", models.StructureAndData, "employees", "1"},
+		{"missing row", models.StructureAndDataIncludingOnly, "employees", "999"},
+		{"unknown table", models.StructureAndDataIncludingOnly, "nope", "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := sess.Explain(context.Background(), models.DumpConfig{Mode: tt.mode, Seeds: root}, tt.table, tt.key); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestSubsetPath(t *testing.T) {
+	sess, _ := openFixture(t)
+	ctx := context.Background()
+	root := models.Seed{Table: "employees", Keys: []string{"3"}}
+
+	// Without recorded origins a row is its own path
+	subset, err := sess.Engine.Traverse(ctx, nil, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subset.Recorded() {
+		t.Error("origins recorded without being asked for")
+	}
+	if got := subset.Path("employees", Key{int64(1)}); len(got) != 1 {
+		t.Errorf("path = %v, want the row alone", got)
+	}
+
+	sess.Engine.RecordOrigins(true)
+	defer sess.Engine.RecordOrigins(false)
+	subset, err = sess.Engine.Traverse(ctx, nil, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := subset.Path("companies", Key{int64(5)}); got != nil {
+		t.Errorf("path of a row out of the subset = %v, want nil", got)
+	}
+	// Every step but the seed was reached from the step before it
+	for _, table := range subset.Tables() {
+		for _, k := range subset.Rows(table).Keys() {
+			path := subset.Path(table, k)
+			if len(path) == 0 || path[0].Via() != "seed" {
+				t.Errorf("path of %s#%v = %v, want it to start at a seed", table, k, path)
+			}
+			for _, s := range path[1:] {
+				if s.Edge == "" {
+					t.Errorf("path of %s#%v = %v, has a step without a relationship", table, k, path)
+				}
+			}
+		}
+	}
+}
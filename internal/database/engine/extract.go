@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+const (
+	// DefaultChunkRows is the number of rows above which a table keyed by a
+	// single integer column is read by several workers, in key ranges
+	DefaultChunkRows = 50000
+	// DefaultBufferRows is the number of rows a worker reads ahead of the writer
+	DefaultBufferRows = 1000
+)
+
+// extractor reads the selected rows of a dump with a pool of workers, each
+// on its own connection, while the writer consumes them in dump order. The
+// rows of every table are split into chunks; a worker starts on the next
+// chunk only while fewer chunks than there are workers are read and not yet
+// written, so at most that many buffers of rows are held at once.
+type extractor struct {
+	chunks  map[string][]*chunk // by table, in key order
+	readers chan adapters.Querier
+	tokens  chan struct{} // one per chunk read and not yet written
+	shared  []*sql.Conn   // connections importing the snapshot of the session
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// chunk is a part of the rows of a table, read by one worker into a buffer
+type chunk struct {
+	query adapters.RowQuery
+	rows  chan []any
+	err   error // set before rows is closed
+}
+
+// startExtraction starts reading the rows of tables, in order, with the
+// configured number of workers. It returns nil when the tables are to be read
+// one at a time.
+func (d *dumper) startExtraction(ctx context.Context, tables []models.Table) (*extractor, error) {
+	if d.workers <= 1 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	x := &extractor{
+		chunks:  make(map[string][]*chunk, len(tables)),
+		readers: make(chan adapters.Querier, d.workers),
+		tokens:  make(chan struct{}, d.workers),
+		cancel:  cancel,
+	}
+	if err := x.openReaders(ctx, d.session.Source, d.snapshot, d.workers); err != nil {
+		x.stop()
+		return nil, err
+	}
+
+	var order []*chunk
+	for i := range tables {
+		t := &tables[i]
+		queries, err := d.chunkQueries(ctx, t)
+		if err != nil {
+			x.stop()
+			return nil, err
+		}
+		for _, q := range queries {
+			c := &chunk{query: q, rows: make(chan []any, d.bufferRows)}
+			x.chunks[t.Name] = append(x.chunks[t.Name], c)
+			order = append(order, c)
+		}
+	}
+
+	x.wg.Add(1)
+	go x.dispatch(ctx, d.session.Source, order)
+	return x, nil
+}
+
+// openReaders provides a connection to every worker. Inside a transaction
+// snapshot each worker reads on a connection importing it; table locks and
+// unsnapshotted reads leave the workers to the connection pool.
+func (x *extractor) openReaders(ctx context.Context, src adapters.Adapter, mode models.SnapshotMode, workers int) error {
+	for range workers {
+		if mode.OrDefault() != models.SnapshotTransaction {
+			x.readers <- src.DB()
+			continue
+		}
+		conn, err := src.ShareSnapshot(ctx)
+		if err != nil {
+			return fmt.Errorf("opening extraction worker: %w", err)
+		}
+		x.shared = append(x.shared, conn)
+		x.readers <- conn
+	}
+	return nil
+}
+
+// dispatch hands the chunks to workers in dump order, waiting for the writer
+// whenever every worker has a chunk it has not written yet
+func (x *extractor) dispatch(ctx context.Context, src adapters.Adapter, order []*chunk) {
+	defer x.wg.Done()
+	for _, c := range order {
+		select {
+		case x.tokens <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		// A reader is free: chunks holding one also hold a token
+		from := <-x.readers
+		x.wg.Add(1)
+		go func() {
+			defer x.wg.Done()
+			c.read(ctx, src, from)
+			x.readers <- from
+		}()
+	}
+}
+
+// read streams the rows of the chunk into its buffer, then closes it
+func (c *chunk) read(ctx context.Context, src adapters.Adapter, from adapters.Querier) {
+	defer close(c.rows)
+	q := c.query
+	q.From = from
+	c.err = src.StreamRows(ctx, q, func(row []any) error {
+		select {
+		case c.rows <- row:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// rows passes the rows of a table to fn in key order as the workers read
+// them, freeing a worker for the next chunk once a chunk is written
+func (x *extractor) rows(ctx context.Context, table string, fn adapters.RowFunc) error {
+	for _, c := range x.chunks[table] {
+		if err := c.drain(ctx, fn); err != nil {
+			return err
+		}
+		<-x.tokens
+	}
+	return nil
+}
+
+// drain passes the buffered rows of the chunk to fn until it is read
+func (c *chunk) drain(ctx context.Context, fn adapters.RowFunc) error {
+	for {
+		select {
+		case row, ok := <-c.rows:
+			if !ok {
+				return c.err
+			}
+			if err := fn(row); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// stop cancels the workers, waits for them and releases the connections
+// importing the snapshot
+func (x *extractor) stop() {
+	x.cancel()
+	x.wg.Wait()
+	for _, conn := range x.shared {
+		// Released even when the dump was cancelled; the transactions only read
+		conn.ExecContext(context.Background(), "COMMIT")
+		conn.Close()
+	}
+	x.shared = nil
+}
+
+// chunkQueries splits the selected rows of a table into the queries workers
+// run: batches of subset keys, key ranges of a big table keyed by a single
+// integer column, or the whole table
+func (d *dumper) chunkQueries(ctx context.Context, t *models.Table) ([]adapters.RowQuery, error) {
+	q := adapters.RowQuery{Table: t.Name, Columns: t.ColumnNames(), OrderBy: t.PrimaryKey}
+	switch d.selection.Filter(t.Name) {
+	case FilterNone:
+		return nil, nil
+	case FilterOnly:
+		return d.subsetQueries(t, q), nil
+	default:
+		return d.keyRanges(ctx, t, q)
+	}
+}
+
+// keyRanges splits the rows of a table keyed by a single integer column into
+// ranges of about chunkRows rows each, judged from the bounds of the key, and
+// keeps the row count for the progress of the table. Smaller tables and
+// tables with other keys are read by a single query.
+func (d *dumper) keyRanges(ctx context.Context, t *models.Table, q adapters.RowQuery) ([]adapters.RowQuery, error) {
+	whole := []adapters.RowQuery{q}
+	if len(t.PrimaryKey) != 1 {
+		return whole, nil
+	}
+	if c := t.Column(t.PrimaryKey[0]); c == nil || !adapters.IsIntegerType(c.DataType) {
+		return whole, nil
+	}
+
+	src := d.session.Source
+	col := src.QuoteIdentifier(t.PrimaryKey[0])
+	var (
+		n      int64
+		lo, hi any
+	)
+	query := fmt.Sprintf("SELECT COUNT(*), MIN(%s), MAX(%s) FROM %s", col, col, src.QuoteIdentifier(t.Name))
+	if err := src.Reader().QueryRowContext(ctx, query).Scan(&n, &lo, &hi); err != nil {
+		return nil, fmt.Errorf("reading key bounds of %s: %w", t.Name, err)
+	}
+	if d.counts == nil {
+		d.counts = make(map[string]int64)
+	}
+	d.counts[t.Name] = n
+	if n <= int64(d.chunkRows) {
+		return whole, nil
+	}
+	first, err := strconv.ParseInt(canonicalValue(lo), 10, 64)
+	if err != nil {
+		return whole, nil
+	}
+	last, err := strconv.ParseInt(canonicalValue(hi), 10, 64)
+	if err != nil {
+		return whole, nil
+	}
+
+	// The first range also takes the NULL keys SQLite allows, which sort
+	// first, and the last one is open so no row is missed
+	parts := (n + int64(d.chunkRows) - 1) / int64(d.chunkRows)
+	step := max(1, (last-first)/parts+1)
+	var (
+		queries []adapters.RowQuery
+		lower   int64
+	)
+	for bound := first + step; bound <= last; bound += step {
+		r := q
+		if len(queries) == 0 {
+			r.Where = fmt.Sprintf("%s IS NULL OR %s < %s", col, col, src.Placeholder(1))
+			r.Args = []any{bound}
+		} else {
+			r.Where = fmt.Sprintf("%s >= %s AND %s < %s", col, src.Placeholder(1), col, src.Placeholder(2))
+			r.Args = []any{lower, bound}
+		}
+		queries = append(queries, r)
+		lower = bound
+	}
+	r := q
+	if len(queries) > 0 {
+		r.Where = fmt.Sprintf("%s >= %s", col, src.Placeholder(1))
+		r.Args = []any{lower}
+	}
+	return append(queries, r), nil
+}
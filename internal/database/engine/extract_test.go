@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// extractSchema returns tables whose keys split into ranges differently
+func extractSchema() string {
+	var b strings.Builder
+	b.WriteString(`
+CREATE TABLE sparse (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE negative (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE null_keys (id INT PRIMARY KEY, v TEXT);
+CREATE TABLE empty (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE composite (a INTEGER NOT NULL, b INTEGER NOT NULL, v TEXT, PRIMARY KEY (a, b));
+CREATE TABLE text_keys (code TEXT PRIMARY KEY, v TEXT);
+`)
+	insert := func(table string, keys ...string) {
+		values := make([]string, len(keys))
+		for i, k := range keys {
+			values[i] = fmt.Sprintf("(%s, 'row %d')", k, i)
+		}
+		fmt.Fprintf(&b, "INSERT INTO %s VALUES %s;\n", table, strings.Join(values, ", "))
+	}
+	var sparse, negative, nulls, composite, text []string
+	for i := range 20 {
+		sparse = append(sparse, fmt.Sprint(i+1), fmt.Sprint(1000+i))
+		negative = append(negative, fmt.Sprint(-100+i), fmt.Sprint(i-10))
+		nulls = append(nulls, fmt.Sprint(i*3))
+		composite = append(composite, fmt.Sprintf("%d, %d", i/4, i%4))
+		text = append(text, fmt.Sprintf("'k%02d'", i))
+	}
+	insert("sparse", append(sparse, "1000000")...)
+	insert("negative", negative...)
+	insert("null_keys", append(nulls, "NULL", "NULL")...)
+	insert("composite", composite...)
+	insert("text_keys", text...)
+	return b.String()
+}
+
+func TestKeyRanges(t *testing.T) {
+	sess, _ := openScript(t, extractSchema())
+	d := &dumper{session: sess, chunkRows: 10}
+	ctx := context.Background()
+	tests := []struct {
+		table  string
+		chunks int
+		count  int64 // -1 when the table has no integer key to count by
+	}{
+		{"sparse", 5, 41},
+		{"negative", 4, 40},
+		{"null_keys", 3, 22},
+		{"empty", 1, 0},
+		{"composite", 1, -1},
+		{"text_keys", 1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			table := sess.Schema.Table(tt.table)
+			q := adapters.RowQuery{Table: table.Name, Columns: table.ColumnNames(), OrderBy: table.PrimaryKey}
+			queries, err := d.keyRanges(ctx, table, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(queries) != tt.chunks {
+				t.Errorf("%d chunks, want %d", len(queries), tt.chunks)
+			}
+			// The count is kept for the progress of the dump
+			if n, ok := d.counts[tt.table]; ok != (tt.count >= 0) || n != max(tt.count, 0) {
+				t.Errorf("count = %d, %t, want %d", n, ok, tt.count)
+			}
+
+			// Every row is read by exactly one chunk, in key order
+			read := func(queries ...adapters.RowQuery) []string {
+				var rows []string
+				for _, q := range queries {
+					err := sess.Source.StreamRows(ctx, q, func(row []any) error {
+						rows = append(rows, fmt.Sprint(row...))
+						return nil
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				return rows
+			}
+			if got, want := read(queries...), read(q); !slices.Equal(got, want) {
+				t.Errorf("chunks read %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// created matches the creation time in the header of a dump
+var created = regexp.MustCompile(`created: \S+`)
+
+func TestParallelDump(t *testing.T) {
+	_, fixture := openFixture(t)
+	_, generated := openScript(t, extractSchema())
+	employee := []models.Seed{{Table: "employees", Keys: []string{"3"}}}
+	tests := []struct {
+		name string
+		cfg  models.DumpConfig
+	}{
+		{"full", models.DumpConfig{SourceConfig: fixture, Mode: models.StructureAndData}},
+		{"including", models.DumpConfig{SourceConfig: fixture, Mode: models.StructureAndDataIncludingOnly, Seeds: employee}},
+		{"excluding", models.DumpConfig{SourceConfig: fixture, Mode: models.StructureAndDataExcluding, Seeds: employee}},
+		{"key ranges", models.DumpConfig{SourceConfig: generated, Mode: models.StructureAndData}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.ChunkRows = 3
+			tt.cfg.BufferRows = 2
+			tt.cfg.Snapshot = models.SnapshotLocks
+			want, _ := dumpScript(t, tt.cfg)
+
+			for _, snapshot := range []models.SnapshotMode{models.SnapshotLocks, models.SnapshotNone} {
+				cfg := tt.cfg
+				cfg.Workers = 4
+				cfg.Snapshot = snapshot
+				got, res := dumpScript(t, cfg)
+				if len(res.Warnings) > 0 {
+					t.Errorf("%s: unexpected warnings: %v", snapshot, res.Warnings)
+				}
+				if created.ReplaceAllString(got, "") != created.ReplaceAllString(want, "") {
+					t.Errorf("%s: parallel dump differs from the sequential one", snapshot)
+				}
+			}
+		})
+	}
+}
+
+func TestParallelDumpSharedSnapshot(t *testing.T) {
+	_, source := openFixture(t)
+	cfg := models.DumpConfig{SourceConfig: source, Mode: models.StructureAndData, Snapshot: models.SnapshotTransaction}
+	want, _ := dumpScript(t, cfg)
+
+	// SQLite cannot share a transaction between connections
+	cfg.Workers = 4
+	cfg.Target = models.ToFile
+	var out bytes.Buffer
+	if _, err := Run(context.Background(), cfg, Options{Output: &out}); err == nil || !strings.Contains(err.Error(), "cannot share") {
+		t.Errorf("err = %v, want the snapshot refused", err)
+	}
+	if out.Len() > 0 {
+		t.Error("refused dump wrote output")
+	}
+
+	cfg.Workers = 1
+	got, _ := dumpScript(t, cfg)
+	if created.ReplaceAllString(got, "") != created.ReplaceAllString(want, "") {
+		t.Error("single worker dump differs from the default one")
+	}
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// DefaultSearchLimit is the number of rows a row search returns at most
+const DefaultSearchLimit = 100
+
+// RowSearch describes a search for rows of a table by the value of one column.
+// Numeric columns are matched exactly; other columns match case insensitively
+// when they contain Term, or against Term as a LIKE pattern when it holds a %.
+type RowSearch struct {
+	Table  string
+	Column string
+	Term   string // an empty term matches every row
+	Limit  int
+}
+
+// RowMatches holds the rows found by a search
+type RowMatches struct {
+	Columns []string
+	Key     []string // primary key columns
+	Rows    [][]any
+	Keys    []Key
+	More    bool // more rows match than the limit allowed to return
+}
+
+// FindRows searches the rows of a table, for picking root rows by values
+// that are easier to remember than primary keys
+func FindRows(ctx context.Context, src adapters.Adapter, schema *models.Schema, s RowSearch) (*RowMatches, error) {
+	t := schema.Table(s.Table)
+	if t == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTable, s.Table)
+	}
+	if len(t.PrimaryKey) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoPrimaryKey, s.Table)
+	}
+	if s.Limit <= 0 {
+		s.Limit = DefaultSearchLimit
+	}
+
+	// One extra row tells whether the limit cut the results
+	q := adapters.RowQuery{Table: t.Name, Columns: t.ColumnNames(), OrderBy: t.PrimaryKey, Limit: s.Limit + 1}
+	if term := strings.TrimSpace(s.Term); term != "" {
+		col := t.Column(s.Column)
+		if col == nil {
+			return nil, fmt.Errorf("unknown column %s.%s", t.Name, s.Column)
+		}
+		q.Where, q.Args = searchPredicate(src, col, term)
+	}
+
+	positions := keyPositions(t)
+	m := &RowMatches{Columns: q.Columns, Key: t.PrimaryKey}
+	err := src.StreamRows(ctx, q, func(values []any) error {
+		if len(m.Rows) == s.Limit {
+			m.More = true
+			return nil
+		}
+		m.Rows = append(m.Rows, values)
+		m.Keys = append(m.Keys, rowKey(values, positions))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("searching %s: %w", t.Name, err)
+	}
+	return m, nil
+}
+
+// searchPredicate renders the condition matching term against a column
+func searchPredicate(a adapters.Adapter, col *models.Column, term string) (string, []any) {
+	quoted := a.QuoteIdentifier(col.Name)
+	if adapters.IsNumericType(col.DataType) {
+		if _, err := strconv.ParseFloat(term, 64); err == nil {
+			return quoted + " = " + a.Placeholder(1), []any{term}
+		}
+	}
+
+	text := "CAST(" + quoted + " AS TEXT)"
+	if a.Type() == models.MySQL {
+		text = "CAST(" + quoted + " AS CHAR)"
+	}
+	pattern := strings.ToLower(term)
+	if !strings.Contains(pattern, "%") {
+		pattern = "%" + pattern + "%"
+	}
+	return "LOWER(" + text + ") LIKE " + a.Placeholder(1), []any{pattern}
+}
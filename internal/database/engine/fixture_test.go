@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// createDatabase loads a SQL script into a new SQLite database and returns its configuration
+func createDatabase(t *testing.T, script string) models.DatabaseConfig {
+	t.Helper()
+	cfg := models.DatabaseConfig{Type: models.SQLite3, FilePath: filepath.Join(t.TempDir(), "test.db")}
+	if _, err := Restore(context.Background(), cfg, strings.NewReader(script), nil); err != nil {
+		t.Fatalf("loading script: %v", err)
+	}
+	return cfg
+}
+
+// openScript loads a SQL script into a new SQLite database and opens a session on it
+func openScript(t *testing.T, script string) (*Session, models.DatabaseConfig) {
+	t.Helper()
+	cfg := createDatabase(t, script)
+	sess, err := Open(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { sess.Close() })
+	return sess, cfg
+}
+
+// openFixture opens a session on a new SQLite copy of the db.sql fixture
+func openFixture(t *testing.T) (*Session, models.DatabaseConfig) {
+	t.Helper()
+	script, err := os.ReadFile(filepath.Join("testdata", "fixture.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return openScript(t, string(script))
+}
+
+// keys renders the rows of a subset by table, each key with its columns
+// joined by commas, sorted with shorter keys first so integers sort by value
+func keys(s *Subset) map[string][]string {
+	result := make(map[string][]string)
+	for _, table := range s.Tables() {
+		var rendered []string
+		for _, k := range s.Rows(table).Keys() {
+			rendered = append(rendered, strings.ReplaceAll(k.String(), "\x1f", ","))
+		}
+		slices.SortFunc(rendered, func(a, b string) int {
+			return cmp.Or(cmp.Compare(len(a), len(b)), strings.Compare(a, b))
+		})
+		result[table] = rendered
+	}
+	return result
+}
+
+// dumpScript runs a file dump of a configuration and returns the script
+func dumpScript(t *testing.T, cfg models.DumpConfig) (string, *Result) {
+	t.Helper()
+	cfg.Target = models.ToFile
+	var out bytes.Buffer
+	res, err := Run(context.Background(), cfg, Options{Output: &out})
+	if err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	return out.String(), res
+}
+
+// foreignKeyViolations loads a dump into a new SQLite database and returns
+// the rows whose references are dangling, as table#rowid -> parent
+func foreignKeyViolations(t *testing.T, script string) []string {
+	t.Helper()
+	cfg := createDatabase(t, script)
+	db, err := sql.Open("sqlite3", cfg.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var violations []string
+	for rows.Next() {
+		var (
+			table, parent string
+			rowid, fkid   sql.NullInt64
+		)
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			t.Fatal(err)
+		}
+		violations = append(violations, table+"#"+canonicalValue(rowid.Int64)+" -> "+parent)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return violations
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Edge is a directed reference from rows of a child table to rows of a parent table
+type Edge struct {
+	Name          string
+	Child         string
+	ChildColumns  []string
+	Parent        string
+	ParentColumns []string
+	TypeColumn    string // child column that must hold TypeValue, for polymorphic relations
+	TypeValue     string
+	Virtual       bool // not enforced by the database, so referenced rows may be missing
+}
+
+// String returns a readable representation of the edge
+func (e Edge) String() string {
+	s := fmt.Sprintf("%s(%s) -> %s(%s)",
+		e.Child, strings.Join(e.ChildColumns, ", "),
+		e.Parent, strings.Join(e.ParentColumns, ", "))
+	if e.TypeColumn != "" {
+		s += fmt.Sprintf(" where %s = '%s'", e.TypeColumn, e.TypeValue)
+	}
+	return s
+}
+
+// condition restricts a query to the rows whose column holds a value
+type condition struct {
+	column string
+	value  string
+}
+
+// condition returns the restriction the child rows of the edge must satisfy
+func (e Edge) condition() condition {
+	return condition{column: e.TypeColumn, value: e.TypeValue}
+}
+
+// Graph indexes the relationships of a schema by table
+type Graph struct {
+	schema   *models.Schema
+	edges    []Edge
+	outgoing map[string][]int // child table -> edges pointing at parents
+	incoming map[string][]int // parent table -> edges coming from children
+}
+
+// NewGraph builds the relationship graph of a schema from its foreign keys
+func NewGraph(schema *models.Schema) *Graph {
+	g := &Graph{
+		schema:   schema,
+		outgoing: make(map[string][]int),
+		incoming: make(map[string][]int),
+	}
+
+	for _, t := range schema.Tables {
+		for _, fk := range t.ForeignKeys {
+			g.AddEdge(edgeOf(fk))
+		}
+	}
+
+	return g
+}
+
+// edgeOf returns the edge of a foreign key. The child column of a JSON
+// reference is given as column->path, which the queries of the engine
+// render with the JSON functions of the dialect.
+func edgeOf(fk models.ForeignKey) Edge {
+	columns := fk.Columns
+	if fk.JSONPath != "" {
+		columns = []string{fk.Source()}
+	}
+	return Edge{
+		Name:          fk.Name,
+		Child:         fk.Table,
+		ChildColumns:  columns,
+		Parent:        fk.RefTable,
+		ParentColumns: fk.RefColumns,
+		TypeColumn:    fk.TypeColumn,
+		TypeValue:     fk.TypeValue,
+		Virtual:       fk.Virtual,
+	}
+}
+
+// AddEdge registers an additional relationship in the graph
+func (g *Graph) AddEdge(e Edge) {
+	idx := len(g.edges)
+	g.edges = append(g.edges, e)
+	g.outgoing[e.Child] = append(g.outgoing[e.Child], idx)
+	g.incoming[e.Parent] = append(g.incoming[e.Parent], idx)
+}
+
+// Edges returns every relationship in the graph
+func (g *Graph) Edges() []Edge {
+	return g.edges
+}
+
+// Parents returns the edges through which rows of table reference other rows
+func (g *Graph) Parents(table string) []Edge {
+	return g.collect(g.outgoing[table])
+}
+
+// Children returns the edges through which other rows reference rows of table
+func (g *Graph) Children(table string) []Edge {
+	return g.collect(g.incoming[table])
+}
+
+func (g *Graph) collect(indexes []int) []Edge {
+	edges := make([]Edge, len(indexes))
+	for i, idx := range indexes {
+		edges[i] = g.edges[idx]
+	}
+	return edges
+}
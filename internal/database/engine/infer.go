@@ -0,0 +1,214 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Defaults of the relationship inference
+const (
+	DefaultInferSample  = 200
+	DefaultInferOverlap = 0.9
+)
+
+// defaultPlurals holds the irregular plurals of common table names
+var defaultPlurals = map[string]string{
+	"person": "people",
+	"child":  "children",
+	"man":    "men",
+	"woman":  "women",
+}
+
+// defaultSuffixes holds the plural endings of regular words by their
+// singular ending. The longest ending of a word applies, and words matching
+// none take an s.
+var defaultSuffixes = map[string]string{
+	"y":  "ies",
+	"ay": "ays",
+	"ey": "eys",
+	"iy": "iys",
+	"oy": "oys",
+	"uy": "uys",
+	"s":  "ses",
+	"x":  "xes",
+	"z":  "zes",
+	"ch": "ches",
+	"sh": "shes",
+}
+
+// InferOptions tunes the inference of relationships from column names
+type InferOptions struct {
+	Sample     int               // distinct values sampled per candidate, DefaultInferSample when zero
+	MinOverlap float64           // share of sampled values that must exist in the referenced table, DefaultInferOverlap when zero
+	Plurals    map[string]string // irregular plurals by singular, on top of the built in ones
+	Suffixes   map[string]string // plural endings by singular ending, as f=ves, on top of the built in ones
+}
+
+// InferredRelation is a relationship proposed from the name of a column and
+// checked against a sample of its values
+type InferredRelation struct {
+	ForeignKey models.ForeignKey `json:"foreign_key"`
+	Sampled    int               `json:"sampled"` // distinct non-NULL values sampled from the column
+	Matched    int               `json:"matched"` // sampled values found in the referenced column
+	Valid      bool              `json:"valid"`   // the overlap reaches the minimum
+}
+
+// Overlap returns the share of sampled values found in the referenced column
+func (r InferredRelation) Overlap() float64 {
+	if r.Sampled == 0 {
+		return 0
+	}
+	return float64(r.Matched) / float64(r.Sampled)
+}
+
+// Evidence describes the outcome of the sample check
+func (r InferredRelation) Evidence() string {
+	if r.Sampled == 0 {
+		return "no values to sample"
+	}
+	return fmt.Sprintf("%.0f%% of %d sampled values found", 100*r.Overlap(), r.Sampled)
+}
+
+func (r InferredRelation) String() string {
+	return fmt.Sprintf("%s (%s)", r.ForeignKey.Declaration(), r.Evidence())
+}
+
+// Infer proposes relationships for the columns no foreign key covers. A
+// column named company_id points at the primary key of companies, or of
+// company; parent_id and parent_*_id point at their own table. Longer names
+// such as billing_address_id fall back to their trailing words. Each
+// candidate is checked by looking up a sample of the column values.
+func (e *Engine) Infer(ctx context.Context, opts InferOptions) ([]InferredRelation, error) {
+	if opts.Sample <= 0 {
+		opts.Sample = DefaultInferSample
+	}
+	if opts.MinOverlap <= 0 {
+		opts.MinOverlap = DefaultInferOverlap
+	}
+	rules := pluralRules{irregular: maps.Clone(defaultPlurals), suffixes: maps.Clone(defaultSuffixes)}
+	for singular, plural := range opts.Plurals {
+		rules.irregular[strings.ToLower(singular)] = strings.ToLower(plural)
+	}
+	for singular, plural := range opts.Suffixes {
+		rules.suffixes[strings.ToLower(singular)] = strings.ToLower(plural)
+	}
+
+	var inferred []InferredRelation
+	for i := range e.schema.Tables {
+		t := &e.schema.Tables[i]
+		covered := make(map[string]bool)
+		for _, fk := range t.ForeignKeys {
+			for _, c := range fk.Columns {
+				covered[c] = true
+			}
+		}
+
+		for _, col := range t.Columns {
+			if covered[col.Name] {
+				continue
+			}
+			parent := e.inferParent(t, col.Name, rules)
+			if parent == nil || (parent.Name == t.Name && parent.PrimaryKey[0] == col.Name) {
+				continue
+			}
+
+			fk := models.ForeignKey{
+				Table:      t.Name,
+				Columns:    []string{col.Name},
+				RefTable:   parent.Name,
+				RefColumns: parent.PrimaryKey,
+				Virtual:    true,
+			}
+			r, err := e.checkRelation(ctx, fk, opts.Sample)
+			if err != nil {
+				return nil, fmt.Errorf("checking %s: %w", fk.Declaration(), err)
+			}
+			r.Valid = r.Sampled > 0 && r.Overlap() >= opts.MinOverlap
+			inferred = append(inferred, r)
+		}
+	}
+	return inferred, nil
+}
+
+// inferParent returns the table a column points at by its name, or nil.
+// Only tables with a single column primary key can be referenced.
+func (e *Engine) inferParent(t *models.Table, column string, rules pluralRules) *models.Table {
+	stem, ok := strings.CutSuffix(strings.ToLower(column), "_id")
+	if !ok || stem == "" {
+		return nil
+	}
+
+	var parent *models.Table
+	if stem == "parent" || strings.HasPrefix(stem, "parent_") {
+		parent = t
+	} else {
+		words := strings.Split(stem, "_")
+		for i := 0; i < len(words) && parent == nil; i++ {
+			name := strings.Join(words[i:], "_")
+			parent = e.tableNamed(rules.pluralize(name))
+			if parent == nil {
+				parent = e.tableNamed(name)
+			}
+		}
+	}
+	if parent == nil || len(parent.PrimaryKey) != 1 {
+		return nil
+	}
+	return parent
+}
+
+// tableNamed returns the table of the schema with a name, ignoring case
+func (e *Engine) tableNamed(name string) *models.Table {
+	for i := range e.schema.Tables {
+		if strings.EqualFold(e.schema.Tables[i].Name, name) {
+			return &e.schema.Tables[i]
+		}
+	}
+	return nil
+}
+
+// checkRelation samples distinct values of the foreign key column and counts
+// the ones found in the referenced table
+func (e *Engine) checkRelation(ctx context.Context, fk models.ForeignKey, sample int) (InferredRelation, error) {
+	r := InferredRelation{ForeignKey: fk}
+	column := e.dialect.QuoteIdentifier(fk.Columns[0])
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s WHERE %s IS NOT NULL LIMIT %d",
+		column, e.dialect.QuoteIdentifier(fk.Table), column, sample)
+	values, err := e.scanKeys(ctx, query, 1, nil)
+	if err != nil {
+		return r, err
+	}
+	found, err := e.lookup(ctx, fk.RefTable, fk.RefColumns, fk.RefColumns, values, condition{})
+	if err != nil {
+		return r, err
+	}
+	r.Sampled, r.Matched = len(values), len(found)
+	return r, nil
+}
+
+// pluralRules are the plurals of irregular words and the plural endings of
+// regular ones, by singular
+type pluralRules struct {
+	irregular map[string]string
+	suffixes  map[string]string
+}
+
+// pluralize returns the plural of the last word of an underscore separated name
+func (r pluralRules) pluralize(name string) string {
+	i := strings.LastIndex(name, "_") + 1
+	prefix, word := name[:i], name[i:]
+	if plural, ok := r.irregular[word]; ok {
+		return prefix + plural
+	}
+	ending, plural := "", "s"
+	for singular, p := range r.suffixes {
+		if len(singular) > len(ending) && len(singular) < len(word) && strings.HasSuffix(word, singular) {
+			ending, plural = singular, p
+		}
+	}
+	return prefix + word[:len(word)-len(ending)] + plural
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// withoutForeignKeys returns the fixture with its foreign keys removed
+func withoutForeignKeys(t *testing.T) string {
+	t.Helper()
+	script, err := os.ReadFile(filepath.Join("testdata", "fixture.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := regexp.MustCompile(`(?m)^\s*FOREIGN KEY .*\n`).ReplaceAllString(string(script), "")
+	// The column before the keys may end in a comment
+	return regexp.MustCompile(`,(\s*(?:--.*)?\s*)\);`).ReplaceAllString(s, "$1);")
+}
+
+// inferred renders the relations as their declarations, with invalid ones marked
+func inferred(relations []InferredRelation) []string {
+	var rendered []string
+	for _, r := range relations {
+		s := r.ForeignKey.Declaration()
+		if !r.Valid {
+			s += " (invalid)"
+		}
+		rendered = append(rendered, s)
+	}
+	return rendered
+}
+
+func TestInfer(t *testing.T) {
+	sess, _ := openScript(t, withoutForeignKeys(t))
+	relations, err := sess.Engine.Infer(context.Background(), InferOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Columns such as manager_id, account_manager_id, category_id and
+	// user_id name no table and are left to the user
+	want := []string{
+		"companies.parent_company_id -> companies.id",
+		"contracts.client_id -> clients.id",
+		"contracts.company_id -> companies.id",
+		"contracts.project_id -> projects.id",
+		"departments.company_id -> companies.id",
+		"departments.location_id -> locations.id",
+		"departments.parent_department_id -> departments.id",
+		"employee_positions.employee_id -> employees.id",
+		"employee_positions.position_id -> positions.id",
+		"employees.company_id -> companies.id",
+		"employees.department_id -> departments.id",
+		"employees.location_id -> locations.id",
+		"expense_categories.parent_category_id -> expense_categories.id",
+		"expenses.company_id -> companies.id",
+		"expenses.employee_id -> employees.id",
+		"expenses.project_id -> projects.id",
+		"expenses.department_id -> departments.id",
+		"locations.company_id -> companies.id",
+		"project_assignments.project_id -> projects.id",
+		"project_assignments.employee_id -> employees.id",
+		"projects.company_id -> companies.id",
+		"projects.department_id -> departments.id",
+		"projects.parent_project_id -> projects.id",
+	}
+	if got := inferred(relations); !slices.Equal(got, want) {
+		t.Errorf("inferred %q, want %q", got, want)
+	}
+	for _, r := range relations {
+		if !r.ForeignKey.Virtual || r.Sampled == 0 || r.Matched != r.Sampled {
+			t.Errorf("%s: virtual %t, %d of %d sampled values found", r.ForeignKey.Declaration(), r.ForeignKey.Virtual, r.Matched, r.Sampled)
+		}
+	}
+}
+
+func TestInferPlurals(t *testing.T) {
+	sess, _ := openScript(t, `
+CREATE TABLE people (id INTEGER PRIMARY KEY);
+CREATE TABLE shelves (id INTEGER PRIMARY KEY);
+CREATE TABLE analyses (id INTEGER PRIMARY KEY);
+CREATE TABLE data (id INTEGER PRIMARY KEY);
+CREATE TABLE books (id INTEGER PRIMARY KEY, person_id INT, shelf_id INT, analysis_id INT, datum_id INT);
+INSERT INTO people VALUES (1), (2);
+INSERT INTO shelves VALUES (1);
+INSERT INTO analyses VALUES (1);
+INSERT INTO data VALUES (1);
+INSERT INTO books VALUES (1, 1, 1, 1, 1), (2, 2, 1, NULL, NULL), (3, 3, NULL, NULL, NULL);
+`)
+	tests := []struct {
+		name string
+		opts InferOptions
+		want []string
+	}{
+		{
+			name: "built in rules",
+			want: []string{"books.person_id -> people.id (invalid)"},
+		},
+		{
+			name: "plural endings",
+			opts: InferOptions{Suffixes: map[string]string{"f": "ves", "IS": "es"}},
+			want: []string{
+				"books.person_id -> people.id (invalid)",
+				"books.shelf_id -> shelves.id",
+				"books.analysis_id -> analyses.id",
+			},
+		},
+		{
+			name: "irregular plurals",
+			opts: InferOptions{Plurals: map[string]string{"Datum": "Data"}, MinOverlap: 0.5},
+			want: []string{
+				"books.person_id -> people.id",
+				"books.datum_id -> data.id",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relations, err := sess.Engine.Infer(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := inferred(relations); !slices.Equal(got, tt.want) {
+				t.Errorf("inferred %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	rules := pluralRules{irregular: defaultPlurals, suffixes: defaultSuffixes}
+	custom := pluralRules{
+		irregular: map[string]string{"person": "persons"},
+		suffixes:  map[string]string{"y": "ys", "f": "ves", "ff": "ffs"},
+	}
+	tests := []struct {
+		rules pluralRules
+		name  string
+		want  string
+	}{
+		{rules, "company", "companies"},
+		{rules, "expense_category", "expense_categories"},
+		{rules, "survey", "surveys"},
+		{rules, "toy", "toys"},
+		{rules, "status", "statuses"},
+		{rules, "box", "boxes"},
+		{rules, "branch", "branches"},
+		{rules, "wish", "wishes"},
+		{rules, "user", "users"},
+		{rules, "sales_person", "sales_people"},
+		{rules, "child", "children"},
+		{rules, "y", "ys"},
+		{custom, "person", "persons"},
+		{custom, "company", "companys"},
+		{custom, "shelf", "shelves"},
+		{custom, "staff", "staffs"},
+		{custom, "box", "boxs"},
+	}
+	for _, tt := range tests {
+		if got := tt.rules.pluralize(tt.name); got != tt.want {
+			t.Errorf("pluralize(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
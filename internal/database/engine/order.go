@@ -0,0 +1,248 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// loadOrder is the order in which the tables of a dump are loaded so that
+// every referenced row exists before the rows referencing it
+type loadOrder struct {
+	Tables []models.Table
+	// Deferred holds, by table, the foreign keys closing a cycle. Their
+	// columns are written as NULL and restored once every table is loaded.
+	Deferred map[string][]models.ForeignKey
+	Warnings []string
+}
+
+// orderTables sorts tables topologically by their foreign keys: of the tables
+// whose referenced tables are loaded, the one given first comes next, so the
+// given order is kept where the keys allow it. Tables referencing each other, directly
+// or through other tables, form a strongly connected component; within one,
+// tables are ordered by the NOT NULL foreign keys and the nullable keys
+// pointing at the same or a later table are deferred. Virtual foreign keys
+// order the tables like declared ones, but as the target does not enforce
+// them, a cycle may break them without deferring their columns.
+func orderTables(tables []models.Table) loadOrder {
+	index := make(map[string]int, len(tables))
+	for i, t := range tables {
+		index[t.Name] = i
+	}
+	refs := make([][]int, len(tables))
+	for i, t := range tables {
+		for _, fk := range t.ForeignKeys {
+			if j, ok := index[fk.RefTable]; ok {
+				refs[i] = append(refs[i], j)
+			}
+		}
+	}
+
+	// Kahn's algorithm over the components, taking the one given first
+	comps := components(refs)
+	of := make([]int, len(tables))
+	first := make([]int, len(comps))
+	for c, component := range comps {
+		first[c] = slices.Min(component)
+		for _, i := range component {
+			of[i] = c
+		}
+	}
+	pending := make([]int, len(comps))
+	users := make([][]int, len(comps))
+	for i := range tables {
+		for _, j := range refs[i] {
+			if of[i] != of[j] {
+				pending[of[i]]++
+				users[of[j]] = append(users[of[j]], of[i])
+			}
+		}
+	}
+
+	order := loadOrder{Deferred: make(map[string][]models.ForeignKey)}
+	done := make([]bool, len(comps))
+	for range comps {
+		next := -1
+		for c := range comps {
+			if !done[c] && pending[c] == 0 && (next < 0 || first[c] < first[next]) {
+				next = c
+			}
+		}
+		done[next] = true
+		for _, c := range users[next] {
+			pending[c]--
+		}
+
+		component := comps[next]
+		if len(component) == 1 && !slices.Contains(refs[component[0]], component[0]) {
+			order.Tables = append(order.Tables, tables[component[0]])
+			continue
+		}
+		order.breakCycle(tables, component, index)
+	}
+	return order
+}
+
+// breakCycle orders the tables of a strongly connected component and defers
+// the foreign keys pointing at a table that is not loaded yet
+func (o *loadOrder) breakCycle(tables []models.Table, component []int, index map[string]int) {
+	slices.Sort(component)
+	member := make(map[int]bool, len(component))
+	for _, i := range component {
+		member[i] = true
+	}
+
+	// Kahn's algorithm over the keys that cannot be deferred, preferring the
+	// tables whose virtual keys point at loaded tables
+	pending := make(map[int]int, len(component))
+	virtual := make(map[int]int, len(component))
+	for _, i := range component {
+		for _, fk := range tables[i].ForeignKeys {
+			j, ok := index[fk.RefTable]
+			switch {
+			case !ok || j == i || !member[j]:
+			case fk.Virtual:
+				virtual[i]++
+			case !nullable(&tables[i], fk):
+				pending[i]++
+			}
+		}
+	}
+	var sorted []int
+	done := make(map[int]bool, len(component))
+	for len(sorted) < len(component) {
+		next := -1
+		for _, i := range component {
+			if !done[i] && pending[i] == 0 && (next < 0 || virtual[next] > 0 && virtual[i] == 0) {
+				next = i
+			}
+		}
+		if next < 0 {
+			var names []string
+			for _, i := range component {
+				if !done[i] {
+					names = append(names, tables[i].Name)
+				}
+			}
+			o.warnf("the cycle between %s cannot be broken, as its foreign key columns are NOT NULL: the data loads only with foreign key checks disabled",
+				strings.Join(names, ", "))
+			for _, i := range component {
+				if !done[i] {
+					sorted = append(sorted, i)
+					done[i] = true
+				}
+			}
+			break
+		}
+		sorted = append(sorted, next)
+		done[next] = true
+		for _, i := range component {
+			for _, fk := range tables[i].ForeignKeys {
+				if fk.RefTable != tables[next].Name || i == next {
+					continue
+				}
+				if fk.Virtual {
+					virtual[i]--
+				} else if !nullable(&tables[i], fk) {
+					pending[i]--
+				}
+			}
+		}
+	}
+
+	position := make(map[string]int, len(sorted))
+	for p, i := range sorted {
+		position[tables[i].Name] = p
+	}
+	for _, i := range sorted {
+		t := &tables[i]
+		o.Tables = append(o.Tables, *t)
+		for _, fk := range t.ForeignKeys {
+			p, ok := position[fk.RefTable]
+			if !ok || fk.Virtual || p < position[t.Name] {
+				continue
+			}
+			switch {
+			case !nullable(t, fk):
+				if fk.RefTable == t.Name {
+					o.warnf("%s(%s) references its own table through NOT NULL columns: rows referencing later rows load only with foreign key checks disabled",
+						t.Name, strings.Join(fk.Columns, ", "))
+				}
+			case len(t.PrimaryKey) == 0:
+				o.warnf("%s(%s) closes a cycle but %s has no primary key to restore it by: the data loads only with foreign key checks disabled",
+					t.Name, strings.Join(fk.Columns, ", "), t.Name)
+			default:
+				o.Deferred[t.Name] = append(o.Deferred[t.Name], fk)
+			}
+		}
+	}
+}
+
+func (o *loadOrder) warnf(format string, args ...any) {
+	o.Warnings = append(o.Warnings, fmt.Sprintf(format, args...))
+}
+
+// nullable reports whether every column of a foreign key accepts NULL
+func nullable(t *models.Table, fk models.ForeignKey) bool {
+	for _, c := range fk.Columns {
+		if col := t.Column(c); col == nil || !col.Nullable {
+			return false
+		}
+	}
+	return true
+}
+
+// components returns the strongly connected components of a graph given as
+// adjacency lists, using Tarjan's algorithm
+func components(adj [][]int) [][]int {
+	var (
+		counter int
+		stack   []int
+		result  [][]int
+	)
+	index := make([]int, len(adj))
+	low := make([]int, len(adj))
+	onStack := make([]bool, len(adj))
+	for i := range index {
+		index[i] = -1
+	}
+
+	var visit func(v int)
+	visit = func(v int) {
+		index[v], low[v] = counter, counter
+		counter++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range adj[v] {
+			if index[w] < 0 {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+
+		if low[v] == index[v] {
+			var component []int
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			result = append(result, component)
+		}
+	}
+	for v := range adj {
+		if index[v] < 0 {
+			visit(v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,349 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// ref is a foreign key of a table built by orderTable
+type ref struct {
+	column, table    string
+	notNull, virtual bool
+}
+
+// orderTable returns a table keyed by id whose columns hold the given references
+func orderTable(name string, refs ...ref) models.Table {
+	t := models.Table{
+		Name:       name,
+		Columns:    []models.Column{{Name: "id", Type: "INTEGER", DataType: "integer"}},
+		PrimaryKey: []string{"id"},
+	}
+	for _, r := range refs {
+		t.Columns = append(t.Columns, models.Column{Name: r.column, Type: "INTEGER", DataType: "integer", Nullable: !r.notNull})
+		t.ForeignKeys = append(t.ForeignKeys, models.ForeignKey{
+			Table: name, Columns: []string{r.column}, RefTable: r.table, RefColumns: []string{"id"}, Virtual: r.virtual,
+		})
+	}
+	return t
+}
+
+// orderOf returns the table names of a load order and its deferred columns by table
+func orderOf(o loadOrder) ([]string, map[string][]string) {
+	var names []string
+	for _, t := range o.Tables {
+		names = append(names, t.Name)
+	}
+	deferred := make(map[string][]string)
+	for table, fks := range o.Deferred {
+		for _, fk := range fks {
+			deferred[table] = append(deferred[table], fk.Columns...)
+		}
+	}
+	return names, deferred
+}
+
+func TestOrderTables(t *testing.T) {
+	noKey := orderTable("log", ref{column: "next_id", table: "log"})
+	noKey.PrimaryKey = nil
+	tests := []struct {
+		name     string
+		tables   []models.Table
+		order    []string
+		deferred map[string][]string
+		warnings int
+	}{
+		{
+			name: "sorted tables unchanged",
+			tables: []models.Table{
+				orderTable("customers"),
+				orderTable("audit"),
+				orderTable("orders", ref{column: "customer_id", table: "customers"}),
+				orderTable("lines", ref{column: "order_id", table: "orders", notNull: true}),
+			},
+			order:    []string{"customers", "audit", "orders", "lines"},
+			deferred: map[string][]string{},
+		},
+		{
+			name: "first given table whose references are loaded",
+			tables: []models.Table{
+				orderTable("lines", ref{column: "order_id", table: "orders", notNull: true}),
+				orderTable("audit"),
+				orderTable("orders", ref{column: "customer_id", table: "customers"}),
+				orderTable("customers"),
+				orderTable("notes"),
+			},
+			order:    []string{"audit", "customers", "orders", "lines", "notes"},
+			deferred: map[string][]string{},
+		},
+		{
+			name:     "self reference",
+			tables:   []models.Table{orderTable("employees", ref{column: "manager_id", table: "employees"})},
+			order:    []string{"employees"},
+			deferred: map[string][]string{"employees": {"manager_id"}},
+		},
+		{
+			name:     "NOT NULL self reference",
+			tables:   []models.Table{orderTable("nodes", ref{column: "parent_id", table: "nodes", notNull: true})},
+			order:    []string{"nodes"},
+			deferred: map[string][]string{},
+			warnings: 1,
+		},
+		{
+			name: "two tables with nullable keys",
+			tables: []models.Table{
+				orderTable("teams", ref{column: "lead_id", table: "members"}),
+				orderTable("members", ref{column: "team_id", table: "teams"}),
+			},
+			order:    []string{"teams", "members"},
+			deferred: map[string][]string{"teams": {"lead_id"}},
+		},
+		{
+			name: "two tables ordered by a NOT NULL key",
+			tables: []models.Table{
+				orderTable("members", ref{column: "team_id", table: "teams", notNull: true}),
+				orderTable("teams", ref{column: "lead_id", table: "members"}),
+			},
+			order:    []string{"teams", "members"},
+			deferred: map[string][]string{"teams": {"lead_id"}},
+		},
+		{
+			name: "NOT NULL cycle",
+			tables: []models.Table{
+				orderTable("a", ref{column: "b_id", table: "b", notNull: true}),
+				orderTable("b", ref{column: "a_id", table: "a", notNull: true}),
+			},
+			order:    []string{"a", "b"},
+			deferred: map[string][]string{},
+			warnings: 1,
+		},
+		{
+			name:     "no primary key",
+			tables:   []models.Table{noKey},
+			order:    []string{"log"},
+			deferred: map[string][]string{},
+			warnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := orderTables(tt.tables)
+			order, deferred := orderOf(o)
+			if !slices.Equal(order, tt.order) {
+				t.Errorf("order = %v, want %v", order, tt.order)
+			}
+			if !maps.EqualFunc(deferred, tt.deferred, slices.Equal) {
+				t.Errorf("deferred = %v, want %v", deferred, tt.deferred)
+			}
+			if len(o.Warnings) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", o.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestCycleBreaker(t *testing.T) {
+	a := adapters.NewSQLite(models.DatabaseConfig{})
+	employees := orderTable("employees", ref{column: "manager_id", table: "employees"})
+	teams := orderTable("teams", ref{column: "lead_id", table: "members"})
+	tests := []struct {
+		name    string
+		table   *models.Table
+		rows    [][]any
+		written [][]any
+		updates []string
+	}{
+		{
+			name:    "self reference",
+			table:   &employees,
+			rows:    [][]any{{int64(1), int64(3)}, {int64(2), int64(1)}, {int64(3), nil}},
+			written: [][]any{{int64(1), nil}, {int64(2), int64(1)}, {int64(3), nil}},
+			updates: []string{`UPDATE "employees" SET "manager_id" = 3 WHERE "id" = 1`},
+		},
+		{
+			name:    "other table",
+			table:   &teams,
+			rows:    [][]any{{int64(1), int64(2)}, {int64(2), nil}},
+			written: [][]any{{int64(1), nil}, {int64(2), nil}},
+			updates: []string{`UPDATE "teams" SET "lead_id" = 2 WHERE "id" = 1`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCycleBreaker(tt.table, tt.table.ForeignKeys)
+			var updates []string
+			for _, row := range tt.rows {
+				updates = append(updates, b.row(a, row)...)
+			}
+			if !slices.EqualFunc(tt.rows, tt.written, slices.Equal) {
+				t.Errorf("written rows = %v, want %v", tt.rows, tt.written)
+			}
+			if !slices.Equal(updates, tt.updates) {
+				t.Errorf("updates = %q, want %q", updates, tt.updates)
+			}
+		})
+	}
+
+	if newCycleBreaker(&employees, nil) != nil {
+		t.Error("expected no breaker for a table without deferred keys")
+	}
+}
+
+const cycleSchema = `
+CREATE TABLE employees (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    manager_id INTEGER NULL REFERENCES employees(id)
+);
+CREATE TABLE teams (
+    id INTEGER PRIMARY KEY,
+    lead_id INTEGER NULL REFERENCES members(id)
+);
+CREATE TABLE members (
+    id INTEGER PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id)
+);
+INSERT INTO employees (id, name, manager_id) VALUES (1, 'Ada', 3), (2, 'Brian', 1), (3, 'Carla', NULL);
+INSERT INTO teams (id, lead_id) VALUES (1, 2), (2, NULL);
+INSERT INTO members (id, team_id) VALUES (1, 1), (2, 1), (3, 2);
+`
+
+func TestDumpBreaksCycles(t *testing.T) {
+	_, source := openScript(t, cycleSchema)
+	script, res := dumpScript(t, models.DumpConfig{SourceConfig: source, Mode: models.StructureAndData})
+
+	deferred := make(map[string]int64)
+	for _, tr := range res.Tables {
+		deferred[tr.Name] = tr.Deferred
+	}
+	if want := map[string]int64{"employees": 1, "teams": 1, "members": 0}; !maps.Equal(deferred, want) {
+		t.Errorf("deferred = %v, want %v", deferred, want)
+	}
+
+	// Load the dump with the foreign keys enforced on every statement
+	db, err := sql.Open("sqlite3", t.TempDir()+"/cycles.db?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, strings.ReplaceAll(script, "PRAGMA foreign_keys = OFF", "")); err != nil {
+		t.Fatalf("loading the dump: %v", err)
+	}
+
+	for query, want := range map[string]string{
+		"SELECT group_concat(id || ':' || ifnull(manager_id, '-'), ',') FROM (SELECT * FROM employees ORDER BY id)": "1:3,2:1,3:-",
+		"SELECT group_concat(id || ':' || ifnull(lead_id, '-'), ',') FROM (SELECT * FROM teams ORDER BY id)":        "1:2,2:-",
+	} {
+		var got string
+		if err := db.QueryRowContext(ctx, query).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s = %s, want %s", query, got, want)
+		}
+	}
+}
+
+func TestOrderTablesVirtualKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		tables   []models.Table
+		order    []string
+		deferred map[string][]string
+	}{
+		{
+			name: "referenced table first",
+			tables: []models.Table{
+				orderTable("orders", ref{column: "customer_ref", table: "customers", notNull: true, virtual: true}),
+				orderTable("customers"),
+			},
+			order:    []string{"customers", "orders"},
+			deferred: map[string][]string{},
+		},
+		{
+			name: "kept in a cycle without deferring it",
+			tables: []models.Table{
+				orderTable("b", ref{column: "a_ref", table: "a", virtual: true}),
+				orderTable("a", ref{column: "b_id", table: "b"}),
+			},
+			order:    []string{"a", "b"},
+			deferred: map[string][]string{"a": {"b_id"}},
+		},
+		{
+			name: "broken by a NOT NULL key",
+			tables: []models.Table{
+				orderTable("a", ref{column: "b_ref", table: "b", notNull: true, virtual: true}),
+				orderTable("b", ref{column: "a_id", table: "a", notNull: true}),
+			},
+			order:    []string{"a", "b"},
+			deferred: map[string][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := orderTables(tt.tables)
+			order, deferred := orderOf(o)
+			if !slices.Equal(order, tt.order) {
+				t.Errorf("order = %v, want %v", order, tt.order)
+			}
+			if !maps.EqualFunc(deferred, tt.deferred, slices.Equal) {
+				t.Errorf("deferred = %v, want %v", deferred, tt.deferred)
+			}
+			if len(o.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", o.Warnings)
+			}
+		})
+	}
+}
+
+func TestSpool(t *testing.T) {
+	var s spool
+	defer s.close()
+	stmts := []string{
+		`UPDATE "notes" SET "body" = 'line one` + "\n" + `line two' WHERE "id" = 1`,
+		`UPDATE "notes" SET "body" = '' WHERE "id" = 2`,
+		`UPDATE "notes" SET "body" = 'ünïcødé ` + strings.Repeat("x", 300) + `' WHERE "id" = 3`,
+	}
+	if err := s.add(); err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range stmts {
+		if err := s.add(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.len() != int64(len(stmts)) {
+		t.Errorf("len = %d, want %d", s.len(), len(stmts))
+	}
+
+	var out bytes.Buffer
+	sink, err := newFileSink("", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.replay(context.Background(), sink); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(stmts, ";\n") + ";\n"; out.String() != want {
+		t.Errorf("replayed %q, want %q", out.String(), want)
+	}
+
+	name := s.file.Name()
+	s.close()
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("spool file %s left behind", name)
+	}
+}
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+)
+
+// sink receives the statements produced by a dump
+type sink interface {
+	// exec writes or executes a single statement
+	exec(ctx context.Context, stmt string) error
+	// comment adds a human readable note, ignored by database sinks
+	comment(text string) error
+	// written returns the number of bytes produced so far
+	written() int64
+	close() error
+}
+
+// fileSink writes statements to a SQL script
+type fileSink struct {
+	w      *bufio.Writer
+	closer io.Closer
+	n      int64
+}
+
+// newFileSink creates the script at path, or writes to w when it is not nil
+func newFileSink(path string, w io.Writer) (*fileSink, error) {
+	if w != nil {
+		return &fileSink{w: bufio.NewWriter(w)}, nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating output file: %w", err)
+	}
+	return &fileSink{w: bufio.NewWriter(f), closer: f}, nil
+}
+
+func (s *fileSink) exec(_ context.Context, stmt string) error {
+	return s.write(stmt + ";\n")
+}
+
+func (s *fileSink) comment(text string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString("-- " + line + "\n")
+	}
+	return s.write(b.String())
+}
+
+func (s *fileSink) write(text string) error {
+	n, err := s.w.WriteString(text)
+	s.n += int64(n)
+	return err
+}
+
+func (s *fileSink) written() int64 {
+	return s.n
+}
+
+func (s *fileSink) close() error {
+	err := s.w.Flush()
+	if s.closer != nil {
+		if cerr := s.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// databaseSink executes statements on a single connection of the target
+// database, so session settings such as disabled FK checks apply to all of them
+type databaseSink struct {
+	target adapters.Adapter
+	conn   *sql.Conn
+	n      int64
+}
+
+// newDatabaseSink takes ownership of a connected target adapter
+func newDatabaseSink(ctx context.Context, target adapters.Adapter) (*databaseSink, error) {
+	conn, err := target.DB().Conn(ctx)
+	if err != nil {
+		target.Close()
+		return nil, fmt.Errorf("acquiring target connection: %w", err)
+	}
+	return &databaseSink{target: target, conn: conn}, nil
+}
+
+func (s *databaseSink) exec(ctx context.Context, stmt string) error {
+	if _, err := s.conn.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("executing %s: %w", summarize(stmt), err)
+	}
+	s.n += int64(len(stmt))
+	return nil
+}
+
+func (s *databaseSink) comment(string) error {
+	return nil
+}
+
+func (s *databaseSink) written() int64 {
+	return s.n
+}
+
+func (s *databaseSink) close() error {
+	err := s.conn.Close()
+	if cerr := s.target.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// summarize shortens a statement for error messages
+func summarize(stmt string) string {
+	stmt = strings.Join(strings.Fields(stmt), " ")
+	if len(stmt) > 80 {
+		return stmt[:77] + "..."
+	}
+	return stmt
+}
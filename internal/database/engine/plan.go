@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// TablePlan is the planned outcome of a dump for one table
+type TablePlan struct {
+	Name     string `json:"name"`
+	Filter   Filter `json:"filter"`
+	Rows     int64  `json:"rows"`
+	Excluded int64  `json:"excluded,omitempty"` // rows reached by the traversal and left out
+	Depth    int    `json:"depth"`              // largest distance from a seed of a reached row, -1 when none
+	Bytes    int64  `json:"bytes"`              // estimated size of the statements written for the table
+}
+
+// Plan describes what a dump configuration would export, without writing anything
+type Plan struct {
+	Mode     models.DumpMode `json:"-"`
+	Tables   []TablePlan     `json:"tables"`
+	Rows     int64           `json:"rows"`
+	Excluded int64           `json:"excluded,omitempty"`
+	Bytes    int64           `json:"bytes"`
+	Edges    []EdgeUse       `json:"edges,omitempty"`
+	Cuts     []Cut           `json:"cuts,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Subset   *Subset         `json:"-"` // rows reached by the traversal, nil in the modes without one
+}
+
+// Plan computes the rows a dump configuration selects from every table. Only
+// key columns are read, and sizes are estimated from the column types.
+func (s *Session) Plan(ctx context.Context, cfg models.DumpConfig) (*Plan, error) {
+	sel, err := s.Engine.Select(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Mode: cfg.Mode, Cuts: sel.Cuts, Warnings: sel.Warnings, Subset: sel.Subset}
+	if sel.Subset != nil {
+		plan.Edges = sel.Subset.Edges
+	}
+	for i := range sel.Scope.Tables {
+		t := &sel.Scope.Tables[i]
+		tp := TablePlan{Name: t.Name, Filter: sel.Filter(t.Name), Depth: -1}
+		if sel.Subset != nil {
+			tp.Depth = sel.Subset.Depth(t.Name)
+		}
+		switch tp.Filter {
+		case FilterAll, FilterExcept:
+			total, err := s.RowCount(ctx, t.Name)
+			if err != nil {
+				return nil, err
+			}
+			tp.Rows = total
+			if tp.Filter == FilterExcept {
+				tp.Excluded = int64(sel.Subset.Rows(t.Name).Len())
+				tp.Rows -= tp.Excluded
+			}
+		case FilterOnly:
+			tp.Rows = int64(sel.Subset.Rows(t.Name).Len())
+		}
+		tp.Bytes = s.estimateSize(t, tp.Rows)
+
+		plan.Tables = append(plan.Tables, tp)
+		plan.Rows += tp.Rows
+		plan.Excluded += tp.Excluded
+		plan.Bytes += tp.Bytes
+	}
+	return plan, nil
+}
+
+// estimateSize returns the expected size of the statements creating a table
+// and inserting rows into it, rendered for the source database
+func (s *Session) estimateSize(t *models.Table, rows int64) int64 {
+	var size int64
+	for _, stmt := range s.Source.CreateTable(t, s.Source.Type()) {
+		size += int64(len(stmt)) + 2
+	}
+	if rows == 0 {
+		return size
+	}
+
+	// Every row is rendered as "\n  (v, v, ...)," and every batch of rows
+	// shares the INSERT INTO header
+	width := int64(5)
+	for i, c := range t.Columns {
+		if i > 0 {
+			width += 2
+		}
+		width += estimatedWidth(c)
+	}
+	batches := (rows + DefaultInsertBatch - 1) / DefaultInsertBatch
+	header := int64(len(s.Source.Insert(t.Name, t.ColumnNames(), nil))) + 2
+	return size + rows*width + batches*header
+}
+
+// estimatedWidth returns the expected length of a value of a column rendered
+// as a SQL literal, judged from its type alone
+func estimatedWidth(c models.Column) int64 {
+	switch dt := c.DataType; {
+	case dt == "bool" || dt == "boolean" || dt == "bit":
+		return 1
+	case dt == "decimal" || dt == "numeric" || dt == "float" || dt == "double" || dt == "real" ||
+		dt == "double precision" || dt == "float4" || dt == "float8":
+		return 10
+	case adapters.IsNumericType(dt):
+		return 6
+	case dt == "date":
+		return 12
+	case dt == "time":
+		return 10
+	case strings.HasPrefix(dt, "datetime") || strings.HasPrefix(dt, "timestamp"):
+		return 21
+	case dt == "uuid":
+		return 38
+	case dt == "char" || dt == "character" || dt == "nchar":
+		return 2 + min(declaredLength(c.Type, 1), 255)
+	case strings.Contains(dt, "char"):
+		return 2 + min(declaredLength(c.Type, 255)/4, 64)
+	case strings.Contains(dt, "text") || strings.Contains(dt, "json") || strings.Contains(dt, "blob") ||
+		strings.Contains(dt, "binary") || dt == "bytea" || dt == "clob" || dt == "xml":
+		return 66
+	default:
+		return 16
+	}
+}
+
+// declaredLength returns the length declared in a type such as varchar(255),
+// or def when the type declares none
+func declaredLength(declared string, def int64) int64 {
+	open := strings.IndexByte(declared, '(')
+	end := strings.IndexAny(declared, ",)")
+	if open < 0 || end < open {
+		return def
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(declared[open+1:end]), 10, 64)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
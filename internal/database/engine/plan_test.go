@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+func TestPlan(t *testing.T) {
+	sess, source := openFixture(t)
+	employee := []models.Seed{{Table: "employees", Keys: []string{"3"}}}
+	tests := []struct {
+		name     string
+		cfg      models.DumpConfig
+		rows     int64
+		excluded int64
+		edges    int
+		// Tables as filter rows/excluded@depth, for the tables whose rows are filtered
+		tables map[string]string
+	}{
+		{
+			name: "structure only",
+			cfg:  models.DumpConfig{Mode: models.StructureOnly},
+		},
+		{
+			name: "every row",
+			cfg:  models.DumpConfig{Mode: models.StructureAndData},
+			rows: 89,
+		},
+		{
+			name:  "including",
+			cfg:   models.DumpConfig{Mode: models.StructureAndDataIncludingOnly, Seeds: employee},
+			rows:  15,
+			edges: 13,
+			tables: map[string]string{
+				"companies":           "only 1/0@1",
+				"departments":         "only 2/0@2",
+				"employee_positions":  "only 1/0@1",
+				"employees":           "only 3/0@2",
+				"expense_categories":  "only 2/0@3",
+				"expenses":            "only 1/0@1",
+				"locations":           "only 1/0@1",
+				"positions":           "only 1/0@2",
+				"project_assignments": "only 1/0@1",
+				"projects":            "only 2/0@3",
+			},
+		},
+		{
+			name:     "excluding",
+			cfg:      models.DumpConfig{Mode: models.StructureAndDataExcluding, Seeds: employee},
+			rows:     85,
+			excluded: 4,
+			edges:    3,
+			tables: map[string]string{
+				"employees":           "except 9/1@0",
+				"employee_positions":  "except 9/1@1",
+				"expenses":            "except 4/1@1",
+				"project_assignments": "except 7/1@1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.SourceConfig = source
+			plan, err := sess.Plan(context.Background(), tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plan.Rows != tt.rows || plan.Excluded != tt.excluded || len(plan.Edges) != tt.edges {
+				t.Errorf("%d rows, %d excluded, %d edges, want %d, %d, %d",
+					plan.Rows, plan.Excluded, len(plan.Edges), tt.rows, tt.excluded, tt.edges)
+			}
+			filtered := make(map[string]string)
+			for _, tp := range plan.Tables {
+				if tp.Filter == FilterOnly || tp.Filter == FilterExcept {
+					filtered[tp.Name] = fmt.Sprintf("%s %d/%d@%d", tp.Filter, tp.Rows, tp.Excluded, tp.Depth)
+				}
+			}
+			if !maps.Equal(filtered, tt.tables) && len(filtered)+len(tt.tables) > 0 {
+				t.Errorf("tables = %v, want %v", filtered, tt.tables)
+			}
+
+			// The dump writes what the plan announces, in about the estimated size
+			script, res := dumpScript(t, tt.cfg)
+			if len(res.Tables) != len(plan.Tables) {
+				t.Fatalf("dumped %d tables, planned %d", len(res.Tables), len(plan.Tables))
+			}
+			planned := make(map[string]TablePlan)
+			for _, tp := range plan.Tables {
+				planned[tp.Name] = tp
+			}
+			for _, tr := range res.Tables {
+				if tp := planned[tr.Name]; tp.Filter != tr.Filter || tp.Rows != tr.Rows {
+					t.Errorf("%s: dumped %s %d rows, planned %s %d", tr.Name, tr.Filter, tr.Rows, tp.Filter, tp.Rows)
+				}
+			}
+			if size := int64(len(script)); plan.Bytes < size/2 || plan.Bytes > size*2 {
+				t.Errorf("estimated %d bytes, dumped %d", plan.Bytes, size)
+			}
+		})
+	}
+}
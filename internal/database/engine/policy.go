@@ -0,0 +1,194 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Policy decides which tables a subset traversal enters and which way it
+// follows each relationship
+type Policy struct {
+	Scope    *Scope
+	Warnings []string
+	follow   map[string]models.FollowRule
+	boundary []string
+	lookup   []string
+	maxDepth *int
+	depths   map[string]int
+	maxRows  int
+	budgets  map[string]int
+}
+
+// NewPolicy prepares the traversal rules of a dump configuration within
+// scope. Follow rules are keyed by foreign key name or by table.column, with
+// the columns of a composite key joined by commas. Foreign keys that are not
+// followed are left out of the scope tables, as their referenced rows are not
+// dumped. Lookup tables win over boundary tables. Depth limits are keyed like
+// follow rules and narrow the global max depth.
+func NewPolicy(graph *Graph, scope *Scope, cfg models.DumpConfig) (*Policy, error) {
+	if err := cfg.ValidateLimits(); err != nil {
+		return nil, err
+	}
+	if err := models.ValidateTablePatterns(cfg.BoundaryTables); err != nil {
+		return nil, err
+	}
+	if err := models.ValidateTablePatterns(cfg.LookupTables); err != nil {
+		return nil, err
+	}
+	p := &Policy{
+		Scope:    scope,
+		follow:   cfg.Follow,
+		boundary: cfg.BoundaryTables,
+		lookup:   cfg.LookupTables,
+		maxDepth: cfg.MaxDepth,
+		depths:   cfg.DepthLimits,
+		maxRows:  cfg.MaxRows,
+		budgets:  cfg.TableMaxRows,
+	}
+
+	for _, list := range []struct {
+		name     string
+		patterns []string
+	}{{"boundary", cfg.BoundaryTables}, {"lookup", cfg.LookupTables}} {
+		for _, pattern := range list.patterns {
+			if !matchesAny(graph.schema, pattern) {
+				p.warnf("%s pattern %s matches no table", list.name, pattern)
+			}
+		}
+	}
+
+	for table := range cfg.TableMaxRows {
+		if graph.schema.Table(table) == nil {
+			p.warnf("row budget of %s matches no table", table)
+		}
+	}
+
+	used := make(map[string]bool)
+	for _, edge := range graph.Edges() {
+		for _, name := range edgeNames(edge) {
+			used[name] = true
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Follow)) {
+		if !used[name] {
+			p.warnf("follow rule %s matches no foreign key", name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.DepthLimits)) {
+		if !used[name] {
+			p.warnf("depth limit %s matches no foreign key", name)
+		}
+	}
+
+	if scope == nil {
+		return p, nil
+	}
+	for i, t := range scope.Tables {
+		fks := make([]models.ForeignKey, 0, len(t.ForeignKeys))
+		for _, fk := range t.ForeignKeys {
+			if p.Rule(edgeOf(fk)) != models.FollowNone {
+				fks = append(fks, fk)
+				continue
+			}
+			p.warnf("%s(%s) is not followed: the foreign key is left out and the referenced rows are not dumped",
+				t.Name, fk.Source())
+		}
+		scope.Tables[i].ForeignKeys = fks
+
+		if !p.Lookup(t.Name) {
+			continue
+		}
+		for _, fk := range fks {
+			if !p.Lookup(fk.RefTable) {
+				p.warnf("lookup table %s references %s, whose rows are dumped only when the traversal reaches them", t.Name, fk.RefTable)
+			}
+		}
+	}
+	return p, nil
+}
+
+// Rule returns the follow rule of an edge, looked up by name first
+func (p *Policy) Rule(edge Edge) models.FollowRule {
+	if p == nil {
+		return models.FollowDefault
+	}
+	for _, name := range edgeNames(edge) {
+		if rule, ok := p.follow[name]; ok {
+			return rule
+		}
+	}
+	return models.FollowDefault
+}
+
+// MaxDepth returns the depth from which an edge no longer pulls referencing
+// rows, 0 for none at all, and false when the edge is not limited
+func (p *Policy) MaxDepth(edge Edge) (int, bool) {
+	if p == nil {
+		return 0, false
+	}
+	limit, ok := 0, p.maxDepth != nil
+	if ok {
+		limit = *p.maxDepth
+	}
+	for _, name := range edgeNames(edge) {
+		if d, found := p.depths[name]; found {
+			if !ok || d < limit {
+				limit, ok = d, true
+			}
+			break
+		}
+	}
+	return limit, ok
+}
+
+// Budget returns how many more rows of a table the traversal may pull as
+// referencing rows, given the rows of the table and of the whole subset, and
+// which budget bounds it. It returns -1 when no budget applies.
+func (p *Policy) Budget(table string, tableRows, totalRows int) (int, string) {
+	remaining, reason := -1, ""
+	if p == nil {
+		return remaining, reason
+	}
+	if b, ok := p.budgets[table]; ok {
+		remaining, reason = max(0, b-tableRows), fmt.Sprintf("row budget of %d for %s reached", b, table)
+	}
+	if p.maxRows > 0 {
+		if left := max(0, p.maxRows-totalRows); remaining < 0 || left < remaining {
+			remaining, reason = left, fmt.Sprintf("total row budget of %d reached", p.maxRows)
+		}
+	}
+	return remaining, reason
+}
+
+// Contains reports whether a table may be entered by the traversal
+func (p *Policy) Contains(table string) bool {
+	return p == nil || ((p.Scope == nil || p.Scope.Contains(table)) && !p.Lookup(table))
+}
+
+// Boundary reports whether the rows of a table are dumped when referenced
+// but never expanded to the rows referencing them
+func (p *Policy) Boundary(table string) bool {
+	return p != nil && models.MatchTable(p.boundary, table)
+}
+
+// Lookup reports whether a table is dumped in full and never traversed
+func (p *Policy) Lookup(table string) bool {
+	return p != nil && models.MatchTable(p.lookup, table)
+}
+
+func (p *Policy) warnf(format string, args ...any) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, args...))
+}
+
+// edgeNames returns the names a follow rule can address an edge by
+func edgeNames(edge Edge) []string {
+	names := []string{edge.Child + "." + strings.Join(edge.ChildColumns, ",")}
+	if edge.Name != "" {
+		names = append([]string{edge.Name}, names...)
+	}
+	return names
+}
@@ -0,0 +1,404 @@
+package engine
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// selectRows computes the subset of an including dump of the fixture
+func selectRows(t *testing.T, sess *Session, cfg models.DumpConfig) (map[string][]string, *Selection) {
+	t.Helper()
+	cfg.Mode = models.StructureAndDataIncludingOnly
+	sel, err := sess.Engine.Select(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys(sel.Subset), sel
+}
+
+func TestFollowRules(t *testing.T) {
+	sess, _ := openFixture(t)
+	tests := []struct {
+		name   string
+		seed   models.Seed
+		follow map[string]models.FollowRule
+		want   map[string][]string
+	}{
+		{
+			name: "default rules",
+			seed: models.Seed{Table: "employee_positions", Keys: []string{"4"}},
+			want: map[string][]string{
+				"employee_positions": {"4"},
+				"positions":          {"2"},
+				"employees":          {"1", "4"},
+				"departments":        {"1", "3"},
+				"locations":          {"1"},
+				"companies":          {"1"},
+			},
+		},
+		{
+			name:   "children of every reached row",
+			seed:   models.Seed{Table: "employee_positions", Keys: []string{"4"}},
+			follow: map[string]models.FollowRule{"employee_positions.position_id": models.FollowChildren},
+			want: map[string][]string{
+				"employee_positions": {"4", "8"},
+				"positions":          {"2"},
+				"employees":          {"1", "4", "8"},
+				"departments":        {"1", "3", "8"},
+				"locations":          {"1", "3"},
+				"companies":          {"1", "2"},
+			},
+		},
+		{
+			name:   "parents only",
+			seed:   models.Seed{Table: "positions", Keys: []string{"8"}},
+			follow: map[string]models.FollowRule{"employee_positions.position_id": models.FollowParents},
+			want:   map[string][]string{"positions": {"8"}},
+		},
+		{
+			name:   "not followed",
+			seed:   models.Seed{Table: "positions", Keys: []string{"8"}},
+			follow: map[string]models.FollowRule{"employee_positions.employee_id": models.FollowNone},
+			want: map[string][]string{
+				"positions":          {"8"},
+				"employee_positions": {"10"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := selectRows(t, sess, models.DumpConfig{Seeds: []models.Seed{tt.seed}, Follow: tt.follow})
+			if !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowNoneDropsForeignKey(t *testing.T) {
+	sess, source := openFixture(t)
+	cfg := models.DumpConfig{
+		SourceConfig: source,
+		Seeds:        []models.Seed{{Table: "positions", Keys: []string{"8"}}},
+		Follow:       map[string]models.FollowRule{"employee_positions.employee_id": models.FollowNone},
+	}
+	_, sel := selectRows(t, sess, cfg)
+	if len(sel.Warnings) == 0 {
+		t.Error("expected a warning about the dropped foreign key")
+	}
+
+	cfg.Mode = models.StructureAndDataIncludingOnly
+	script, _ := dumpScript(t, cfg)
+	if violations := foreignKeyViolations(t, script); len(violations) > 0 {
+		t.Errorf("dangling references: %v", violations)
+	}
+}
+
+func TestFollowRulesExcluding(t *testing.T) {
+	sess, source := openFixture(t)
+	ctx := context.Background()
+	root := []models.Seed{{Table: "employees", Keys: []string{"1"}}}
+	for _, rule := range []models.FollowRule{models.FollowDefault, models.FollowChildren, models.FollowParents, models.FollowNone} {
+		t.Run(rule.String(), func(t *testing.T) {
+			cfg := models.DumpConfig{
+				SourceConfig: source,
+				Mode:         models.StructureAndDataExcluding,
+				Seeds:        root,
+				Follow:       map[string]models.FollowRule{"employees.manager_id": rule},
+			}
+			_, err := sess.Engine.Select(ctx, cfg)
+			if rule == models.FollowParents || rule == models.FollowNone {
+				if err == nil {
+					t.Error("expected the excluding mode to reject the rule")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			script, _ := dumpScript(t, cfg)
+			if violations := foreignKeyViolations(t, script); len(violations) > 0 {
+				t.Errorf("dangling references: %v", violations)
+			}
+		})
+	}
+
+	// The employees reporting to a removed manager go with it whatever the rule
+	policy, err := NewPolicy(sess.Engine.Graph(), nil, models.DumpConfig{
+		Follow: map[string]models.FollowRule{"employees.manager_id": models.FollowParents},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subset, err := sess.Engine.Dependents(ctx, policy, root...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := sess.Engine.Dependents(ctx, nil, root...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := keys(subset), keys(all); !maps.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("dependents = %v, want %v", got, want)
+	}
+	if got := keys(subset)["employees"]; !slices.Contains(got, "2") {
+		t.Errorf("dependent employees = %v, want the reports of employee 1", got)
+	}
+}
+
+func TestBoundaryTables(t *testing.T) {
+	sess, _ := openFixture(t)
+	tests := []struct {
+		name string
+		cfg  models.DumpConfig
+		want map[string][]string
+	}{
+		{
+			name: "seed table",
+			cfg: models.DumpConfig{
+				Seeds:          []models.Seed{{Table: "employees", Keys: []string{"3"}}},
+				BoundaryTables: []string{"employees"},
+			},
+			want: map[string][]string{
+				"employees":   {"1", "2", "3"},
+				"departments": {"1", "2"},
+				"locations":   {"1"},
+				"companies":   {"1"},
+			},
+		},
+		{
+			name: "over a children rule",
+			cfg: models.DumpConfig{
+				Seeds:          []models.Seed{{Table: "employee_positions", Keys: []string{"4"}}},
+				Follow:         map[string]models.FollowRule{"employee_positions.position_id": models.FollowChildren},
+				BoundaryTables: []string{"position*"},
+			},
+			want: map[string][]string{
+				"employee_positions": {"4"},
+				"positions":          {"2"},
+				"employees":          {"1", "4"},
+				"departments":        {"1", "3"},
+				"locations":          {"1"},
+				"companies":          {"1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := selectRows(t, sess, tt.cfg)
+			if !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupTables(t *testing.T) {
+	sess, source := openFixture(t)
+	cfg := models.DumpConfig{
+		SourceConfig: source,
+		Seeds:        []models.Seed{{Table: "employees", Keys: []string{"3"}}},
+		LookupTables: []string{"companies", "locations"},
+	}
+	got, sel := selectRows(t, sess, cfg)
+	want := map[string][]string{
+		"employees":           {"1", "2", "3"},
+		"employee_positions":  {"3"},
+		"positions":           {"1"},
+		"project_assignments": {"4"},
+		"projects":            {"1", "2"},
+		"expenses":            {"1"},
+		"expense_categories":  {"1", "2"},
+		"departments":         {"1", "2"},
+	}
+	if !maps.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	for _, table := range cfg.LookupTables {
+		if f := sel.Filter(table); f != FilterAll {
+			t.Errorf("filter of %s = %v, want %v", table, f, FilterAll)
+		}
+	}
+
+	cfg.Mode = models.StructureAndDataIncludingOnly
+	script, res := dumpScript(t, cfg)
+	for _, tr := range res.Tables {
+		if tr.Name == "companies" && tr.Rows != 5 {
+			t.Errorf("dumped %d companies, want all 5", tr.Rows)
+		}
+	}
+	if violations := foreignKeyViolations(t, script); len(violations) > 0 {
+		t.Errorf("dangling references: %v", violations)
+	}
+
+	cfg.Seeds = []models.Seed{{Table: "companies", Keys: []string{"1"}}}
+	if _, err := sess.Engine.Select(context.Background(), cfg); err == nil {
+		t.Error("expected an error for a seed on a lookup table")
+	}
+}
+
+func TestBoundaryAndLookupExcluding(t *testing.T) {
+	sess, _ := openFixture(t)
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		cfg  models.DumpConfig
+	}{
+		{"boundary", models.DumpConfig{
+			Seeds:          []models.Seed{{Table: "employees", Keys: []string{"1"}}},
+			BoundaryTables: []string{"employees"},
+		}},
+		{"lookup", models.DumpConfig{
+			Seeds:        []models.Seed{{Table: "employees", Keys: []string{"3"}}},
+			LookupTables: []string{"expenses"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Rows depending on the seeds would be kept, referencing them
+			tt.cfg.Mode = models.StructureAndDataExcluding
+			if _, err := sess.Engine.Select(ctx, tt.cfg); err == nil {
+				t.Error("expected the excluding mode to reject the tables")
+			}
+
+			scope, err := NewScope(sess.Schema, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy, err := NewPolicy(sess.Engine.Graph(), scope, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := sess.Engine.Dependents(ctx, policy, tt.cfg.Seeds...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := sess.Engine.Dependents(ctx, nil, tt.cfg.Seeds...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.EqualFunc(keys(got), keys(want), slices.Equal) {
+				t.Errorf("dependents = %v, want %v", keys(got), keys(want))
+			}
+		})
+	}
+}
+
+// depth returns a max depth setting
+func depth(n int) *int {
+	return &n
+}
+
+func TestLimits(t *testing.T) {
+	sess, source := openFixture(t)
+	location := []models.Seed{{Table: "locations", Keys: []string{"3"}}}
+	depthCuts := []string{
+		"expenses(department_id) -> departments(id): 1 rows of expenses left out, max depth 1 reached",
+		"projects(department_id) -> departments(id): 1 rows of projects left out, max depth 1 reached",
+		"employee_positions(employee_id) -> employees(id): 1 rows of employee_positions left out, max depth 1 reached",
+		"expenses(employee_id) -> employees(id): 1 rows of expenses left out, max depth 1 reached",
+		"project_assignments(employee_id) -> employees(id): 1 rows of project_assignments left out, max depth 1 reached",
+		"projects(project_manager_id) -> employees(id): 1 rows of projects left out, max depth 1 reached",
+	}
+	tests := []struct {
+		name  string
+		cfg   models.DumpConfig
+		rows  map[string]int // rows of some tables
+		total int
+		cuts  []string
+	}{
+		{
+			name:  "max depth",
+			cfg:   models.DumpConfig{Seeds: location, MaxDepth: depth(1)},
+			rows:  map[string]int{"locations": 1, "departments": 1, "employees": 1, "companies": 2},
+			total: 5,
+			cuts:  depthCuts,
+		},
+		{
+			name:  "max depth at the seeds",
+			cfg:   models.DumpConfig{Seeds: location, MaxDepth: depth(0)},
+			rows:  map[string]int{"locations": 1, "companies": 2},
+			total: 3,
+			cuts: []string{
+				"departments(location_id) -> locations(id): 1 rows of departments left out, max depth 0 reached",
+				"employees(location_id) -> locations(id): 1 rows of employees left out, max depth 0 reached",
+			},
+		},
+		{
+			name: "depth limit of an edge",
+			cfg:  models.DumpConfig{Seeds: location, DepthLimits: map[string]int{"employees.location_id": 0}},
+			// Employee 8 is still reached through its department
+			rows:  map[string]int{"employees": 2, "departments": 2},
+			total: 17,
+			cuts:  []string{"employees(location_id) -> locations(id): 1 rows of employees left out, max depth 0 reached"},
+		},
+		{
+			name:  "stricter of max depth and depth limit",
+			cfg:   models.DumpConfig{Seeds: location, MaxDepth: depth(1), DepthLimits: map[string]int{"employees.location_id": 2}},
+			total: 5,
+			cuts:  depthCuts,
+		},
+		{
+			name:  "table budget",
+			cfg:   models.DumpConfig{Seeds: []models.Seed{{Table: "departments", Keys: []string{"1"}}}, TableMaxRows: map[string]int{"employees": 2}},
+			rows:  map[string]int{"departments": 5, "employees": 6},
+			total: 43,
+			cuts: []string{
+				"employees(department_id) -> departments(id): 3 rows of employees left out, row budget of 2 for employees reached",
+				"employees(manager_id) -> employees(id): 3 rows of employees left out, row budget of 2 for employees reached",
+			},
+		},
+		{
+			name: "total budget",
+			cfg:  models.DumpConfig{Seeds: location, MaxRows: 4},
+			// The referenced companies are pulled past the budget
+			rows:  map[string]int{"locations": 1, "departments": 1, "employees": 1, "companies": 2},
+			total: 5,
+			cuts: []string{
+				"expenses(department_id) -> departments(id): 1 rows of expenses left out, total row budget of 4 reached",
+				"projects(department_id) -> departments(id): 1 rows of projects left out, total row budget of 4 reached",
+				"employee_positions(employee_id) -> employees(id): 1 rows of employee_positions left out, total row budget of 4 reached",
+				"expenses(employee_id) -> employees(id): 1 rows of expenses left out, total row budget of 4 reached",
+				"project_assignments(employee_id) -> employees(id): 1 rows of project_assignments left out, total row budget of 4 reached",
+				"projects(project_manager_id) -> employees(id): 1 rows of projects left out, total row budget of 4 reached",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.SourceConfig = source
+			_, sel := selectRows(t, sess, tt.cfg)
+			for table, n := range tt.rows {
+				if got := sel.Subset.Rows(table).Len(); got != n {
+					t.Errorf("%d rows of %s, want %d", got, table, n)
+				}
+			}
+			if got := sel.Subset.Count(); got != tt.total {
+				t.Errorf("%d rows in the subset, want %d", got, tt.total)
+			}
+			var cuts []string
+			for _, c := range sel.Cuts {
+				cuts = append(cuts, c.String())
+			}
+			if !slices.Equal(cuts, tt.cuts) {
+				t.Errorf("cuts = %q, want %q", cuts, tt.cuts)
+			}
+
+			tt.cfg.Mode = models.StructureAndDataIncludingOnly
+			script, _ := dumpScript(t, tt.cfg)
+			if violations := foreignKeyViolations(t, script); len(violations) > 0 {
+				t.Errorf("dangling references: %v", violations)
+			}
+
+			// Rows depending on a seed past a limit would keep referencing it
+			tt.cfg.Mode = models.StructureAndDataExcluding
+			if _, err := sess.Engine.Select(context.Background(), tt.cfg); err == nil {
+				t.Error("expected the excluding mode to reject the limits")
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// ReportSuffix is appended to the dump file name, without its extension, to name its report
+const ReportSuffix = ".report.json"
+
+// DefaultReportPath returns the report file next to the output of a file dump,
+// or an empty string when the dump has no output file
+func DefaultReportPath(cfg models.DumpConfig) string {
+	if cfg.Target != models.ToFile || cfg.OutputPath == "" || cfg.OutputPath == "-" {
+		return ""
+	}
+	return strings.TrimSuffix(cfg.OutputPath, filepath.Ext(cfg.OutputPath)) + ReportSuffix
+}
+
+// MarshalJSON renders the duration in seconds, which is easier to consume than nanoseconds
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		result
+		Duration float64 `json:"duration_seconds"`
+	}{result(r), r.Duration.Seconds()})
+}
+
+// WriteReport writes the result as a JSON report
+func (r *Result) WriteReport(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
+
+// Summary renders the result as plain text, suitable for copying
+func (r *Result) Summary() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Source:   %s\n", r.Source)
+	fmt.Fprintf(&b, "Mode:     %s\n", r.Mode)
+	fmt.Fprintf(&b, "Snapshot: %s\n", r.Snapshot)
+	fmt.Fprintf(&b, "Output:   %s (%s)\n", r.Output, r.Target)
+	fmt.Fprintf(&b, "Rows:     %d in %d tables\n", r.Rows, len(r.Tables))
+	var deferred int64
+	for _, t := range r.Tables {
+		deferred += t.Deferred
+	}
+	if deferred > 0 {
+		fmt.Fprintf(&b, "Deferred: %d references restored after loading, to break cycles\n", deferred)
+	}
+	fmt.Fprintf(&b, "Written:  %s\n", FormatBytes(r.Bytes))
+	fmt.Fprintf(&b, "Duration: %s\n", r.Duration.Round(time.Millisecond))
+	if r.Report != "" {
+		fmt.Fprintf(&b, "Report:   %s\n", r.Report)
+	}
+
+	b.WriteString("\n")
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tFILTER\tROWS")
+	for _, t := range r.Tables {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", t.Name, t.Filter, t.Rows)
+	}
+	tw.Flush()
+
+	if len(r.Warnings) > 0 {
+		fmt.Fprintf(&b, "\nWarnings (%d):\n", len(r.Warnings))
+		for _, w := range r.Warnings {
+			fmt.Fprintf(&b, "- %s\n", w)
+		}
+	}
+	return b.String()
+}
+
+// FormatBytes renders a byte count with a binary unit
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// restoreReportEvery is the number of statements between two restore progress updates
+const restoreReportEvery = 50
+
+// Restore executes a SQL script against a database and returns the number of
+// statements run. Scripts written by reltrace are restored statement by
+// statement on a single connection, so their session settings stay in effect.
+func Restore(ctx context.Context, cfg models.DatabaseConfig, r io.Reader, progress ProgressFunc) (int, error) {
+	target, err := adapters.Open(ctx, cfg)
+	if err != nil {
+		return 0, err
+	}
+	out, err := newDatabaseSink(ctx, target)
+	if err != nil {
+		return 0, err
+	}
+	defer out.close()
+
+	n := 0
+	err = SplitStatements(r, cfg.Type == models.MySQL, func(stmt string) error {
+		if err := out.exec(ctx, stmt); err != nil {
+			return fmt.Errorf("statement %d: %w", n+1, err)
+		}
+		n++
+		if progress != nil && n%restoreReportEvery == 0 {
+			progress(Progress{Phase: PhaseWriting, Expected: -1, Rows: int64(n), Message: fmt.Sprintf("%d statements executed", n)})
+		}
+		return nil
+	})
+	return n, err
+}
+
+// SplitStatements reads a SQL script and calls fn for every statement, without
+// its terminating semicolon. Quoted strings, quoted identifiers and comments
+// are honoured; backslashEscapes enables MySQL style escapes inside strings.
+func SplitStatements(r io.Reader, backslashEscapes bool, fn func(stmt string) error) error {
+	br := bufio.NewReader(r)
+	var (
+		stmt  strings.Builder
+		quote byte // the open quote character, or zero outside quotes
+	)
+
+	emit := func() error {
+		s := strings.TrimSpace(stmt.String())
+		stmt.Reset()
+		if s == "" {
+			return nil
+		}
+		return fn(s)
+	}
+
+	for {
+		c, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			if quote != 0 {
+				return errors.New("unterminated quoted string at end of script")
+			}
+			return emit()
+		}
+		if err != nil {
+			return err
+		}
+
+		if quote != 0 {
+			stmt.WriteByte(c)
+			switch {
+			case c == '\\' && quote == '\'' && backslashEscapes:
+				next, err := br.ReadByte()
+				if err != nil {
+					return errors.New("unterminated quoted string at end of script")
+				}
+				stmt.WriteByte(next)
+			case c == quote:
+				// A doubled quote is an escaped quote and keeps the string open
+				if next, err := br.Peek(1); err == nil && next[0] == quote {
+					b, _ := br.ReadByte()
+					stmt.WriteByte(b)
+				} else {
+					quote = 0
+				}
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+			stmt.WriteByte(c)
+		case '-':
+			if next, err := br.Peek(1); err == nil && next[0] == '-' {
+				if _, err := br.ReadString('\n'); err != nil && !errors.Is(err, io.EOF) {
+					return err
+				}
+				stmt.WriteByte('\n')
+				continue
+			}
+			stmt.WriteByte(c)
+		case '/':
+			if next, err := br.Peek(1); err == nil && next[0] == '*' {
+				if err := skipBlockComment(br); err != nil {
+					return err
+				}
+				stmt.WriteByte(' ')
+				continue
+			}
+			stmt.WriteByte(c)
+		case ';':
+			if err := emit(); err != nil {
+				return err
+			}
+		default:
+			stmt.WriteByte(c)
+		}
+	}
+}
+
+// skipBlockComment consumes a /* ... */ comment whose opening slash was already read
+func skipBlockComment(br *bufio.Reader) error {
+	if _, err := br.ReadByte(); err != nil {
+		return err
+	}
+	var prev byte
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return errors.New("unterminated comment at end of script")
+		}
+		if prev == '*' && c == '/' {
+			return nil
+		}
+		prev = c
+	}
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Scope is the set of tables a dump covers once the include and exclude
+// lists are applied
+type Scope struct {
+	// Tables holds the covered tables in schema order. Foreign keys pointing
+	// outside the scope are removed, so the dumped schema stays restorable.
+	Tables   []models.Table
+	Warnings []string
+	names    map[string]bool
+}
+
+// NewScope applies include and exclude patterns to the tables of a schema.
+// An empty include list covers every table; exclusions win over inclusions.
+func NewScope(schema *models.Schema, include, exclude []string) (*Scope, error) {
+	if err := models.ValidateTablePatterns(include); err != nil {
+		return nil, err
+	}
+	if err := models.ValidateTablePatterns(exclude); err != nil {
+		return nil, err
+	}
+
+	s := &Scope{names: make(map[string]bool)}
+	for _, t := range schema.Tables {
+		if (len(include) == 0 || models.MatchTable(include, t.Name)) && !models.MatchTable(exclude, t.Name) {
+			s.names[t.Name] = true
+		}
+	}
+
+	for _, list := range []struct {
+		name     string
+		patterns []string
+	}{{"include", include}, {"exclude", exclude}} {
+		for _, p := range list.patterns {
+			if !matchesAny(schema, p) {
+				s.warnf("%s pattern %s matches no table", list.name, p)
+			}
+		}
+	}
+
+	for _, t := range schema.Tables {
+		if !s.names[t.Name] {
+			continue
+		}
+		fks := make([]models.ForeignKey, 0, len(t.ForeignKeys))
+		for _, fk := range t.ForeignKeys {
+			if s.names[fk.RefTable] {
+				fks = append(fks, fk)
+				continue
+			}
+			s.warnf("%s(%s) references excluded table %s: the foreign key is left out and the referenced rows are not dumped",
+				t.Name, strings.Join(fk.Columns, ", "), fk.RefTable)
+		}
+		t.ForeignKeys = fks
+		s.Tables = append(s.Tables, t)
+	}
+	return s, nil
+}
+
+// Contains reports whether a table is covered by the scope
+func (s *Scope) Contains(table string) bool {
+	return s.names[table]
+}
+
+func (s *Scope) warnf(format string, args ...any) {
+	s.Warnings = append(s.Warnings, fmt.Sprintf(format, args...))
+}
+
+// matchesAny reports whether a pattern matches at least one table of the schema
+func matchesAny(schema *models.Schema, pattern string) bool {
+	for _, t := range schema.Tables {
+		if models.MatchTable([]string{pattern}, t.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewScope(t *testing.T) {
+	sess, _ := openFixture(t)
+	tests := []struct {
+		name             string
+		include, exclude []string
+		// Tables as name:foreign keys kept, in schema order
+		tables   []string
+		warnings []string
+	}{
+		{
+			name: "every table",
+			tables: []string{"activity_log:1", "clients:1", "companies:1", "contracts:3", "departments:3", "employee_positions:2",
+				"employees:4", "expense_categories:1", "expenses:5", "locations:1", "positions:0", "project_assignments:2", "projects:4"},
+		},
+		{
+			name:    "included tables lose their keys to the others",
+			include: []string{"employee*", "companies"},
+			tables:  []string{"companies:1", "employee_positions:1", "employees:2"},
+			warnings: []string{
+				"employee_positions(position_id) references excluded table positions: the foreign key is left out and the referenced rows are not dumped",
+				"employees(department_id) references excluded table departments: the foreign key is left out and the referenced rows are not dumped",
+				"employees(location_id) references excluded table locations: the foreign key is left out and the referenced rows are not dumped",
+			},
+		},
+		{
+			name:    "excluding referencing tables keeps every key",
+			exclude: []string{"activity_log", "expense*"},
+			tables: []string{"clients:1", "companies:1", "contracts:3", "departments:3", "employee_positions:2",
+				"employees:4", "locations:1", "positions:0", "project_assignments:2", "projects:4"},
+		},
+		{
+			name:    "exclusions win, and patterns matching nothing are reported",
+			include: []string{"companies", "locations", "audit_*"},
+			exclude: []string{"locations", "tmp_*"},
+			tables:  []string{"companies:1"},
+			warnings: []string{
+				"include pattern audit_* matches no table",
+				"exclude pattern tmp_* matches no table",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScope(sess.Schema, tt.include, tt.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var tables []string
+			for _, table := range s.Tables {
+				tables = append(tables, fmt.Sprintf("%s:%d", table.Name, len(table.ForeignKeys)))
+				if !s.Contains(table.Name) {
+					t.Errorf("scope does not contain its table %s", table.Name)
+				}
+			}
+			if !slices.Equal(tables, tt.tables) {
+				t.Errorf("tables = %q, want %q", tables, tt.tables)
+			}
+			if !slices.Equal(s.Warnings, tt.warnings) {
+				t.Errorf("warnings = %q, want %q", s.Warnings, tt.warnings)
+			}
+		})
+	}
+
+	// The schema itself keeps every foreign key
+	if n := len(sess.Schema.Table("employees").ForeignKeys); n != 4 {
+		t.Errorf("schema employees has %d foreign keys left, want 4", n)
+	}
+	for _, patterns := range [][]string{{"audit_[a-"}, {"["}} {
+		if _, err := NewScope(sess.Schema, patterns, nil); err == nil || !strings.Contains(err.Error(), "invalid table pattern") {
+			t.Errorf("include %q: err = %v, want an invalid pattern", patterns, err)
+		}
+		if _, err := NewScope(sess.Schema, nil, patterns); err == nil || !strings.Contains(err.Error(), "invalid table pattern") {
+			t.Errorf("exclude %q: err = %v, want an invalid pattern", patterns, err)
+		}
+	}
+}
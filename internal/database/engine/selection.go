@@ -0,0 +1,156 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Filter describes which rows of a table belong to a dump
+type Filter int
+
+const (
+	// FilterNone exports no rows of the table
+	FilterNone Filter = iota
+	// FilterAll exports every row of the table
+	FilterAll
+	// FilterOnly exports only the rows in the subset
+	FilterOnly
+	// FilterExcept exports every row except the ones in the subset
+	FilterExcept
+)
+
+func (f Filter) String() string {
+	switch f {
+	case FilterNone:
+		return "none"
+	case FilterAll:
+		return "all"
+	case FilterOnly:
+		return "only"
+	case FilterExcept:
+		return "except"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText renders the filter by name in reports
+func (f Filter) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// Selection is the set of rows a dump operation exports
+type Selection struct {
+	Mode   models.DumpMode
+	Scope  *Scope
+	Policy *Policy
+	// Subset holds the rows the including mode exports, the closure of the
+	// seeds in both directions, or the rows the excluding mode leaves out,
+	// the seeds and the rows depending on them
+	Subset   *Subset
+	Cuts     []Cut
+	Warnings []string
+}
+
+// Select computes the rows selected by a dump configuration. It is the single
+// place where the data of the including and excluding modes is determined.
+func (e *Engine) Select(ctx context.Context, cfg models.DumpConfig) (*Selection, error) {
+	scope, err := NewScope(e.schema, cfg.IncludeTables, cfg.ExcludeTables)
+	if err != nil {
+		return nil, err
+	}
+	sel := &Selection{Mode: cfg.Mode, Scope: scope, Warnings: scope.Warnings}
+
+	switch cfg.Mode {
+	case models.StructureOnly, models.StructureAndData:
+		return sel, nil
+	case models.StructureAndDataExcluding, models.StructureAndDataIncludingOnly:
+		seeds, err := cfg.AllSeeds()
+		if err != nil {
+			return nil, err
+		}
+		if len(seeds) == 0 {
+			return nil, fmt.Errorf("mode %s requires a root table and primary key, or seeds", cfg.Mode)
+		}
+		if cfg.Mode == models.StructureAndDataExcluding {
+			if err := cfg.ValidateExcluding(); err != nil {
+				return nil, err
+			}
+		}
+		policy, err := NewPolicy(e.graph, scope, cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, seed := range seeds {
+			if err := seed.Validate(); err != nil {
+				return nil, err
+			}
+			if e.schema.Table(seed.Table) != nil && !scope.Contains(seed.Table) {
+				return nil, fmt.Errorf("seed table %s is excluded from the dump", seed.Table)
+			}
+			if policy.Lookup(seed.Table) {
+				return nil, fmt.Errorf("seed table %s is a lookup table, which is dumped in full", seed.Table)
+			}
+		}
+		traverse := e.Traverse
+		if cfg.Mode == models.StructureAndDataExcluding {
+			// Rows referenced by the excluded ones stay, or the rows
+			// referencing them too would be left dangling
+			traverse = e.Dependents
+		}
+		subset, err := traverse(ctx, policy, seeds...)
+		if err != nil {
+			return nil, err
+		}
+		sel.Policy = policy
+		sel.Subset = subset
+		sel.Cuts = subset.Cuts
+		sel.Warnings = append(sel.Warnings, policy.Warnings...)
+		sel.Warnings = append(sel.Warnings, subset.Warnings...)
+		return sel, nil
+	default:
+		return nil, fmt.Errorf("unsupported dump mode %s", cfg.Mode)
+	}
+}
+
+// Filter returns how the rows of a table are selected
+func (s *Selection) Filter(table string) Filter {
+	if s.Scope != nil && !s.Scope.Contains(table) {
+		return FilterNone
+	}
+	if s.Policy.Lookup(table) {
+		return FilterAll
+	}
+	switch s.Mode {
+	case models.StructureAndData:
+		return FilterAll
+	case models.StructureAndDataIncludingOnly:
+		if s.Subset.Rows(table).Len() == 0 {
+			return FilterNone
+		}
+		return FilterOnly
+	case models.StructureAndDataExcluding:
+		if s.Subset.Rows(table).Len() == 0 {
+			return FilterAll
+		}
+		return FilterExcept
+	default:
+		return FilterNone
+	}
+}
+
+// Includes reports whether the row of table identified by key is exported
+func (s *Selection) Includes(table string, key Key) bool {
+	switch s.Filter(table) {
+	case FilterAll:
+		return true
+	case FilterOnly:
+		return s.Subset.Rows(table).Contains(key)
+	case FilterExcept:
+		return !s.Subset.Rows(table).Contains(key)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+func TestSelectFilter(t *testing.T) {
+	sess, _ := openFixture(t)
+	only := func(tables ...string) map[string]Filter {
+		filters := make(map[string]Filter)
+		for _, table := range sess.Schema.TableNames() {
+			filters[table] = FilterNone
+		}
+		for _, table := range tables {
+			filters[table] = FilterOnly
+		}
+		return filters
+	}
+	except := func(tables ...string) map[string]Filter {
+		filters := make(map[string]Filter)
+		for _, table := range sess.Schema.TableNames() {
+			filters[table] = FilterAll
+		}
+		for _, table := range tables {
+			filters[table] = FilterExcept
+		}
+		return filters
+	}
+
+	tests := []struct {
+		name string
+		cfg  models.DumpConfig
+		want map[string]Filter
+	}{
+		{
+			name: "full dump",
+			cfg:  models.DumpConfig{Mode: models.StructureAndData},
+			want: except(),
+		},
+		{
+			name: "including the closure of a row",
+			cfg:  models.DumpConfig{Mode: models.StructureAndDataIncludingOnly, RootTable: "employees", RootPrimaryKey: "3"},
+			want: only("employees", "employee_positions", "positions", "project_assignments", "projects",
+				"expenses", "expense_categories", "departments", "locations", "companies"),
+		},
+		{
+			name: "excluding a row and its dependents",
+			cfg:  models.DumpConfig{Mode: models.StructureAndDataExcluding, RootTable: "employees", RootPrimaryKey: "3"},
+			want: except("employees", "employee_positions", "project_assignments", "expenses"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := sess.Engine.Select(context.Background(), tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for table, want := range tt.want {
+				if got := sel.Filter(table); got != want {
+					t.Errorf("Filter(%s) = %s, want %s", table, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectIncludes(t *testing.T) {
+	sess, _ := openFixture(t)
+	tests := []struct {
+		mode models.DumpMode
+		row  Step
+		want bool
+	}{
+		{models.StructureAndDataIncludingOnly, Step{Table: "employees", Key: Key{int64(3)}}, true},
+		{models.StructureAndDataIncludingOnly, Step{Table: "employees", Key: Key{int64(2)}}, true},
+		{models.StructureAndDataIncludingOnly, Step{Table: "employees", Key: Key{int64(4)}}, false},
+		{models.StructureAndDataExcluding, Step{Table: "employees", Key: Key{int64(3)}}, false},
+		{models.StructureAndDataExcluding, Step{Table: "employees", Key: Key{int64(2)}}, true},
+		{models.StructureAndDataExcluding, Step{Table: "companies", Key: Key{int64(1)}}, true},
+		{models.StructureAndDataExcluding, Step{Table: "expenses", Key: Key{int64(1)}}, false},
+		{models.StructureAndDataExcluding, Step{Table: "expenses", Key: Key{int64(2)}}, true},
+	}
+	for _, tt := range tests {
+		cfg := models.DumpConfig{Mode: tt.mode, RootTable: "employees", RootPrimaryKey: "3"}
+		sel, err := sess.Engine.Select(context.Background(), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := sel.Includes(tt.row.Table, tt.row.Key); got != tt.want {
+			t.Errorf("%s: Includes(%s) = %v, want %v", tt.mode, tt.row, got, tt.want)
+		}
+	}
+}
+
+// TestDumpKeepsForeignKeys loads the dumps of subsets into a new database
+// and checks that no reference is left dangling
+func TestDumpKeepsForeignKeys(t *testing.T) {
+	_, source := openFixture(t)
+	roots := []struct {
+		table, key string
+	}{
+		{"employees", "1"},
+		{"employees", "3"},
+		{"companies", "1"},
+		{"departments", "1"},
+		{"projects", "1"},
+		{"expense_categories", "4"},
+	}
+	for _, mode := range []models.DumpMode{models.StructureAndDataIncludingOnly, models.StructureAndDataExcluding} {
+		for _, root := range roots {
+			t.Run(mode.String()+"/"+root.table+"#"+root.key, func(t *testing.T) {
+				cfg := models.DumpConfig{SourceConfig: source, Mode: mode, RootTable: root.table, RootPrimaryKey: root.key}
+				script, res := dumpScript(t, cfg)
+				if res.Rows == 0 {
+					t.Fatal("dump holds no rows")
+				}
+				if violations := foreignKeyViolations(t, script); len(violations) > 0 {
+					t.Errorf("dangling references: %v", violations)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// Session is an open source database together with its introspected schema
+type Session struct {
+	Source adapters.Adapter
+	Schema *models.Schema
+	Engine *Engine
+}
+
+// Open connects to a database and introspects its schema
+func Open(ctx context.Context, cfg models.DatabaseConfig) (*Session, error) {
+	src, err := adapters.Open(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := adapters.Introspect(ctx, src)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("introspecting schema: %w", err)
+	}
+
+	return &Session{
+		Source: src,
+		Schema: schema,
+		Engine: New(src.Reader(), src, schema),
+	}, nil
+}
+
+// OpenDump opens the source of a dump configuration, declares the
+// relationships it adds and starts the snapshot its reads run in
+func OpenDump(ctx context.Context, cfg models.DumpConfig) (*Session, error) {
+	sess, err := Open(ctx, cfg.SourceConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := sess.AddRelations(cfg); err != nil {
+		sess.Close()
+		return nil, err
+	}
+	if err := sess.Snapshot(ctx, cfg.Snapshot); err != nil {
+		sess.Close()
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Snapshot makes every following read of the session see the source as it
+// is now, until the session is closed. A relationship traversal issues many
+// queries, and rows written between them would otherwise reference rows read
+// before they existed. The locks mode read locks every table instead.
+func (s *Session) Snapshot(ctx context.Context, mode models.SnapshotMode) error {
+	if mode.OrDefault() == models.SnapshotNone {
+		return nil
+	}
+	if err := s.Source.BeginSnapshot(ctx, mode.OrDefault(), s.Schema.TableNames()); err != nil {
+		return fmt.Errorf("%s snapshot: %w", mode.OrDefault(), err)
+	}
+	s.Engine = New(s.Source.Reader(), s.Source, s.Schema)
+	return nil
+}
+
+// AddRelations declares the relationships of a dump configuration the source
+// does not enforce, which are then traversed like its own foreign keys
+func (s *Session) AddRelations(cfg models.DumpConfig) error {
+	if len(cfg.VirtualForeignKeys) == 0 && len(cfg.Polymorphic) == 0 {
+		return nil
+	}
+	if err := s.Schema.AddRelations(cfg); err != nil {
+		return err
+	}
+	s.Engine = New(s.Source.Reader(), s.Source, s.Schema)
+	return nil
+}
+
+// Close releases the source connection
+func (s *Session) Close() error {
+	return s.Source.Close()
+}
+
+// RowCount returns the exact number of rows of a table
+func (s *Session) RowCount(ctx context.Context, table string) (int64, error) {
+	var n int64
+	query := "SELECT COUNT(*) FROM " + s.Source.QuoteIdentifier(table)
+	if err := s.Source.Reader().QueryRowContext(ctx, query).Scan(&n); err != nil {
+		return 0, fmt.Errorf("counting rows of %s: %w", table, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// spool keeps statements in a temporary file until they can run, so the
+// references deferred to break cycles are held in bounded memory however
+// many rows the cyclic tables have
+type spool struct {
+	file *os.File
+	w    *bufio.Writer
+	n    int64
+}
+
+// add appends statements to the spool, creating its file on first use
+func (s *spool) add(stmts ...string) error {
+	if len(stmts) == 0 {
+		return nil
+	}
+	if s.file == nil {
+		f, err := os.CreateTemp("", "reltrace-deferred-*.sql")
+		if err != nil {
+			return fmt.Errorf("spooling deferred references: %w", err)
+		}
+		s.file, s.w = f, bufio.NewWriter(f)
+	}
+	for _, stmt := range stmts {
+		if _, err := s.w.Write(binary.AppendUvarint(nil, uint64(len(stmt)))); err != nil {
+			return fmt.Errorf("spooling deferred references: %w", err)
+		}
+		if _, err := s.w.WriteString(stmt); err != nil {
+			return fmt.Errorf("spooling deferred references: %w", err)
+		}
+		s.n++
+	}
+	return nil
+}
+
+// len returns the number of statements spooled
+func (s *spool) len() int64 {
+	return s.n
+}
+
+// replay passes the spooled statements to a sink in the order they were added
+func (s *spool) replay(ctx context.Context, out sink) error {
+	if s.file == nil {
+		return nil
+	}
+	if err := s.w.Flush(); err != nil {
+		return fmt.Errorf("spooling deferred references: %w", err)
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("reading deferred references: %w", err)
+	}
+	r := bufio.NewReader(s.file)
+	var stmt []byte
+	for range s.n {
+		size, err := binary.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("reading deferred references: %w", err)
+		}
+		if uint64(cap(stmt)) < size {
+			stmt = make([]byte, size)
+		}
+		stmt = stmt[:size]
+		if _, err := io.ReadFull(r, stmt); err != nil {
+			return fmt.Errorf("reading deferred references: %w", err)
+		}
+		if err := out.exec(ctx, string(stmt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close removes the file of the spool
+func (s *spool) close() {
+	if s.file == nil {
+		return
+	}
+	s.file.Close()
+	os.Remove(s.file.Name())
+	s.file = nil
+}
@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Key identifies a row by the values of its primary key columns
+type Key []any
+
+// String returns the canonical form of the key used for set membership
+func (k Key) String() string {
+	parts := make([]string, len(k))
+	for i, v := range k {
+		parts[i] = canonicalValue(v)
+	}
+	return strings.Join(parts, "\x1f")
+}
+
+// canonicalValue renders a driver value so that equal keys read through
+// different drivers (int64, []byte, string) compare equal
+func canonicalValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "\x00"
+	case []byte:
+		return string(val)
+	case string:
+		return val
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// normalizeValue converts driver specific values into stable Go values
+func normalizeValue(v any) any {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+// RowSet is an insertion ordered set of row keys belonging to one table
+type RowSet struct {
+	index map[string]struct{}
+	keys  []Key
+}
+
+func newRowSet() *RowSet {
+	return &RowSet{index: make(map[string]struct{})}
+}
+
+// Add inserts a key into the set and reports whether it was not already present
+func (r *RowSet) Add(k Key) bool {
+	id := k.String()
+	if _, ok := r.index[id]; ok {
+		return false
+	}
+	r.index[id] = struct{}{}
+	r.keys = append(r.keys, k)
+	return true
+}
+
+// Contains reports whether the key is part of the set
+func (r *RowSet) Contains(k Key) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.index[k.String()]
+	return ok
+}
+
+// Keys returns the keys of the set in the order they were added
+func (r *RowSet) Keys() []Key {
+	if r == nil {
+		return nil
+	}
+	return r.keys
+}
+
+// Len returns the number of keys in the set
+func (r *RowSet) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.keys)
+}
+
+// Subset is the closed set of rows, per table, reached by a traversal
+type Subset struct {
+	rows     map[string]*RowSet
+	depths   map[string]int
+	origins  map[string]map[string]Origin // by table and key, when provenance is recorded
+	Edges    []EdgeUse
+	Cuts     []Cut
+	Warnings []string
+}
+
+// EdgeUse records the rows a relationship pulled into the subset, from the
+// referencing rows to their parents or from the referenced rows to their children
+type EdgeUse struct {
+	Edge      string `json:"edge"`
+	Direction string `json:"direction"`
+	Table     string `json:"table"`
+	Rows      int    `json:"rows"`
+}
+
+func (u EdgeUse) String() string {
+	return fmt.Sprintf("%s: %d rows of %s pulled as %s", u.Edge, u.Rows, u.Table, u.Direction)
+}
+
+// Cut records rows of a table that a relationship did not pull because a
+// depth limit or row budget was reached
+type Cut struct {
+	Edge   string `json:"edge"`
+	Table  string `json:"table"`
+	Reason string `json:"reason"`
+	Rows   int    `json:"rows"`
+}
+
+func (c Cut) String() string {
+	return fmt.Sprintf("%s: %d rows of %s left out, %s", c.Edge, c.Rows, c.Table, c.Reason)
+}
+
+func newSubset() *Subset {
+	return &Subset{rows: make(map[string]*RowSet), depths: make(map[string]int)}
+}
+
+// Rows returns the row set of a table, or nil if no row of the table was reached
+func (s *Subset) Rows(table string) *RowSet {
+	return s.rows[table]
+}
+
+// Origin is the row, and the relationship from it, through which a traversal
+// first reached a row
+type Origin struct {
+	Edge      Edge
+	Direction string // parents or children, as in EdgeUse
+	Table     string
+	Key       Key
+}
+
+// Step is a row on the path from a seed to a row of the subset, with the
+// relationship followed to reach it; the first step is the seed
+type Step struct {
+	Table     string `json:"table"`
+	Key       Key    `json:"key"`
+	Edge      string `json:"edge,omitempty"`
+	Direction string `json:"direction,omitempty"`
+}
+
+// String renders the row as table#key, with composite key values joined by commas
+func (s Step) String() string {
+	values := make([]string, len(s.Key))
+	for i, v := range s.Key {
+		values[i] = fmt.Sprint(v)
+	}
+	return s.Table + "#" + strings.Join(values, ",")
+}
+
+// Via describes how the row was reached, as the relationship followed and
+// whether the row was pulled as a parent or a child, or as a seed
+func (s Step) Via() string {
+	switch {
+	case s.Edge == "":
+		return "seed"
+	case s.Direction == "parents":
+		return "parent via " + s.Edge
+	default:
+		return "child via " + s.Edge
+	}
+}
+
+// Recorded reports whether the subset holds the origin of its rows
+func (s *Subset) Recorded() bool {
+	return s.origins != nil
+}
+
+// Path returns the rows through which a row was first reached, from a seed
+// to the row itself. It returns nil when the row is not in the subset, and
+// only the row itself when its origin was not recorded.
+func (s *Subset) Path(table string, k Key) []Step {
+	if !s.Rows(table).Contains(k) {
+		return nil
+	}
+	path := []Step{{Table: table, Key: k}}
+	for len(path) <= s.Count() {
+		o, ok := s.origins[table][k.String()]
+		if !ok {
+			break
+		}
+		path[len(path)-1].Edge = o.Edge.String()
+		path[len(path)-1].Direction = o.Direction
+		table, k = o.Table, o.Key
+		path = append(path, Step{Table: table, Key: k})
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// Depth returns the largest distance in relationships from a seed at which
+// rows of a table were reached, or -1 if no row of the table was reached
+func (s *Subset) Depth(table string) int {
+	if d, ok := s.depths[table]; ok {
+		return d
+	}
+	return -1
+}
+
+// Tables returns the names of the tables with at least one reached row, sorted
+func (s *Subset) Tables() []string {
+	tables := make([]string, 0, len(s.rows))
+	for name, rs := range s.rows {
+		if rs.Len() > 0 {
+			tables = append(tables, name)
+		}
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// Count returns the total number of rows in the subset
+func (s *Subset) Count() int {
+	total := 0
+	for _, rs := range s.rows {
+		total += rs.Len()
+	}
+	return total
+}
+
+func (s *Subset) rowSet(table string) *RowSet {
+	rs, ok := s.rows[table]
+	if !ok {
+		rs = newRowSet()
+		s.rows[table] = rs
+	}
+	return rs
+}
+
+func (s *Subset) warnf(format string, args ...any) {
+	s.Warnings = append(s.Warnings, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,653 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+// traversal holds the state of a single closure computation
+type traversal struct {
+	engine     *Engine
+	policy     *Policy
+	dependents bool // only rows referencing reached rows are pulled, never the referenced ones
+	subset     *Subset
+	down       map[string]*RowSet // rows reached from the root side, whose children are pulled
+	pending    map[level]*pendingRows
+	queue      []level
+	skipped    map[string]bool
+	cuts       map[Cut]int // rows left out per edge and reason
+	order      []Cut
+	uses       map[EdgeUse]int // rows pulled per edge and direction
+	used       []EdgeUse
+}
+
+// level identifies the rows of a table reached at the same depth
+type level struct {
+	table string
+	depth int
+}
+
+// pendingRows are the rows of a table waiting to be expanded
+type pendingRows struct {
+	added []Key // new rows, whose parents are pulled
+	down  []Key // rows newly reached from the root side, whose children are pulled
+}
+
+// Traverse computes the closure of rows related to the seed rows. The rows
+// referenced by a selected row are always pulled, so the subset stays
+// consistent; the rows referencing it are pulled only when it was reached
+// from the root side, that is from a seed through referencing rows, unless
+// the policy says otherwise for the relationship. A nil policy follows every
+// relationship of every table by default.
+//
+// Rows are expanded breadth first, so the depth of a row is its distance in
+// relationships from the nearest seed. Depth limits and row budgets stop the
+// expansion to referencing rows, never the pulling of referenced rows, and
+// every cut is recorded in the subset.
+func (e *Engine) Traverse(ctx context.Context, policy *Policy, seeds ...models.Seed) (*Subset, error) {
+	return e.traverse(ctx, policy, false, seeds)
+}
+
+// Dependents computes the seed rows together with every row depending on
+// them, that is referencing them directly or through other dependent rows.
+// Referenced rows are never pulled, so leaving the result out of a database
+// keeps it consistent. Only the scope of the policy applies: its rules,
+// boundaries, depth limits and row budgets would leave dependent rows in
+// place, referencing rows that are left out.
+func (e *Engine) Dependents(ctx context.Context, policy *Policy, seeds ...models.Seed) (*Subset, error) {
+	if policy != nil {
+		policy = &Policy{Scope: policy.Scope}
+	}
+	return e.traverse(ctx, policy, true, seeds)
+}
+
+func (e *Engine) traverse(ctx context.Context, policy *Policy, dependents bool, seeds []models.Seed) (*Subset, error) {
+	t := &traversal{
+		engine:     e,
+		policy:     policy,
+		dependents: dependents,
+		subset:     newSubset(),
+		down:       make(map[string]*RowSet),
+		pending:    make(map[level]*pendingRows),
+		skipped:    make(map[string]bool),
+		cuts:       make(map[Cut]int),
+		uses:       make(map[EdgeUse]int),
+	}
+	if e.origins {
+		t.subset.origins = make(map[string]map[string]Origin)
+	}
+
+	for _, seed := range seeds {
+		keys, err := e.seedKeys(ctx, seed)
+		if err != nil {
+			return nil, fmt.Errorf("seed %s: %w", seed, err)
+		}
+		if len(keys) == 0 {
+			t.subset.warnf("seed %s matches no rows", seed)
+		}
+		t.add(level{seed.Table, 0}, keys, true, nil)
+	}
+	if t.subset.Count() == 0 {
+		return nil, fmt.Errorf("%w: no seed matches a row", ErrRootNotFound)
+	}
+
+	if err := t.run(ctx); err != nil {
+		return nil, err
+	}
+	for _, c := range t.order {
+		c.Rows = t.cuts[c]
+		t.subset.Cuts = append(t.subset.Cuts, c)
+		t.subset.warnf("%s", c)
+	}
+	for _, u := range t.used {
+		u.Rows = t.uses[u]
+		t.subset.Edges = append(t.subset.Edges, u)
+	}
+	return t.subset, nil
+}
+
+// seedKeys returns the primary keys of the rows a seed selects
+func (e *Engine) seedKeys(ctx context.Context, seed models.Seed) ([]Key, error) {
+	pk, err := e.primaryKey(seed.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	if seed.Where != "" {
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE (%s)",
+			e.quoteColumns(pk), e.dialect.QuoteIdentifier(seed.Table), seed.Where)
+		return e.scanKeys(ctx, query, len(pk), nil)
+	}
+
+	var found []Key
+	if len(seed.Keys) > 0 {
+		if len(pk) != 1 {
+			return nil, fmt.Errorf("primary key of %s has %d columns, give its keys as column=value pairs", seed.Table, len(pk))
+		}
+		wanted := make([]Key, len(seed.Keys))
+		for i, k := range seed.Keys {
+			wanted[i] = Key{k}
+		}
+		keys, err := e.lookup(ctx, seed.Table, pk, pk, wanted, condition{})
+		if err != nil {
+			return nil, err
+		}
+		if missing := missingKeys(wanted, keys); len(missing) > 0 {
+			return nil, fmt.Errorf("%w: %v", ErrRootNotFound, missing)
+		}
+		found = append(found, keys...)
+	}
+
+	// Rows are matched by the columns they name, grouped so that rows naming
+	// the same columns share a query
+	groups := make(map[string][]map[string]string)
+	var order []string
+	for _, row := range seed.Rows {
+		id := strings.Join(slices.Sorted(maps.Keys(row)), "\x1f")
+		if _, ok := groups[id]; !ok {
+			order = append(order, id)
+		}
+		groups[id] = append(groups[id], row)
+	}
+	for _, id := range order {
+		keys, err := e.matchRows(ctx, seed.Table, pk, groups[id])
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, keys...)
+	}
+	return found, nil
+}
+
+// matchRows returns the primary keys of the rows of table whose columns
+// equal one of the column/value maps, which all name the same columns
+func (e *Engine) matchRows(ctx context.Context, table string, pk []string, rows []map[string]string) ([]Key, error) {
+	tbl := e.schema.Table(table)
+	columns := slices.Sorted(maps.Keys(rows[0]))
+	for _, c := range columns {
+		if tbl.Column(c) == nil {
+			return nil, fmt.Errorf("unknown column %s.%s", table, c)
+		}
+	}
+
+	wanted := make([]Key, len(rows))
+	for i, row := range rows {
+		wanted[i] = make(Key, len(columns))
+		for j, c := range columns {
+			wanted[i][j] = row[c]
+		}
+	}
+
+	// Select the matched columns as well to tell which rows were not found
+	found, err := e.query(ctx, table, append(slices.Clone(columns), pk...), columns, wanted, nil, condition{})
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]Key, len(found))
+	keys := make([]Key, len(found))
+	for i, k := range found {
+		matched[i] = k[:len(columns)]
+		keys[i] = k[len(columns):]
+	}
+	if missing := missingKeys(wanted, matched); len(missing) > 0 {
+		rendered := make([]string, len(missing))
+		for i, k := range missing {
+			values := make([]string, len(k))
+			for j, v := range k {
+				values[j] = fmt.Sprint(v)
+			}
+			rendered[i] = models.FormatKeyMap(columns, values)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrRootNotFound, strings.Join(rendered, ", "))
+	}
+	return keys, nil
+}
+
+// add records keys of a table and schedules them for expansion; down marks
+// rows reached from the root side and via holds the origins of the keys by
+// their string form. It returns the number of keys new to the subset.
+func (t *traversal) add(at level, keys []Key, down bool, via map[string]Origin) int {
+	rs := t.subset.rowSet(at.table)
+	p := t.pending[at]
+	if p == nil {
+		p = &pendingRows{}
+	}
+	added := 0
+	for _, k := range keys {
+		if rs.Add(k) {
+			p.added = append(p.added, k)
+			added++
+			if o, ok := via[k.String()]; ok {
+				t.origin(at.table, k, o)
+			}
+		}
+		if !down {
+			continue
+		}
+		if t.down[at.table] == nil {
+			t.down[at.table] = newRowSet()
+		}
+		if t.down[at.table].Add(k) {
+			p.down = append(p.down, k)
+		}
+	}
+	if t.pending[at] == nil && (len(p.added) > 0 || len(p.down) > 0) {
+		t.pending[at] = p
+		t.queue = append(t.queue, at)
+	}
+	if d, ok := t.subset.depths[at.table]; added > 0 && (!ok || at.depth > d) {
+		t.subset.depths[at.table] = at.depth
+	}
+	return added
+}
+
+// origin records the row a key of a table was first reached through
+func (t *traversal) origin(table string, k Key, o Origin) {
+	origins := t.subset.origins[table]
+	if origins == nil {
+		origins = make(map[string]Origin)
+		t.subset.origins[table] = origins
+	}
+	origins[k.String()] = o
+}
+
+// use records rows an edge pulled into the subset
+func (t *traversal) use(edge Edge, direction, table string, rows int) {
+	if rows == 0 {
+		return
+	}
+	u := EdgeUse{Edge: edge.String(), Direction: direction, Table: table}
+	if _, ok := t.uses[u]; !ok {
+		t.used = append(t.used, u)
+	}
+	t.uses[u] += rows
+}
+
+// cut records rows an edge did not pull because of a limit
+func (t *traversal) cut(edge Edge, reason string, rows int) {
+	c := Cut{Edge: edge.String(), Table: edge.Child, Reason: reason}
+	if _, ok := t.cuts[c]; !ok {
+		t.order = append(t.order, c)
+	}
+	t.cuts[c] += rows
+}
+
+// run expands pending rows until the closure is complete
+func (t *traversal) run(ctx context.Context) error {
+	for len(t.queue) > 0 {
+		at := t.queue[0]
+		t.queue = t.queue[1:]
+
+		p := t.pending[at]
+		delete(t.pending, at)
+		if err := t.expand(ctx, at, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expand follows the edges touching a table for its pending rows
+func (t *traversal) expand(ctx context.Context, at level, p *pendingRows) error {
+	e := t.engine
+	table := at.table
+	next := level{depth: at.depth + 1}
+	pk, err := e.primaryKey(table)
+	if err != nil {
+		return err
+	}
+
+	for _, edge := range e.graph.Parents(table) {
+		if t.dependents || len(p.added) == 0 || !t.supported(edge) || t.policy.Rule(edge) == models.FollowNone {
+			continue
+		}
+
+		refs, sources, err := t.references(ctx, table, pk, edge.ChildColumns, p.added, edge.condition())
+		if err != nil {
+			return fmt.Errorf("following %s: %w", edge, err)
+		}
+		parents, matched, err := t.resolve(ctx, edge.Parent, edge.ParentColumns, refs, condition{}, !edge.Virtual)
+		if err != nil {
+			return fmt.Errorf("following %s: %w", edge, err)
+		}
+		next.table = edge.Parent
+		via := origins(Origin{Edge: edge, Direction: "parents", Table: table}, sources, refs, parents, matched)
+		t.use(edge, "parents", edge.Parent, t.add(next, parents, false, via))
+	}
+
+	for _, edge := range e.graph.Children(table) {
+		if t.policy.Boundary(table) {
+			break
+		}
+		var keys []Key
+		switch t.policy.Rule(edge) {
+		case models.FollowDefault:
+			keys = p.down
+		case models.FollowChildren:
+			keys = p.added
+		}
+		if len(keys) == 0 || !t.supported(edge) {
+			continue
+		}
+
+		refs, sources := keys, keys
+		if !slices.Equal(edge.ParentColumns, pk) {
+			refs, sources, err = t.references(ctx, table, pk, edge.ParentColumns, keys, condition{})
+			if err != nil {
+				return fmt.Errorf("following %s: %w", edge, err)
+			}
+		}
+		children, matched, err := t.resolve(ctx, edge.Child, edge.ChildColumns, refs, edge.condition(), false)
+		if err != nil {
+			return fmt.Errorf("following %s: %w", edge, err)
+		}
+		next.table = edge.Child
+		via := origins(Origin{Edge: edge, Direction: "children", Table: table}, sources, refs, children, matched)
+		t.use(edge, "children", edge.Child, t.add(next, t.limit(edge, at.depth, children), true, via))
+	}
+
+	return nil
+}
+
+// limit returns the children an edge may pull from rows at depth, recording
+// the new rows left out by depth limits and row budgets
+func (t *traversal) limit(edge Edge, depth int, children []Key) []Key {
+	rs := t.subset.Rows(edge.Child)
+	seen := newRowSet()
+	for _, k := range children {
+		if !rs.Contains(k) {
+			seen.Add(k)
+		}
+	}
+	if seen.Len() == 0 {
+		return children
+	}
+
+	if d, ok := t.policy.MaxDepth(edge); ok && depth >= d {
+		t.cut(edge, fmt.Sprintf("max depth %d reached", d), seen.Len())
+		return nil
+	}
+
+	remaining, reason := t.policy.Budget(edge.Child, rs.Len(), t.subset.Count())
+	if remaining < 0 || seen.Len() <= remaining {
+		return children
+	}
+	kept := make([]Key, 0, len(children))
+	taken := newRowSet()
+	for _, k := range children {
+		if rs.Contains(k) {
+			kept = append(kept, k)
+		} else if taken.Len() < remaining && taken.Add(k) {
+			kept = append(kept, k)
+		}
+	}
+	t.cut(edge, reason, seen.Len()-taken.Len())
+	return kept
+}
+
+// references returns the distinct values of columns for the rows of table
+// whose primary key is one of keys. When origins are recorded, it also
+// returns, for every value, the key of the first row holding it.
+func (t *traversal) references(ctx context.Context, table string, pk, columns []string, keys []Key, cond condition) ([]Key, []Key, error) {
+	if t.subset.origins == nil {
+		refs, err := t.engine.columnValues(ctx, table, pk, columns, keys, cond)
+		return refs, nil, err
+	}
+
+	rows, err := t.engine.query(ctx, table, append(slices.Clone(columns), pk...), pk, keys, columns, cond)
+	if err != nil {
+		return nil, nil, err
+	}
+	var refs, sources []Key
+	seen := newRowSet()
+	for _, row := range rows {
+		if seen.Add(row[:len(columns)]) {
+			refs = append(refs, row[:len(columns)])
+			sources = append(sources, row[len(columns):])
+		}
+	}
+	return refs, sources, nil
+}
+
+// resolve returns the primary keys of the rows of table whose columns match
+// one of the tuples and that satisfy the condition. Tuples of the primary key
+// are taken as they are when they are known to exist. When origins are
+// recorded, it also returns the tuple each row matched.
+func (t *traversal) resolve(ctx context.Context, table string, columns []string, tuples []Key, cond condition, exist bool) ([]Key, []Key, error) {
+	if len(tuples) == 0 {
+		return nil, nil, nil
+	}
+	pk, err := t.engine.primaryKey(table)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exist && slices.Equal(pk, columns) && cond.column == "" {
+		return tuples, tuples, nil
+	}
+	if t.subset.origins == nil {
+		keys, err := t.engine.lookup(ctx, table, pk, columns, tuples, cond)
+		return keys, nil, err
+	}
+
+	rows, err := t.engine.query(ctx, table, append(slices.Clone(pk), columns...), columns, tuples, nil, cond)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := make([]Key, len(rows))
+	matched := make([]Key, len(rows))
+	for i, row := range rows {
+		keys[i], matched[i] = row[:len(pk)], row[len(pk):]
+	}
+	return keys, matched, nil
+}
+
+// origins returns the origin of every key reached through an edge, by the
+// key string, given the source row of every referenced tuple and the tuple
+// every key matched. It returns nil when origins are not recorded.
+func origins(from Origin, sources, refs, keys, matched []Key) map[string]Origin {
+	if sources == nil || matched == nil {
+		return nil
+	}
+	source := make(map[string]Key, len(refs))
+	for i, ref := range refs {
+		if _, ok := source[ref.String()]; !ok {
+			source[ref.String()] = sources[i]
+		}
+	}
+	via := make(map[string]Origin, len(keys))
+	for i, k := range keys {
+		if src, ok := source[matched[i].String()]; ok {
+			o := from
+			o.Key = src
+			via[k.String()] = o
+		}
+	}
+	return via
+}
+
+// supported reports whether the edge can be followed, warning once per table otherwise
+func (t *traversal) supported(edge Edge) bool {
+	if !t.policy.Contains(edge.Child) || !t.policy.Contains(edge.Parent) {
+		return false
+	}
+	if len(edge.ChildColumns) != len(edge.ParentColumns) {
+		t.skip(edge.String(), "the referencing and referenced columns differ in number")
+		return false
+	}
+	for _, table := range []string{edge.Child, edge.Parent} {
+		if _, err := t.engine.primaryKey(table); err != nil {
+			t.skip(table, err.Error())
+			return false
+		}
+	}
+	return true
+}
+
+func (t *traversal) skip(subject, reason string) {
+	if t.skipped[subject] {
+		return
+	}
+	t.skipped[subject] = true
+	t.subset.warnf("%s: %s", subject, reason)
+}
+
+// primaryKey returns the primary key columns of a table
+func (e *Engine) primaryKey(table string) ([]string, error) {
+	tbl := e.schema.Table(table)
+	if tbl == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTable, table)
+	}
+	if len(tbl.PrimaryKey) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoPrimaryKey, table)
+	}
+	return tbl.PrimaryKey, nil
+}
+
+// columnValues returns the distinct values of columns for the rows of table
+// whose primary key is one of keys. Tuples holding a NULL are skipped, as a
+// foreign key with a NULL column references nothing.
+func (e *Engine) columnValues(ctx context.Context, table string, pk, columns []string, keys []Key, cond condition) ([]Key, error) {
+	return e.query(ctx, table, columns, pk, keys, columns, cond)
+}
+
+// lookup returns the primary keys of the rows of table whose columns match one of the tuples
+func (e *Engine) lookup(ctx context.Context, table string, pk, columns []string, tuples []Key, cond condition) ([]Key, error) {
+	return e.query(ctx, table, pk, columns, tuples, nil, cond)
+}
+
+// query selects distinct tuples of columns from table where the match
+// columns equal one of tuples, batching the IN list and skipping rows where
+// one of the notNull columns is NULL or the condition does not hold
+func (e *Engine) query(ctx context.Context, table string, columns, match []string, tuples []Key, notNull []string, cond condition) ([]Key, error) {
+	var result []Key
+
+	batchSize := max(1, e.batchSize/len(match))
+	for start := 0; start < len(tuples); start += batchSize {
+		batch := tuples[start:min(start+batchSize, len(tuples))]
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "SELECT DISTINCT %s FROM %s WHERE %s",
+			e.quoteColumns(columns),
+			e.dialect.QuoteIdentifier(table),
+			inPredicate(e.dialect, match, len(batch)))
+		for _, c := range notNull {
+			fmt.Fprintf(&b, " AND %s IS NOT NULL", columnExpr(e.dialect, c))
+		}
+		args := flatten(batch)
+		if cond.column != "" {
+			args = append(args, cond.value)
+			fmt.Fprintf(&b, " AND %s = %s", e.dialect.QuoteIdentifier(cond.column), e.dialect.Placeholder(len(args)))
+		}
+
+		keys, err := e.scanKeys(ctx, b.String(), len(columns), args)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, keys...)
+	}
+
+	return result, nil
+}
+
+// quoteColumns renders a comma separated list of quoted column names
+func (e *Engine) quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = columnExpr(e.dialect, c)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// columnExpr quotes a column name, or renders the value at the path of a
+// JSON column given as column->path
+func columnExpr(d Dialect, column string) string {
+	if col, path, ok := strings.Cut(column, models.JSONPathSeparator); ok {
+		return d.JSONValue(d.QuoteIdentifier(col), path)
+	}
+	return d.QuoteIdentifier(column)
+}
+
+// inPredicate renders the condition matching columns against n tuples of
+// bind parameters, numbered from 1. Composite keys are compared as row
+// values, (a, b) IN ((?, ?), ...), which MySQL, PostgreSQL and SQLite accept.
+func inPredicate(d Dialect, columns []string, n int) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = columnExpr(d, c)
+	}
+
+	param := 1
+	tuples := make([]string, n)
+	for i := range tuples {
+		marks := make([]string, len(columns))
+		for j := range marks {
+			marks[j] = d.Placeholder(param)
+			param++
+		}
+		tuples[i] = strings.Join(marks, ", ")
+		if len(columns) > 1 {
+			tuples[i] = "(" + tuples[i] + ")"
+		}
+	}
+
+	lhs := quoted[0]
+	if len(columns) > 1 {
+		lhs = "(" + strings.Join(quoted, ", ") + ")"
+	}
+	return lhs + " IN (" + strings.Join(tuples, ", ") + ")"
+}
+
+// scanKeys runs a query and collects every row as a key
+func (e *Engine) scanKeys(ctx context.Context, query string, width int, args []any) ([]Key, error) {
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []Key
+	for rows.Next() {
+		dest := make([]any, width)
+		ptrs := make([]any, width)
+		for i := range dest {
+			ptrs[i] = &dest[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i := range dest {
+			dest[i] = normalizeValue(dest[i])
+		}
+		keys = append(keys, Key(dest))
+	}
+	return keys, rows.Err()
+}
+
+// missingKeys returns the wanted keys that are not among the found ones
+func missingKeys(wanted, found []Key) []Key {
+	seen := make(map[string]bool, len(found))
+	for _, k := range found {
+		seen[k.String()] = true
+	}
+	var missing []Key
+	for _, k := range wanted {
+		if !seen[k.String()] {
+			missing = append(missing, k)
+		}
+	}
+	return missing
+}
+
+// flatten returns the values of every key, one key after the other
+func flatten(keys []Key) []any {
+	var values []any
+	for _, k := range keys {
+		values = append(values, k...)
+	}
+	return values
+}
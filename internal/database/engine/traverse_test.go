@@ -0,0 +1,345 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/antoniosarro/reltrace/internal/database/models"
+)
+
+func TestTraverse(t *testing.T) {
+	sess, _ := openFixture(t)
+	tests := []struct {
+		name  string
+		seeds []models.Seed
+		want  map[string][]string
+	}{
+		{
+			name:  "children from the seed, parents of every row",
+			seeds: []models.Seed{{Table: "positions", Keys: []string{"8"}}},
+			want: map[string][]string{
+				"positions":          {"8"},
+				"employee_positions": {"10"},
+				"employees":          {"10"},
+				"companies":          {"1"},
+				"locations":          {"1"},
+			},
+		},
+		{
+			name:  "children of children",
+			seeds: []models.Seed{{Table: "companies", Keys: []string{"5"}}},
+			want: map[string][]string{
+				"companies": {"5"},
+				"locations": {"7"},
+			},
+		},
+		{
+			name:  "parents are not expanded to their children",
+			seeds: []models.Seed{{Table: "employees", Keys: []string{"3"}}},
+			want: map[string][]string{
+				"employees":           {"1", "2", "3"},
+				"employee_positions":  {"3"},
+				"positions":           {"1"},
+				"project_assignments": {"4"},
+				"projects":            {"1", "2"},
+				"expenses":            {"1"},
+				"expense_categories":  {"1", "2"},
+				"departments":         {"1", "2"},
+				"locations":           {"1"},
+				"companies":           {"1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subset, err := sess.Engine.Traverse(context.Background(), nil, tt.seeds...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(subset); !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraverseUnknownRoot(t *testing.T) {
+	sess, _ := openFixture(t)
+	_, err := sess.Engine.Traverse(context.Background(), nil, models.Seed{Table: "employees", Keys: []string{"99"}})
+	if !errors.Is(err, ErrRootNotFound) {
+		t.Fatalf("err = %v, want %v", err, ErrRootNotFound)
+	}
+}
+
+func TestDependents(t *testing.T) {
+	sess, _ := openFixture(t)
+	tests := []struct {
+		name  string
+		seeds []models.Seed
+		want  map[string][]string
+	}{
+		{
+			name:  "referencing rows only",
+			seeds: []models.Seed{{Table: "employees", Keys: []string{"3"}}},
+			want: map[string][]string{
+				"employees":           {"3"},
+				"employee_positions":  {"3"},
+				"project_assignments": {"4"},
+				"expenses":            {"1"},
+			},
+		},
+		{
+			name:  "rows depending on dependents",
+			seeds: []models.Seed{{Table: "companies", Keys: []string{"3"}}},
+			want: map[string][]string{
+				"companies":          {"3"},
+				"locations":          {"5"},
+				"departments":        {"9"},
+				"employees":          {"9"},
+				"employee_positions": {"9"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subset, err := sess.Engine.Dependents(context.Background(), nil, tt.seeds...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(subset); !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraverseSeeds(t *testing.T) {
+	sess, _ := openFixture(t)
+	tests := []struct {
+		name     string
+		seeds    []models.Seed
+		want     map[string][]string
+		warnings int
+	}{
+		{
+			name: "union of several seeds",
+			seeds: []models.Seed{
+				{Table: "positions", Keys: []string{"8"}},
+				{Table: "companies", Keys: []string{"5"}},
+			},
+			want: map[string][]string{
+				"positions":          {"8"},
+				"employee_positions": {"10"},
+				"employees":          {"10"},
+				"companies":          {"1", "5"},
+				"locations":          {"1", "7"},
+			},
+		},
+		{
+			name:  "predicate seed",
+			seeds: []models.Seed{{Table: "companies", Where: "industry IN ('Software', 'Cloud Computing')"}},
+			want: map[string][]string{
+				"companies": {"4", "5"},
+				"locations": {"6", "7"},
+			},
+		},
+		{
+			name: "seed matching no rows",
+			seeds: []models.Seed{
+				{Table: "companies", Keys: []string{"5"}},
+				{Table: "companies", Where: "industry = 'Mining'"},
+			},
+			want: map[string][]string{
+				"companies": {"5"},
+				"locations": {"7"},
+			},
+			warnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subset, err := sess.Engine.Traverse(context.Background(), nil, tt.seeds...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(subset); !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+			if len(subset.Warnings) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", subset.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestTraversePolymorphic(t *testing.T) {
+	sess, _ := openFixture(t)
+	relation, err := models.ParsePolymorphicRelation("activity_log.entity_type,entity_id: employees=employees,projects=projects,contracts=contracts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.AddRelations(models.DumpConfig{Polymorphic: []models.PolymorphicRelation{relation}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every log row has entity_id 1 or 3: only the rows of the matching
+	// entity type depend on a row
+	tests := []struct {
+		name       string
+		seed       models.Seed
+		dependents bool
+		want       map[string][]string
+	}{
+		{
+			name: "log rows of the seed type only",
+			seed: models.Seed{Table: "contracts", Keys: []string{"1"}},
+			want: map[string][]string{
+				"contracts":    {"1"},
+				"activity_log": {"3"},
+				"clients":      {"1"},
+				"companies":    {"1"},
+				"projects":     {"1"},
+				"departments":  {"1", "7"},
+				"employees":    {"1", "7"},
+				"locations":    {"1"},
+			},
+		},
+		{
+			name: "the entity of a log row is its parent",
+			seed: models.Seed{Table: "activity_log", Keys: []string{"3"}},
+			want: map[string][]string{
+				"activity_log": {"3"},
+				"contracts":    {"1"},
+				"clients":      {"1"},
+				"companies":    {"1"},
+				"projects":     {"1"},
+				"departments":  {"1", "7"},
+				"employees":    {"1", "7"},
+				"locations":    {"1"},
+			},
+		},
+		{
+			name:       "dependents through several entity types",
+			seed:       models.Seed{Table: "projects", Keys: []string{"1"}},
+			dependents: true,
+			want: map[string][]string{
+				"projects":            {"1", "2", "3"},
+				"contracts":           {"1", "2"},
+				"activity_log":        {"2", "3"},
+				"expenses":            {"1", "2", "3", "4"},
+				"project_assignments": {"1", "2", "3", "4", "5", "6"},
+			},
+		},
+		{
+			name:       "dependents of an employee",
+			seed:       models.Seed{Table: "employees", Keys: []string{"3"}},
+			dependents: true,
+			want: map[string][]string{
+				"employees":           {"3"},
+				"activity_log":        {"1"},
+				"employee_positions":  {"3"},
+				"project_assignments": {"4"},
+				"expenses":            {"1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traverse := sess.Engine.Traverse
+			if tt.dependents {
+				traverse = sess.Engine.Dependents
+			}
+			subset, err := traverse(context.Background(), nil, tt.seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(subset); !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraverseJSONForeignKey(t *testing.T) {
+	sess, _ := openFixture(t)
+	err := sess.AddRelations(models.DumpConfig{VirtualForeignKeys: []string{"activity_log.new_values->$.client_id -> clients.id"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the new values of log row 3 hold a client_id
+	tests := []struct {
+		name       string
+		seed       models.Seed
+		dependents bool
+		want       map[string][]string
+	}{
+		{
+			name: "log rows referencing the client",
+			seed: models.Seed{Table: "clients", Keys: []string{"1"}},
+			want: map[string][]string{
+				"clients":      {"1"},
+				"activity_log": {"3"},
+				"contracts":    {"1"},
+				"companies":    {"1"},
+				"projects":     {"1"},
+				"departments":  {"1", "7"},
+				"employees":    {"1", "7"},
+				"locations":    {"1"},
+			},
+		},
+		{
+			name: "no log row references the client",
+			seed: models.Seed{Table: "clients", Keys: []string{"2"}},
+			want: map[string][]string{
+				"clients":     {"2"},
+				"contracts":   {"2"},
+				"companies":   {"1"},
+				"projects":    {"1", "2"},
+				"departments": {"1", "2", "7"},
+				"employees":   {"1", "2", "7"},
+				"locations":   {"1"},
+			},
+		},
+		{
+			name: "the client in the log row is its parent",
+			seed: models.Seed{Table: "activity_log", Keys: []string{"3"}},
+			want: map[string][]string{
+				"activity_log": {"3"},
+				"clients":      {"1"},
+				"employees":    {"7"},
+				"departments":  {"7"},
+				"companies":    {"1"},
+				"locations":    {"1"},
+			},
+		},
+		{
+			name:       "log rows depend on the client",
+			seed:       models.Seed{Table: "clients", Keys: []string{"1"}},
+			dependents: true,
+			want: map[string][]string{
+				"clients":      {"1"},
+				"activity_log": {"3"},
+				"contracts":    {"1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traverse := sess.Engine.Traverse
+			if tt.dependents {
+				traverse = sess.Engine.Dependents
+			}
+			subset, err := traverse(context.Background(), nil, tt.seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(subset); !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
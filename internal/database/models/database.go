@@ -1,5 +1,7 @@
 package models
 
+import "fmt"
+
 // DatabaseType represents the type of database
 type DatabaseType string
 
@@ -9,13 +11,42 @@ const (
 	SQLite3    DatabaseType = "sqlite3"
 )
 
+// ParseDatabaseType returns the database type matching its name
+func ParseDatabaseType(s string) (DatabaseType, error) {
+	switch DatabaseType(s) {
+	case MySQL, PostgreSQL, SQLite3:
+		return DatabaseType(s), nil
+	default:
+		return "", fmt.Errorf("unknown database type %q (expected mysql, postgresql or sqlite3)", s)
+	}
+}
+
+// DefaultPort returns the port used when none is configured
+func (t DatabaseType) DefaultPort() string {
+	switch t {
+	case MySQL:
+		return "3306"
+	case PostgreSQL:
+		return "5432"
+	default:
+		return ""
+	}
+}
+
+// SharesSnapshot reports whether other connections can read inside the
+// transaction snapshot of a dump, as parallel readers need. Only PostgreSQL
+// exports its snapshots.
+func (t DatabaseType) SharesSnapshot() bool {
+	return t == PostgreSQL
+}
+
 // DatabaseConfig holds database connection configuration
 type DatabaseConfig struct {
-	Type     DatabaseType `json:"type"`
-	Host     string       `json:"host,omitempty"`
-	Port     string       `json:"port,omitempty"`
-	User     string       `json:"user,omitempty"`
-	Password string       `json:"password,omitempty"`
-	Database string       `json:"database,omitempty"`
-	FilePath string       `json:"file_path,omitempty"` // For SQLite3
+	Type     DatabaseType `json:"type" yaml:"type"`
+	Host     string       `json:"host,omitempty" yaml:"host,omitempty"`
+	Port     string       `json:"port,omitempty" yaml:"port,omitempty"`
+	User     string       `json:"user,omitempty" yaml:"user,omitempty"`
+	Password string       `json:"password,omitempty" yaml:"password,omitempty"`
+	Database string       `json:"database,omitempty" yaml:"database,omitempty"`
+	FilePath string       `json:"file_path,omitempty" yaml:"file_path,omitempty"` // For SQLite3
 }
@@ -1,16 +1,178 @@
 package models
 
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
 // DumpConfig holds the configuration for a dump operation
 type DumpConfig struct {
-	SourceConfig   DatabaseConfig  `json:"source_config"`
-	Mode           DumpMode        `json:"mode"`
-	Target         DumpTarget      `json:"target"`
-	TargetConfig   *DatabaseConfig `json:"target_config,omitempty"` // For direct database imports
-	OutputPath     string          `json:"output_path,omitempty"`   // For file exports
-	RootTable      string          `json:"root_table,omitempty"`
-	RootPrimaryKey string          `json:"root_primary_key,omitempty"`
-	IncludeTables  []string        `json:"include_tables,omitempty"`
-	ExcludeTables  []string        `json:"exclude_tables,omitempty"`
+	SourceConfig       DatabaseConfig        `json:"source_config" yaml:"source_config"`
+	Mode               DumpMode              `json:"mode" yaml:"mode"`
+	Target             DumpTarget            `json:"target" yaml:"target"`
+	TargetConfig       *DatabaseConfig       `json:"target_config,omitempty" yaml:"target_config,omitempty"` // For direct database imports
+	OutputPath         string                `json:"output_path,omitempty" yaml:"output_path,omitempty"`     // For file exports
+	RootTable          string                `json:"root_table,omitempty" yaml:"root_table,omitempty"`
+	RootPrimaryKey     string                `json:"root_primary_key,omitempty" yaml:"root_primary_key,omitempty"`         // Comma separated for several roots
+	Seeds              []Seed                `json:"seeds,omitempty" yaml:"seeds,omitempty"`                               // Further roots, traversed together with the root table
+	IncludeTables      []string              `json:"include_tables,omitempty" yaml:"include_tables,omitempty"`             // Table names or glob patterns; empty includes every table
+	ExcludeTables      []string              `json:"exclude_tables,omitempty" yaml:"exclude_tables,omitempty"`             // Table names or glob patterns
+	BoundaryTables     []string              `json:"boundary_tables,omitempty" yaml:"boundary_tables,omitempty"`           // Tables whose referenced rows are dumped but never expanded
+	LookupTables       []string              `json:"lookup_tables,omitempty" yaml:"lookup_tables,omitempty"`               // Tables dumped in full and never traversed
+	Follow             map[string]FollowRule `json:"follow,omitempty" yaml:"follow,omitempty"`                             // Traversal rules by foreign key name or table.column
+	Polymorphic        []PolymorphicRelation `json:"polymorphic,omitempty" yaml:"polymorphic,omitempty"`                   // Type/id column pairs pointing at several tables
+	VirtualForeignKeys []string              `json:"virtual_foreign_keys,omitempty" yaml:"virtual_foreign_keys,omitempty"` // Undeclared relationships as child.column -> parent.column
+	MaxDepth           *int                  `json:"max_depth,omitempty" yaml:"max_depth,omitempty"`                       // Relationships followed from a seed to referencing rows, 0 stopping at the seeds; unset is unlimited
+	DepthLimits        map[string]int        `json:"depth_limits,omitempty" yaml:"depth_limits,omitempty"`                 // Max depth by foreign key name or table.column, read like MaxDepth
+	MaxRows            int                   `json:"max_rows,omitempty" yaml:"max_rows,omitempty"`                         // Row budget of the whole subset; 0 is unlimited
+	TableMaxRows       map[string]int        `json:"table_max_rows,omitempty" yaml:"table_max_rows,omitempty"`             // Row budgets by table name
+	Snapshot           SnapshotMode          `json:"snapshot,omitempty" yaml:"snapshot,omitempty"`                         // How reads of a live source are kept consistent; transaction when empty
+	Workers            int                   `json:"workers,omitempty" yaml:"workers,omitempty"`                           // Tables and key ranges read concurrently; 0 or 1 reads one at a time
+	ChunkRows          int                   `json:"chunk_rows,omitempty" yaml:"chunk_rows,omitempty"`                     // Rows above which a table is read in key range chunks; 0 uses the default
+	BufferRows         int                   `json:"buffer_rows,omitempty" yaml:"buffer_rows,omitempty"`                   // Rows a worker reads ahead of the writer; 0 uses the default
+}
+
+// KeyPairSeparator joins the column=value pairs of a key given by columns,
+// as in employee_id=3&position_id=2
+const KeyPairSeparator = "&"
+
+// RootKeys returns the keys of the root rows, as given in RootPrimaryKey
+func (c DumpConfig) RootKeys() []string {
+	var keys []string
+	for _, k := range strings.Split(c.RootPrimaryKey, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Seed selects rows a subset traversal starts from: rows of a table given
+// by primary key values, by column/value maps or by a SQL predicate
+type Seed struct {
+	Table string              `json:"table" yaml:"table"`
+	Keys  []string            `json:"keys,omitempty" yaml:"keys,omitempty"`   // Values of a single column primary key
+	Rows  []map[string]string `json:"rows,omitempty" yaml:"rows,omitempty"`   // Column/value maps of a composite primary key or a unique business key
+	Where string              `json:"where,omitempty" yaml:"where,omitempty"` // Predicate in the source dialect, e.g. industry = 'Technology'
+}
+
+// ParseSeed builds a seed from root keys, each either a single primary key
+// value or column=value pairs joined by KeyPairSeparator
+func ParseSeed(table string, keys []string) (Seed, error) {
+	seed := Seed{Table: table}
+	for _, k := range keys {
+		if !strings.Contains(k, "=") {
+			seed.Keys = append(seed.Keys, k)
+			continue
+		}
+		row, err := ParseKeyMap(k)
+		if err != nil {
+			return seed, err
+		}
+		seed.Rows = append(seed.Rows, row)
+	}
+	return seed, nil
+}
+
+// ParseKeyMap parses a key given as column=value pairs joined by KeyPairSeparator
+func ParseKeyMap(s string) (map[string]string, error) {
+	row := make(map[string]string)
+	for _, pair := range strings.Split(s, KeyPairSeparator) {
+		column, value, ok := strings.Cut(pair, "=")
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid key %q: expected column=value pairs joined by %s", s, KeyPairSeparator)
+		}
+		if _, dup := row[column]; dup {
+			return nil, fmt.Errorf("invalid key %q: column %s given twice", s, column)
+		}
+		row[column] = strings.TrimSpace(value)
+	}
+	return row, nil
+}
+
+// FormatKeyMap renders a key as column=value pairs, in the order of columns
+func FormatKeyMap(columns, values []string) string {
+	pairs := make([]string, len(columns))
+	for i, c := range columns {
+		pairs[i] = c + "=" + values[i]
+	}
+	return strings.Join(pairs, KeyPairSeparator)
+}
+
+func (s Seed) String() string {
+	if s.Where != "" {
+		return s.Table + " where " + s.Where
+	}
+	keys := slices.Clone(s.Keys)
+	for _, row := range s.Rows {
+		columns := slices.Sorted(maps.Keys(row))
+		values := make([]string, len(columns))
+		for i, c := range columns {
+			values[i] = row[c]
+		}
+		keys = append(keys, FormatKeyMap(columns, values))
+	}
+	return s.Table + " [" + strings.Join(keys, ", ") + "]"
+}
+
+// Validate reports whether the seed names a table and either keys or a predicate
+func (s Seed) Validate() error {
+	hasKeys := len(s.Keys) > 0 || len(s.Rows) > 0
+	hasWhere := strings.TrimSpace(s.Where) != ""
+	switch {
+	case s.Table == "":
+		return errors.New("seed without a table")
+	case !hasKeys && !hasWhere:
+		return fmt.Errorf("seed %s needs keys or a where predicate", s.Table)
+	case hasKeys && hasWhere:
+		return fmt.Errorf("seed %s has both keys and a where predicate", s.Table)
+	}
+	for _, row := range s.Rows {
+		if len(row) == 0 {
+			return fmt.Errorf("seed %s has an empty row key", s.Table)
+		}
+	}
+	return nil
+}
+
+// AllSeeds returns the seeds of a subset dump: the root table rows, if any,
+// followed by the other seeds
+func (c DumpConfig) AllSeeds() ([]Seed, error) {
+	var seeds []Seed
+	if keys := c.RootKeys(); c.RootTable != "" && len(keys) > 0 {
+		root, err := ParseSeed(c.RootTable, keys)
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, root)
+	}
+	return append(seeds, c.Seeds...), nil
+}
+
+// MatchTable reports whether a table name matches one of the patterns, which
+// are table names or globs such as audit_*
+func MatchTable(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTablePatterns returns an error for the first malformed glob pattern
+func ValidateTablePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid table pattern %q: %w", p, err)
+		}
+	}
+	return nil
 }
 
 // DumpMode defines the type of dump operation
@@ -38,6 +200,21 @@ func (d DumpMode) String() string {
 	}
 }
 
+// MarshalText renders the mode by name in job files
+func (d DumpMode) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a mode name from a job file
+func (d *DumpMode) UnmarshalText(text []byte) error {
+	mode, err := ParseDumpMode(string(text))
+	if err != nil {
+		return err
+	}
+	*d = mode
+	return nil
+}
+
 // DumpTarget defines where the output should go
 type DumpTarget int
 
@@ -56,3 +233,79 @@ func (d DumpTarget) String() string {
 		return "unknown"
 	}
 }
+
+// MarshalText renders the target by name in job files
+func (d DumpTarget) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a target name from a job file
+func (d *DumpTarget) UnmarshalText(text []byte) error {
+	target, err := ParseDumpTarget(string(text))
+	if err != nil {
+		return err
+	}
+	*d = target
+	return nil
+}
+
+// SnapshotMode is how the reads of a dump are kept consistent while the
+// source database is being written to
+type SnapshotMode string
+
+const (
+	// SnapshotTransaction reads inside a single transaction seeing the data as of its start
+	SnapshotTransaction SnapshotMode = "transaction"
+	// SnapshotLocks holds read locks on every table, for sources that cannot snapshot
+	SnapshotLocks SnapshotMode = "locks"
+	// SnapshotNone reads outside any transaction, for sources nobody writes to
+	SnapshotNone SnapshotMode = "none"
+)
+
+// OrDefault returns the mode, or SnapshotTransaction when it is not set
+func (m SnapshotMode) OrDefault() SnapshotMode {
+	if m == "" {
+		return SnapshotTransaction
+	}
+	return m
+}
+
+// UnmarshalText parses a snapshot mode from a job file
+func (m *SnapshotMode) UnmarshalText(text []byte) error {
+	mode, err := ParseSnapshotMode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
+// ParseSnapshotMode returns the snapshot mode matching its name
+func ParseSnapshotMode(s string) (SnapshotMode, error) {
+	switch m := SnapshotMode(s); m {
+	case SnapshotTransaction, SnapshotLocks, SnapshotNone:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown snapshot mode %q: expected transaction, locks or none", s)
+	}
+}
+
+// ParseDumpMode returns the dump mode matching its string representation
+func ParseDumpMode(s string) (DumpMode, error) {
+	for _, m := range []DumpMode{StructureOnly, StructureAndData, StructureAndDataExcluding, StructureAndDataIncludingOnly} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown dump mode %q", s)
+}
+
+// ParseDumpTarget returns the dump target matching its string representation
+func ParseDumpTarget(s string) (DumpTarget, error) {
+	for _, t := range []DumpTarget{ToFile, ToDatabase} {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown dump target %q", s)
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatchTable(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		name     string
+		want     bool
+	}{
+		{nil, "orders", false},
+		{[]string{"orders"}, "orders", true},
+		{[]string{"orders"}, "orders_archive", false},
+		{[]string{"audit_*"}, "audit_log", true},
+		{[]string{"audit_*"}, "audit", false},
+		{[]string{"tmp_?"}, "tmp_1", true},
+		{[]string{"tmp_?"}, "tmp_12", false},
+		{[]string{"[ab]*"}, "billing", true},
+		{[]string{"users", "audit_*"}, "audit_log", true},
+		{[]string{"["}, "[", false},
+	}
+	for _, tt := range tests {
+		if got := MatchTable(tt.patterns, tt.name); got != tt.want {
+			t.Errorf("MatchTable(%q, %s) = %t, want %t", tt.patterns, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseKeyMap(t *testing.T) {
+	tests := []struct {
+		key  string
+		want map[string]string
+		err  string
+	}{
+		{key: "id=3", want: map[string]string{"id": "3"}},
+		{key: "employee_id=3&position_id=2", want: map[string]string{"employee_id": "3", "position_id": "2"}},
+		{key: " order_id = 10 & line = 2 ", want: map[string]string{"order_id": "10", "line": "2"}},
+		{key: "code=a=b", want: map[string]string{"code": "a=b"}},
+		{key: "note=", want: map[string]string{"note": ""}},
+		{key: "3", err: "expected column=value pairs"},
+		{key: "id=3&", err: "expected column=value pairs"},
+		{key: "=3", err: "expected column=value pairs"},
+		{key: "id=3&id=4", err: "column id given twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := ParseKeyMap(tt.key)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("key = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeed(t *testing.T) {
+	seed, err := ParseSeed("order_items", []string{"7", "order_id=10&line=2", "8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(seed.Keys, []string{"7", "8"}) || len(seed.Rows) != 1 ||
+		!maps.Equal(seed.Rows[0], map[string]string{"order_id": "10", "line": "2"}) {
+		t.Errorf("seed = %+v", seed)
+	}
+	if got, want := seed.String(), "order_items [7, 8, line=2&order_id=10]"; got != want {
+		t.Errorf("seed renders as %s, want %s", got, want)
+	}
+	if _, err := ParseSeed("order_items", []string{"order_id=10&order_id=11"}); err == nil {
+		t.Error("seed with a column given twice was accepted")
+	}
+}
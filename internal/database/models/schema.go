@@ -0,0 +1,270 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Schema describes the tables of a database and the relationships between them
+type Schema struct {
+	Type   DatabaseType `json:"type,omitempty"`
+	Name   string       `json:"name,omitempty"`
+	Tables []Table      `json:"tables"`
+}
+
+// Table describes a single database table
+type Table struct {
+	Name        string             `json:"name"`
+	Comment     string             `json:"comment,omitempty"`
+	Columns     []Column           `json:"columns,omitempty"`
+	PrimaryKey  []string           `json:"primary_key,omitempty"`
+	ForeignKeys []ForeignKey       `json:"foreign_keys,omitempty"`
+	Uniques     []UniqueConstraint `json:"uniques,omitempty"`
+	Indexes     []Index            `json:"indexes,omitempty"`
+	Checks      []CheckConstraint  `json:"checks,omitempty"`
+}
+
+// Column describes a single table column
+type Column struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`                // Declared type, e.g. "varchar(255)"
+	DataType      string   `json:"data_type,omitempty"` // Base type without modifiers, lower case
+	Nullable      bool     `json:"nullable"`
+	Default       *string  `json:"default,omitempty"`   // SQL expression, nil when there is no default
+	OnUpdate      string   `json:"on_update,omitempty"` // MySQL ON UPDATE expression
+	AutoIncrement bool     `json:"auto_increment,omitempty"`
+	EnumValues    []string `json:"enum_values,omitempty"`
+	Comment       string   `json:"comment,omitempty"`
+}
+
+// ReferentialAction is the action taken on referencing rows when a referenced row changes
+type ReferentialAction string
+
+const (
+	NoAction   ReferentialAction = "NO ACTION"
+	Restrict   ReferentialAction = "RESTRICT"
+	Cascade    ReferentialAction = "CASCADE"
+	SetNull    ReferentialAction = "SET NULL"
+	SetDefault ReferentialAction = "SET DEFAULT"
+)
+
+// ParseReferentialAction normalizes an action as reported by a database catalog
+func ParseReferentialAction(s string) ReferentialAction {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "RESTRICT", "R":
+		return Restrict
+	case "CASCADE", "C":
+		return Cascade
+	case "SET NULL", "N":
+		return SetNull
+	case "SET DEFAULT", "D":
+		return SetDefault
+	default:
+		return NoAction
+	}
+}
+
+// ForeignKey describes a reference from columns of one table to columns of another
+type ForeignKey struct {
+	Name       string            `json:"name,omitempty"`
+	Table      string            `json:"table"`
+	Columns    []string          `json:"columns"`
+	RefTable   string            `json:"ref_table"`
+	RefColumns []string          `json:"ref_columns"`
+	OnDelete   ReferentialAction `json:"on_delete,omitempty"`
+	OnUpdate   ReferentialAction `json:"on_update,omitempty"`
+	Virtual    bool              `json:"virtual,omitempty"`     // Declared in configuration rather than in the database
+	TypeColumn string            `json:"type_column,omitempty"` // Polymorphic relations only hold for rows whose TypeColumn equals TypeValue
+	TypeValue  string            `json:"type_value,omitempty"`
+	JSONPath   string            `json:"json_path,omitempty"` // The reference is the value at this path of the JSON column
+}
+
+// UniqueConstraint describes a set of columns whose values must be unique
+type UniqueConstraint struct {
+	Name    string   `json:"name,omitempty"`
+	Columns []string `json:"columns"`
+}
+
+// Index describes a secondary index that does not back a constraint
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"` // Column names, or expressions for expression indexes
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+// CheckConstraint describes a boolean expression every row must satisfy
+type CheckConstraint struct {
+	Name       string `json:"name,omitempty"`
+	Expression string `json:"expression"`
+}
+
+// Table returns the table with the given name, or nil if it does not exist
+func (s *Schema) Table(name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
+
+// TableNames returns the names of all tables in schema order
+func (s *Schema) TableNames() []string {
+	names := make([]string, len(s.Tables))
+	for i, t := range s.Tables {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// ReferencesTo returns the foreign keys of every table pointing at the given table
+func (s *Schema) ReferencesTo(table string) []ForeignKey {
+	var fks []ForeignKey
+	for _, t := range s.Tables {
+		for _, fk := range t.ForeignKeys {
+			if fk.RefTable == table {
+				fks = append(fks, fk)
+			}
+		}
+	}
+	return fks
+}
+
+// AddRelations declares the virtual foreign keys and polymorphic relations
+// of a dump configuration
+func (s *Schema) AddRelations(cfg DumpConfig) error {
+	if err := s.AddVirtualForeignKeys(cfg.VirtualForeignKeys); err != nil {
+		return err
+	}
+	return s.AddPolymorphicRelations(cfg.Polymorphic)
+}
+
+// AddVirtualForeignKeys declares relationships the database does not
+// enforce, given as child.column -> parent.column with the columns of
+// composite keys joined by commas
+func (s *Schema) AddVirtualForeignKeys(decls []string) error {
+	for _, decl := range decls {
+		fk, err := ParseForeignKey(decl)
+		if err != nil {
+			return err
+		}
+		child, parent := s.Table(fk.Table), s.Table(fk.RefTable)
+		switch {
+		case child == nil:
+			return fmt.Errorf("virtual foreign key %s: unknown table %s", decl, fk.Table)
+		case parent == nil:
+			return fmt.Errorf("virtual foreign key %s: unknown table %s", decl, fk.RefTable)
+		case len(fk.Columns) != len(fk.RefColumns):
+			return fmt.Errorf("virtual foreign key %s: the referencing and referenced columns differ in number", decl)
+		}
+		if fk.JSONPath != "" && len(fk.Columns) != 1 {
+			return fmt.Errorf("virtual foreign key %s: a JSON reference needs a single referenced column", decl)
+		}
+		for i := range fk.Columns {
+			if child.Column(fk.Columns[i]) == nil {
+				return fmt.Errorf("virtual foreign key %s: unknown column %s.%s", decl, fk.Table, fk.Columns[i])
+			}
+			if parent.Column(fk.RefColumns[i]) == nil {
+				return fmt.Errorf("virtual foreign key %s: unknown column %s.%s", decl, fk.RefTable, fk.RefColumns[i])
+			}
+		}
+		fk.Virtual = true
+		child.ForeignKeys = append(child.ForeignKeys, fk)
+	}
+	return nil
+}
+
+// AddPolymorphicRelations declares a virtual foreign key for every target
+// of the polymorphic relations
+func (s *Schema) AddPolymorphicRelations(relations []PolymorphicRelation) error {
+	for _, r := range relations {
+		child := s.Table(r.Table)
+		switch {
+		case child == nil:
+			return fmt.Errorf("polymorphic relation %s: unknown table %s", r, r.Table)
+		case child.Column(r.TypeColumn) == nil:
+			return fmt.Errorf("polymorphic relation %s: unknown column %s.%s", r, r.Table, r.TypeColumn)
+		case child.Column(r.IDColumn) == nil:
+			return fmt.Errorf("polymorphic relation %s: unknown column %s.%s", r, r.Table, r.IDColumn)
+		case len(r.Targets) == 0:
+			return fmt.Errorf("polymorphic relation %s: no targets", r)
+		}
+
+		for _, value := range slices.Sorted(maps.Keys(r.Targets)) {
+			target, column, _ := strings.Cut(r.Targets[value], ".")
+			parent := s.Table(target)
+			if parent == nil {
+				return fmt.Errorf("polymorphic relation %s: unknown table %s", r, target)
+			}
+			if column == "" {
+				if len(parent.PrimaryKey) != 1 {
+					return fmt.Errorf("polymorphic relation %s: %s needs a single column primary key, or give the column as %s.column", r, target, target)
+				}
+				column = parent.PrimaryKey[0]
+			} else if parent.Column(column) == nil {
+				return fmt.Errorf("polymorphic relation %s: unknown column %s.%s", r, target, column)
+			}
+			child.ForeignKeys = append(child.ForeignKeys, ForeignKey{
+				Table:      r.Table,
+				Columns:    []string{r.IDColumn},
+				RefTable:   target,
+				RefColumns: []string{column},
+				Virtual:    true,
+				TypeColumn: r.TypeColumn,
+				TypeValue:  value,
+			})
+		}
+	}
+	return nil
+}
+
+// ColumnNames returns the names of the table columns in ordinal order
+func (t *Table) ColumnNames() []string {
+	names := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// Column returns the column with the given name, or nil if it does not exist
+func (t *Table) Column(name string) *Column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// IsPrimaryKey reports whether the column is part of the primary key
+func (t *Table) IsPrimaryKey(column string) bool {
+	for _, c := range t.PrimaryKey {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// SelfReferences returns the foreign keys of the table pointing back at itself
+func (t *Table) SelfReferences() []ForeignKey {
+	var fks []ForeignKey
+	for _, fk := range t.ForeignKeys {
+		if fk.RefTable == t.Name {
+			fks = append(fks, fk)
+		}
+	}
+	return fks
+}
+
+// BaseType returns the lower cased type name without length, precision or enum modifiers
+func BaseType(declared string) string {
+	t := strings.ToLower(strings.TrimSpace(declared))
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		t = strings.TrimSpace(t[:i])
+	}
+	return t
+}
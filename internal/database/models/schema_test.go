@@ -0,0 +1,138 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReferentialAction(t *testing.T) {
+	tests := []struct {
+		catalog string
+		want    ReferentialAction
+	}{
+		{"CASCADE", Cascade},
+		{" set null ", SetNull},
+		{"RESTRICT", Restrict},
+		{"SET DEFAULT", SetDefault},
+		{"NO ACTION", NoAction},
+		{"", NoAction},
+		// pg_constraint codes
+		{"c", Cascade},
+		{"n", SetNull},
+		{"r", Restrict},
+		{"d", SetDefault},
+		{"a", NoAction},
+	}
+	for _, tt := range tests {
+		if got := ParseReferentialAction(tt.catalog); got != tt.want {
+			t.Errorf("ParseReferentialAction(%q) = %s, want %s", tt.catalog, got, tt.want)
+		}
+	}
+}
+
+// sampleSchema returns posts, users, tags and comments without foreign keys
+func sampleSchema() *Schema {
+	columns := func(names ...string) []Column {
+		cs := make([]Column, len(names))
+		for i, n := range names {
+			cs[i] = Column{Name: n, Type: "int", DataType: "int"}
+		}
+		return cs
+	}
+	return &Schema{Tables: []Table{
+		{Name: "posts", Columns: columns("id"), PrimaryKey: []string{"id"}},
+		{Name: "users", Columns: columns("id", "uuid"), PrimaryKey: []string{"id"}},
+		{Name: "tags", Columns: columns("post_id", "name"), PrimaryKey: []string{"post_id", "name"}},
+		{Name: "comments", Columns: columns("id", "kind", "target_id"), PrimaryKey: []string{"id"}},
+	}}
+}
+
+func TestAddVirtualForeignKeys(t *testing.T) {
+	s := sampleSchema()
+	err := s.AddVirtualForeignKeys([]string{"comments.target_id -> posts.id", "tags.post_id -> posts.id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"comments", "tags"} {
+		fks := s.Table(table).ForeignKeys
+		if len(fks) != 1 || !fks[0].Virtual || fks[0].RefTable != "posts" {
+			t.Errorf("%s foreign keys = %+v, want one virtual key to posts", table, fks)
+		}
+	}
+
+	tests := []struct {
+		decl string
+		err  string
+	}{
+		{"notes.post_id -> posts.id", "unknown table notes"},
+		{"comments.target_id -> pages.id", "unknown table pages"},
+		{"comments.post_id -> posts.id", "unknown column comments.post_id"},
+		{"comments.target_id -> users.email", "unknown column users.email"},
+		{"comments.id,target_id -> users.id", "differ in number"},
+		{"comments.target_id", "expected table.column -> table.column"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.decl, func(t *testing.T) {
+			err := sampleSchema().AddVirtualForeignKeys([]string{tt.decl})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestAddPolymorphicRelations(t *testing.T) {
+	s := sampleSchema()
+	err := s.AddPolymorphicRelations([]PolymorphicRelation{{
+		Table: "comments", TypeColumn: "kind", IDColumn: "target_id",
+		Targets: map[string]string{"user": "users.uuid", "post": "posts"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One virtual key per target, in type value order, holding for its type only
+	var got []string
+	for _, fk := range s.Table("comments").ForeignKeys {
+		if !fk.Virtual {
+			t.Errorf("%s is not virtual", fk.Declaration())
+		}
+		got = append(got, fk.Declaration()+" if "+fk.TypeColumn+"="+fk.TypeValue)
+	}
+	want := []string{
+		"comments.target_id -> posts.id if kind=post",
+		"comments.target_id -> users.uuid if kind=user",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("foreign keys = %q, want %q", got, want)
+	}
+}
+
+func TestAddPolymorphicRelationsErrors(t *testing.T) {
+	relation := func(table, typeColumn, idColumn string, targets map[string]string) PolymorphicRelation {
+		return PolymorphicRelation{Table: table, TypeColumn: typeColumn, IDColumn: idColumn, Targets: targets}
+	}
+	posts := map[string]string{"post": "posts"}
+	tests := []struct {
+		name     string
+		relation PolymorphicRelation
+		err      string
+	}{
+		{"unknown table", relation("notes", "kind", "target_id", posts), "unknown table notes"},
+		{"unknown type column", relation("comments", "type", "target_id", posts), "unknown column comments.type"},
+		{"unknown id column", relation("comments", "kind", "ref", posts), "unknown column comments.ref"},
+		{"no targets", relation("comments", "kind", "target_id", nil), "no targets"},
+		{"unknown target", relation("comments", "kind", "target_id", map[string]string{"page": "pages"}), "unknown table pages"},
+		{"unknown target column", relation("comments", "kind", "target_id", map[string]string{"user": "users.email"}), "unknown column users.email"},
+		{"composite target key", relation("comments", "kind", "target_id", map[string]string{"tag": "tags"}), "single column primary key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sampleSchema()
+			err := s.AddPolymorphicRelations([]PolymorphicRelation{tt.relation})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FollowRule defines which way a subset traversal follows a relationship
+type FollowRule int
+
+const (
+	// FollowDefault always pulls the referenced parent rows, and the
+	// referencing child rows only of rows reached from the root side
+	FollowDefault FollowRule = iota
+	// FollowChildren pulls the parents and the children of every reached row
+	FollowChildren
+	// FollowParents only pulls the referenced parent rows, for integrity
+	FollowParents
+	// FollowNone does not follow the relationship at all
+	FollowNone
+)
+
+func (f FollowRule) String() string {
+	switch f {
+	case FollowDefault:
+		return "default"
+	case FollowChildren:
+		return "children"
+	case FollowParents:
+		return "parents"
+	case FollowNone:
+		return "none"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText renders the rule by name in job files
+func (f FollowRule) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText parses a rule name from a job file
+func (f *FollowRule) UnmarshalText(text []byte) error {
+	rule, err := ParseFollowRule(string(text))
+	if err != nil {
+		return err
+	}
+	*f = rule
+	return nil
+}
+
+// ParseFollowRule returns the follow rule matching its string representation
+func ParseFollowRule(s string) (FollowRule, error) {
+	for _, f := range []FollowRule{FollowDefault, FollowChildren, FollowParents, FollowNone} {
+		if f.String() == s {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown follow rule %q, expected default, children, parents or none", s)
+}
+
+// ValidateLimits returns an error for a negative depth limit, row budget or
+// extraction setting
+func (c DumpConfig) ValidateLimits() error {
+	if c.Workers < 0 {
+		return fmt.Errorf("workers must not be negative, got %d", c.Workers)
+	}
+	if c.ChunkRows < 0 {
+		return fmt.Errorf("chunk rows must not be negative, got %d", c.ChunkRows)
+	}
+	if c.BufferRows < 0 {
+		return fmt.Errorf("buffer rows must not be negative, got %d", c.BufferRows)
+	}
+	if c.MaxDepth != nil && *c.MaxDepth < 0 {
+		return fmt.Errorf("max depth must not be negative, got %d", *c.MaxDepth)
+	}
+	if c.MaxRows < 0 {
+		return fmt.Errorf("max rows must not be negative, got %d", c.MaxRows)
+	}
+	for name, d := range c.DepthLimits {
+		if d < 0 {
+			return fmt.Errorf("depth limit of %s must not be negative, got %d", name, d)
+		}
+	}
+	for table, n := range c.TableMaxRows {
+		if n < 0 {
+			return fmt.Errorf("row budget of %s must not be negative, got %d", table, n)
+		}
+	}
+	return nil
+}
+
+// ValidateWorkers returns an error when several workers would read a
+// transaction snapshot the source cannot share between connections
+func (c DumpConfig) ValidateWorkers() error {
+	if c.Workers > 1 && c.Snapshot.OrDefault() == SnapshotTransaction && !c.SourceConfig.Type.SharesSnapshot() {
+		return fmt.Errorf("%s cannot share a transaction snapshot between connections: read with one worker, or with the locks or none snapshot mode", c.SourceConfig.Type)
+	}
+	return nil
+}
+
+// ValidateExcluding returns an error for the settings that would keep the
+// excluding mode from reaching every row depending on its seeds. Those rows
+// are left out with the seeds, and any of them kept would reference a row
+// that is not dumped.
+func (c DumpConfig) ValidateExcluding() error {
+	for _, setting := range []struct {
+		name string
+		set  bool
+	}{
+		{"max depth", c.MaxDepth != nil},
+		{"depth limits", len(c.DepthLimits) > 0},
+		{"max rows", c.MaxRows > 0},
+		{"table row budgets", len(c.TableMaxRows) > 0},
+		{"boundary tables", len(c.BoundaryTables) > 0},
+		{"lookup tables", len(c.LookupTables) > 0},
+	} {
+		if setting.set {
+			return fmt.Errorf("%s apply to the including mode only: the excluding mode leaves out every row depending on its seeds", setting.name)
+		}
+	}
+	for name, rule := range c.Follow {
+		if rule == FollowParents || rule == FollowNone {
+			return fmt.Errorf("follow rule %s=%s applies to the including mode only: the excluding mode leaves out every row depending on its seeds", name, rule)
+		}
+	}
+	return nil
+}
+
+// JSONPathSeparator joins a JSON column and a path within it, as in new_values->$.client_id
+const JSONPathSeparator = "->"
+
+// ParseForeignKey parses a relationship declared as child.column ->
+// parent.column, with the columns of composite keys joined by commas. The
+// child side may be a JSON column and a path, as in
+// activity_log.new_values->$.client_id -> clients.id.
+func ParseForeignKey(decl string) (ForeignKey, error) {
+	i := strings.LastIndex(decl, JSONPathSeparator)
+	if i < 0 {
+		return ForeignKey{}, fmt.Errorf("invalid foreign key %q: expected table.column -> table.column", decl)
+	}
+	from, to := decl[:i], decl[i+len(JSONPathSeparator):]
+	from, path, isJSON := strings.Cut(from, JSONPathSeparator)
+	child, columns, err := parseColumnRef(from)
+	if err != nil {
+		return ForeignKey{}, fmt.Errorf("invalid foreign key %q: %w", decl, err)
+	}
+	parent, refColumns, err := parseColumnRef(to)
+	if err != nil {
+		return ForeignKey{}, fmt.Errorf("invalid foreign key %q: %w", decl, err)
+	}
+	fk := ForeignKey{Table: child, Columns: columns, RefTable: parent, RefColumns: refColumns}
+	if isJSON {
+		fk.JSONPath = strings.TrimSpace(path)
+		if len(columns) != 1 {
+			return ForeignKey{}, fmt.Errorf("invalid foreign key %q: a JSON path applies to a single column", decl)
+		}
+		if _, err := ParseJSONPath(fk.JSONPath); err != nil {
+			return ForeignKey{}, fmt.Errorf("invalid foreign key %q: %w", decl, err)
+		}
+	}
+	return fk, nil
+}
+
+// Declaration renders the foreign key as ParseForeignKey reads it
+func (fk ForeignKey) Declaration() string {
+	return fmt.Sprintf("%s.%s -> %s.%s", fk.Table, fk.Source(), fk.RefTable, strings.Join(fk.RefColumns, ","))
+}
+
+// Source renders the referencing columns, or the JSON column and path
+func (fk ForeignKey) Source() string {
+	if fk.JSONPath != "" {
+		return fk.Columns[0] + JSONPathSeparator + fk.JSONPath
+	}
+	return strings.Join(fk.Columns, ",")
+}
+
+// ParseJSONPath returns the keys and array indexes of a path such as
+// $.customer.id or $.items[0], the subset of JSON path every dialect reads
+func ParseJSONPath(path string) ([]string, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok || rest == "" {
+		return nil, fmt.Errorf("invalid JSON path %q: expected $.key", path)
+	}
+	var segments []string
+	for rest != "" {
+		var segment string
+		switch rest[0] {
+		case '.':
+			end := 1
+			for end < len(rest) && isPathChar(rest[end]) {
+				end++
+			}
+			segment, rest = rest[1:end], rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unclosed [", path)
+			}
+			segment, rest = rest[1:end], rest[end+1:]
+			for i := 0; i < len(segment); i++ {
+				if segment[i] < '0' || segment[i] > '9' {
+					return nil, fmt.Errorf("invalid JSON path %q: array index %q is not a number", path, segment)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q: unexpected %q", path, rest[0])
+		}
+		if segment == "" {
+			return nil, fmt.Errorf("invalid JSON path %q: empty key", path)
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// isPathChar reports whether a byte may appear in an unquoted JSON path key
+func isPathChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// parseColumnRef splits table.column, or table.a,b, into its parts
+func parseColumnRef(s string) (string, []string, error) {
+	s = strings.TrimSpace(s)
+	i := strings.LastIndex(s, ".")
+	if i <= 0 || i == len(s)-1 {
+		return "", nil, fmt.Errorf("expected table.column, got %q", s)
+	}
+	var columns []string
+	for _, c := range strings.Split(s[i+1:], ",") {
+		if c = strings.TrimSpace(c); c == "" {
+			return "", nil, fmt.Errorf("empty column in %q", s)
+		}
+		columns = append(columns, c)
+	}
+	return s[:i], columns, nil
+}
+
+// PolymorphicRelation maps the values of a type column to the tables its id
+// column points at, as in Rails and Laravel polymorphic associations
+type PolymorphicRelation struct {
+	Table      string            `json:"table" yaml:"table"`
+	TypeColumn string            `json:"type_column" yaml:"type_column"`
+	IDColumn   string            `json:"id_column" yaml:"id_column"`
+	Targets    map[string]string `json:"targets" yaml:"targets"` // Type value to table, or table.column when not its primary key
+}
+
+func (r PolymorphicRelation) String() string {
+	return fmt.Sprintf("%s(%s, %s)", r.Table, r.TypeColumn, r.IDColumn)
+}
+
+// ParsePolymorphicRelation parses a relation declared as
+// table.type_column,id_column: value=table,value=table.column
+func ParsePolymorphicRelation(decl string) (PolymorphicRelation, error) {
+	from, to, ok := strings.Cut(decl, ":")
+	if !ok {
+		return PolymorphicRelation{}, fmt.Errorf("invalid polymorphic relation %q: expected table.type_column,id_column: value=table,...", decl)
+	}
+	table, columns, err := parseColumnRef(from)
+	if err != nil {
+		return PolymorphicRelation{}, fmt.Errorf("invalid polymorphic relation %q: %w", decl, err)
+	}
+	if len(columns) != 2 {
+		return PolymorphicRelation{}, fmt.Errorf("invalid polymorphic relation %q: expected a type and an id column", decl)
+	}
+	r := PolymorphicRelation{Table: table, TypeColumn: columns[0], IDColumn: columns[1], Targets: make(map[string]string)}
+	for _, pair := range strings.Split(to, ",") {
+		value, target, ok := strings.Cut(pair, "=")
+		value, target = strings.TrimSpace(value), strings.TrimSpace(target)
+		if !ok || value == "" || target == "" {
+			return PolymorphicRelation{}, fmt.Errorf("invalid polymorphic relation %q: expected value=table, got %q", decl, strings.TrimSpace(pair))
+		}
+		r.Targets[value] = target
+	}
+	return r, nil
+}
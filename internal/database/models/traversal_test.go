@@ -0,0 +1,181 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParsePolymorphicRelation(t *testing.T) {
+	tests := []struct {
+		decl string
+		want PolymorphicRelation
+		err  string
+	}{
+		{
+			decl: "activity_log.entity_type,entity_id: employees=employees,projects=projects",
+			want: PolymorphicRelation{Table: "activity_log", TypeColumn: "entity_type", IDColumn: "entity_id",
+				Targets: map[string]string{"employees": "employees", "projects": "projects"}},
+		},
+		{
+			decl: " comments.commentable_type , commentable_id :App\\Post=posts, App\\User = users.uuid ",
+			want: PolymorphicRelation{Table: "comments", TypeColumn: "commentable_type", IDColumn: "commentable_id",
+				Targets: map[string]string{"App\\Post": "posts", "App\\User": "users.uuid"}},
+		},
+		{decl: "activity_log.entity_type,entity_id", err: "expected table.type_column,id_column"},
+		{decl: "activity_log.entity_id: employees=employees", err: "expected a type and an id column"},
+		{decl: "activity_log.a,b,c: employees=employees", err: "expected a type and an id column"},
+		{decl: "entity_type,entity_id: employees=employees", err: "expected table.column"},
+		{decl: "activity_log.entity_type,entity_id: employees", err: `expected value=table, got "employees"`},
+		{decl: "activity_log.entity_type,entity_id: employees=", err: "expected value=table"},
+		{decl: "activity_log.entity_type,entity_id: =employees", err: "expected value=table"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.decl, func(t *testing.T) {
+			got, err := ParsePolymorphicRelation(tt.decl)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Table != tt.want.Table || got.TypeColumn != tt.want.TypeColumn || got.IDColumn != tt.want.IDColumn ||
+				!maps.Equal(got.Targets, tt.want.Targets) {
+				t.Errorf("relation = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseForeignKey(t *testing.T) {
+	tests := []struct {
+		decl string
+		want ForeignKey
+		err  string
+	}{
+		{
+			decl: "orders.customer_ref -> customers.id",
+			want: ForeignKey{Table: "orders", Columns: []string{"customer_ref"}, RefTable: "customers", RefColumns: []string{"id"}},
+		},
+		{
+			decl: "  lines.order_id, line_no->order_lines.order_id,no ",
+			want: ForeignKey{Table: "lines", Columns: []string{"order_id", "line_no"}, RefTable: "order_lines", RefColumns: []string{"order_id", "no"}},
+		},
+		{
+			decl: "app.orders.customer_id -> app.customers.id",
+			want: ForeignKey{Table: "app.orders", Columns: []string{"customer_id"}, RefTable: "app.customers", RefColumns: []string{"id"}},
+		},
+		{decl: "orders.customer_id customers.id", err: "expected table.column -> table.column"},
+		{decl: "customer_id -> customers.id", err: `expected table.column, got "customer_id"`},
+		{decl: "orders.customer_id -> customers", err: `expected table.column, got "customers"`},
+		{decl: "orders. -> customers.id", err: "expected table.column"},
+		{decl: "orders.a,,b -> customers.id", err: "empty column"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.decl, func(t *testing.T) {
+			got, err := ParseForeignKey(tt.decl)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Table != tt.want.Table || !slices.Equal(got.Columns, tt.want.Columns) || got.RefTable != tt.want.RefTable ||
+				!slices.Equal(got.RefColumns, tt.want.RefColumns) || got.JSONPath != "" || got.Virtual {
+				t.Errorf("foreign key = %+v, want %+v", got, tt.want)
+			}
+			if again, err := ParseForeignKey(got.Declaration()); err != nil || again.Declaration() != got.Declaration() {
+				t.Errorf("declaration %q reads back as %+v, %v", got.Declaration(), again, err)
+			}
+		})
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+		err  string
+	}{
+		{path: "$.client_id", want: []string{"client_id"}},
+		{path: "$.customer.id", want: []string{"customer", "id"}},
+		{path: "$.items[0].sku", want: []string{"items", "0", "sku"}},
+		{path: "$[12]", want: []string{"12"}},
+		{path: "", err: "expected $.key"},
+		{path: "$", err: "expected $.key"},
+		{path: "client_id", err: "expected $.key"},
+		{path: "$.", err: "empty key"},
+		{path: "$.a..b", err: "empty key"},
+		{path: "$[]", err: "empty key"},
+		{path: "$.items[0", err: "unclosed ["},
+		{path: "$.items[x]", err: "is not a number"},
+		{path: "$.first-name", err: `unexpected '-'`},
+		{path: `$."quoted"`, err: "empty key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParseJSONPath(tt.path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("segments = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseForeignKeyJSON(t *testing.T) {
+	tests := []struct {
+		decl string
+		want ForeignKey
+		err  string
+	}{
+		{
+			decl: "activity_log.new_values->$.client_id -> clients.id",
+			want: ForeignKey{Table: "activity_log", Columns: []string{"new_values"}, RefTable: "clients", RefColumns: []string{"id"}, JSONPath: "$.client_id"},
+		},
+		{
+			decl: "orders.payload -> $.customer.id->customers.id",
+			want: ForeignKey{Table: "orders", Columns: []string{"payload"}, RefTable: "customers", RefColumns: []string{"id"}, JSONPath: "$.customer.id"},
+		},
+		{decl: "orders.a,b->$.id -> customers.id", err: "a JSON path applies to a single column"},
+		{decl: "orders.payload->customer -> customers.id", err: "invalid JSON path"},
+		{decl: "orders.payload->$.items[x] -> customers.id", err: "is not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.decl, func(t *testing.T) {
+			got, err := ParseForeignKey(tt.decl)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Table != tt.want.Table || !slices.Equal(got.Columns, tt.want.Columns) || got.RefTable != tt.want.RefTable ||
+				!slices.Equal(got.RefColumns, tt.want.RefColumns) || got.JSONPath != tt.want.JSONPath {
+				t.Errorf("foreign key = %+v, want %+v", got, tt.want)
+			}
+			// The declaration reads back as the same key
+			if again, err := ParseForeignKey(got.Declaration()); err != nil || again.Source() != got.Source() {
+				t.Errorf("declaration %q reads back as %+v, %v", got.Declaration(), again, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package completion
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/ui/components/processing"
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chromeHeight is the number of lines around the scrollable summary
+const chromeHeight = 8
+
+// Show displays the outcome of a dump
+func (s *Summary) Show(msg processing.DumpFinishedMsg) {
+	s.finished = msg
+	s.status = ""
+	s.failed = false
+	s.saving = false
+	s.viewport.SetContent(s.text())
+	s.viewport.GotoTop()
+}
+
+// Update handles summary updates
+func (s *Summary) Update(msg tea.Msg) (*Summary, tea.Cmd) {
+	if _, resize := msg.(tea.WindowSizeMsg); s.saving && !resize {
+		return s.updateSaveJob(msg)
+	}
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.viewport.Width = msg.Width
+		s.viewport.Height = max(5, msg.Height-chromeHeight)
+		return s, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc":
+			return s, tea.Quit
+		case "enter":
+			return s, func() tea.Msg { return RestartMsg{} }
+		case "c":
+			if err := clipboard.WriteAll(s.text()); err != nil {
+				s.status = "Could not copy to the clipboard: " + err.Error()
+				s.failed = true
+			} else {
+				s.status = "Summary copied to the clipboard"
+				s.failed = false
+			}
+			return s, nil
+		case "s":
+			return s.startSaveJob()
+		}
+	}
+
+	var cmd tea.Cmd
+	s.viewport, cmd = s.viewport.Update(msg)
+	return s, cmd
+}
+
+// View renders the summary
+func (s *Summary) View() string {
+	if s.saving {
+		return s.viewSaveJob()
+	}
+	var b strings.Builder
+
+	f := s.finished
+	switch {
+	case errors.Is(f.Err, context.Canceled):
+		b.WriteString(s.styles.Warning.Render("⚠ Dump cancelled"))
+	case f.Err != nil:
+		b.WriteString(s.styles.Error.Render("✗ Dump failed"))
+	default:
+		b.WriteString(s.styles.Success.Render("✓ Dump completed"))
+		if n := len(f.Result.Warnings); n > 0 {
+			b.WriteString(s.styles.Warning.Render(" with warnings"))
+		}
+	}
+	b.WriteString("\n\n")
+	b.WriteString(s.viewport.View())
+	b.WriteString("\n\n")
+
+	b.WriteString(s.viewStatus())
+	b.WriteString(s.styles.Help.Render("• ↑/↓ to scroll • C to copy • S to save as job • Enter to start another dump • Q to quit"))
+	return b.String()
+}
+
+// viewStatus renders the outcome of the last copy or save, if any
+func (s *Summary) viewStatus() string {
+	if s.status == "" {
+		return ""
+	}
+	if s.failed {
+		return s.styles.Error.Render(s.status) + "\n"
+	}
+	return s.styles.Info.Render(s.status) + "\n"
+}
+
+// text returns the plain text summary, as displayed and copied
+func (s *Summary) text() string {
+	f := s.finished
+	if f.Err != nil {
+		if errors.Is(f.Err, context.Canceled) {
+			return "The dump was cancelled before completion; the output may be incomplete.\n"
+		}
+		return f.Err.Error() + "\n"
+	}
+	return f.Result.Summary()
+}
@@ -0,0 +1,101 @@
+package completion
+
+import (
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/config"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newJobInputs creates the inputs of the save as job step
+func newJobInputs(s *Summary) []textinput.Model {
+	inputs := make([]textinput.Model, 2)
+	for i := range inputs {
+		t := textinput.New()
+		t.Cursor.Style = s.styles.Cursor
+		t.CharLimit = 255
+		inputs[i] = t
+	}
+	inputs[0].Placeholder = "Job Name (e.g., customer-subset)"
+	inputs[1].Placeholder = "Job File (default: " + config.DefaultJobFiles[0] + ")"
+	return inputs
+}
+
+// startSaveJob switches to the step saving the configuration of the dump as a job
+func (s *Summary) startSaveJob() (*Summary, tea.Cmd) {
+	if s.jobInputs == nil {
+		s.jobInputs = newJobInputs(s)
+	}
+	s.saving = true
+	s.status = ""
+	s.jobFocus = 0
+	s.jobInputs[1].Blur()
+	return s, s.jobInputs[0].Focus()
+}
+
+// updateSaveJob handles input in the save as job step
+func (s *Summary) updateSaveJob(msg tea.Msg) (*Summary, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "esc":
+			s.saving = false
+			return s, nil
+		case "tab", "shift+tab", "up", "down":
+			s.jobInputs[s.jobFocus].Blur()
+			s.jobFocus = (s.jobFocus + 1) % len(s.jobInputs)
+			return s, s.jobInputs[s.jobFocus].Focus()
+		case "enter":
+			s.saveJob()
+			return s, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	s.jobInputs[s.jobFocus], cmd = s.jobInputs[s.jobFocus].Update(msg)
+	return s, cmd
+}
+
+// saveJob writes the configuration of the dump as a named job
+func (s *Summary) saveJob() {
+	name := strings.TrimSpace(s.jobInputs[0].Value())
+	if name == "" {
+		s.status = "A job name is required"
+		s.failed = true
+		return
+	}
+	path := strings.TrimSpace(s.jobInputs[1].Value())
+	if path == "" {
+		path = config.DefaultJobFiles[0]
+	}
+
+	if err := config.SaveJob(path, name, config.WithPasswordRefs(s.finished.Config)); err != nil {
+		s.status = err.Error()
+		s.failed = true
+		return
+	}
+	s.status = "Saved job " + name + " to " + path
+	s.failed = false
+	s.saving = false
+}
+
+// viewSaveJob renders the save as job step
+func (s *Summary) viewSaveJob() string {
+	var b strings.Builder
+
+	b.WriteString(s.styles.Title.Render("Save Configuration as Job"))
+	b.WriteString("\n\n")
+
+	labels := []string{"Job Name:", "Job File:"}
+	for i := range s.jobInputs {
+		b.WriteString(labels[i] + "\n")
+		b.WriteString(s.jobInputs[i].View())
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(s.viewStatus())
+	b.WriteString(s.styles.Help.Render("• Passwords are saved as $RELTRACE_PASSWORD references"))
+	b.WriteString("\n")
+	b.WriteString(s.styles.Help.Render("• Tab to navigate • Enter to save • Esc to go back"))
+	return b.String()
+}
@@ -0,0 +1,33 @@
+package completion
+
+import (
+	"github.com/antoniosarro/reltrace/internal/ui/components/processing"
+	"github.com/antoniosarro/reltrace/internal/ui/styles"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+)
+
+// RestartMsg is sent when the user wants to configure another dump
+type RestartMsg struct{}
+
+// Summary shows the outcome of a finished dump
+type Summary struct {
+	styles   *styles.Styles
+	viewport viewport.Model
+	finished processing.DumpFinishedMsg
+	status   string
+	failed   bool // the status reports an error
+
+	// Save as job step
+	saving    bool
+	jobInputs []textinput.Model
+	jobFocus  int
+}
+
+// NewSummary creates a new completion summary
+func NewSummary(s *styles.Styles) *Summary {
+	return &Summary{
+		styles:   s,
+		viewport: viewport.New(80, 20),
+	}
+}
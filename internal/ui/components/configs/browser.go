@@ -0,0 +1,372 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// schemaLoadTimeout bounds the introspection of the source database
+const schemaLoadTimeout = 60 * time.Second
+
+// browserRows is the number of tables shown at once in the schema browser
+const browserRows = 12
+
+// relationRows is the number of relationships listed for the selected table
+const relationRows = 6
+
+// schemaLoadedMsg carries the introspected source schema
+type schemaLoadedMsg struct {
+	source    models.DatabaseConfig
+	schema    *models.Schema
+	estimates map[string]int64
+	err       error
+}
+
+// tableEntry is a table listed by the schema browser
+type tableEntry struct {
+	name     string
+	rows     int64
+	incoming int
+	outgoing int
+	key      []string
+}
+
+// newBrowserFilter creates the filter input of the schema browser
+func newBrowserFilter(c *ConfigForm) textinput.Model {
+	t := textinput.New()
+	t.Cursor.Style = c.styles.Cursor
+	t.CharLimit = 255
+	t.Placeholder = "Type to filter tables"
+	t.Prompt = "/ "
+	return t
+}
+
+// startBrowser switches to the schema browser, introspecting the source
+// database unless its schema is already loaded
+func (c *ConfigForm) startBrowser() (*ConfigForm, tea.Cmd) {
+	src := c.buildConfig().SourceConfig
+	if msg := sourceValidationError(src); msg != "" {
+		c.status = msg
+		c.statusErr = true
+		return c, nil
+	}
+
+	c.step = 5
+	c.status = ""
+	c.browserFilter.SetValue("")
+	c.browserCursor = 0
+	cmd := c.browserFilter.Focus()
+	if c.schema != nil && c.schemaSource == src {
+		c.selectCurrentRoot()
+		return c, cmd
+	}
+
+	c.schema = nil
+	c.loading = true
+	return c, tea.Batch(cmd, loadSchema(c.buildConfig()))
+}
+
+// schemaLoaded keeps the introspected schema; it is kept even when the user
+// left the browser before it was read, so reopening the browser is instant
+func (c *ConfigForm) schemaLoaded(msg schemaLoadedMsg) (*ConfigForm, tea.Cmd) {
+	if msg.source != c.buildConfig().SourceConfig {
+		return c, nil
+	}
+	c.loading = false
+	if msg.err != nil {
+		if c.step >= 5 {
+			c.status = msg.err.Error()
+			c.statusErr = true
+		}
+		return c, nil
+	}
+	c.schema = msg.schema
+	c.estimates = msg.estimates
+	c.schemaSource = msg.source
+	c.selectCurrentRoot()
+	switch c.step {
+	case 6:
+		return c, c.initFinder()
+	case 8:
+		return c, c.infer()
+	}
+	return c, nil
+}
+
+// updateBrowser handles input in the schema browser
+func (c *ConfigForm) updateBrowser(msg tea.Msg) (*ConfigForm, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		entries := c.browserEntries()
+		switch msg.String() {
+		case "esc":
+			c.step = 0
+			c.status = ""
+			return c, c.focusInput(5)
+		case "up", "ctrl+p":
+			c.moveBrowserCursor(-1, len(entries))
+			return c, nil
+		case "down", "ctrl+n":
+			c.moveBrowserCursor(1, len(entries))
+			return c, nil
+		case "pgup":
+			c.moveBrowserCursor(-browserRows, len(entries))
+			return c, nil
+		case "pgdown":
+			c.moveBrowserCursor(browserRows, len(entries))
+			return c, nil
+		case "enter":
+			if c.loading || len(entries) == 0 {
+				return c, nil
+			}
+			return c, c.pickRoot(entries[c.browserCursor])
+		}
+	}
+
+	before := c.browserFilter.Value()
+	var cmd tea.Cmd
+	c.browserFilter, cmd = c.browserFilter.Update(msg)
+	if c.browserFilter.Value() != before {
+		c.browserCursor = 0
+	}
+	return c, cmd
+}
+
+// moveBrowserCursor moves the highlighted table, staying inside the list
+func (c *ConfigForm) moveBrowserCursor(delta, n int) {
+	c.browserCursor = max(0, min(n-1, c.browserCursor+delta))
+}
+
+// selectCurrentRoot highlights the root table already entered in the form
+func (c *ConfigForm) selectCurrentRoot() {
+	root := strings.TrimSpace(c.inputs[5].Value())
+	for i, e := range c.browserEntries() {
+		if e.name == root {
+			c.browserCursor = i
+			return
+		}
+	}
+}
+
+// pickRoot fills the root table with the chosen table and moves on to its key
+func (c *ConfigForm) pickRoot(e tableEntry) tea.Cmd {
+	if c.inputs[5].Value() != e.name {
+		c.inputs[5].SetValue(e.name)
+		c.inputs[6].SetValue("")
+	}
+	c.step = 0
+	c.status = ""
+	return c.focusInput(6)
+}
+
+// browserEntries returns the tables matching the filter, sorted by name
+func (c *ConfigForm) browserEntries() []tableEntry {
+	if c.schema == nil {
+		return nil
+	}
+	filter := strings.ToLower(strings.TrimSpace(c.browserFilter.Value()))
+
+	var entries []tableEntry
+	for _, t := range c.schema.Tables {
+		if filter != "" && !strings.Contains(strings.ToLower(t.Name), filter) {
+			continue
+		}
+		entries = append(entries, tableEntry{
+			name:     t.Name,
+			rows:     c.estimates[t.Name],
+			incoming: len(c.schema.ReferencesTo(t.Name)),
+			outgoing: len(t.ForeignKeys),
+			key:      t.PrimaryKey,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	return entries
+}
+
+// rootKeyColumns returns the primary key of the root table entered in the
+// form, and whether the table is known to the loaded schema
+func (c *ConfigForm) rootKeyColumns() ([]string, bool) {
+	if c.schema == nil || c.schemaSource != c.buildConfig().SourceConfig {
+		return nil, false
+	}
+	t := c.schema.Table(strings.TrimSpace(c.inputs[5].Value()))
+	if t == nil {
+		return nil, false
+	}
+	return t.PrimaryKey, true
+}
+
+// loadSchema introspects the source database, declares the relationships of
+// the configuration it does not enforce and estimates the table sizes
+func loadSchema(dump models.DumpConfig) tea.Cmd {
+	cfg := dump.SourceConfig
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), schemaLoadTimeout)
+		defer cancel()
+
+		src, err := adapters.Open(ctx, cfg)
+		if err != nil {
+			return schemaLoadedMsg{source: cfg, err: err}
+		}
+		defer src.Close()
+
+		schema, err := adapters.Introspect(ctx, src)
+		if err != nil {
+			return schemaLoadedMsg{source: cfg, err: fmt.Errorf("introspecting schema: %w", err)}
+		}
+		if err := schema.AddRelations(dump); err != nil {
+			return schemaLoadedMsg{source: cfg, err: err}
+		}
+		estimates, err := src.RowEstimates(ctx)
+		if err != nil {
+			return schemaLoadedMsg{source: cfg, err: fmt.Errorf("estimating table sizes: %w", err)}
+		}
+		return schemaLoadedMsg{source: cfg, schema: schema, estimates: estimates}
+	}
+}
+
+// viewBrowser renders the schema browser
+func (c *ConfigForm) viewBrowser() string {
+	var b strings.Builder
+
+	b.WriteString(c.styles.Title.Render("Select Root Table"))
+	b.WriteString("\n\n")
+
+	switch {
+	case c.loading:
+		b.WriteString(c.styles.Info.Render("Reading schema..."))
+		b.WriteString("\n\n")
+	case c.schema == nil:
+		b.WriteString(c.viewStatus())
+	default:
+		b.WriteString(c.browserFilter.View())
+		b.WriteString("\n\n")
+		b.WriteString(c.viewBrowserTables())
+	}
+
+	b.WriteString(c.styles.Help.Render("• Type to filter • ↑/↓ to navigate • Enter to select • Esc to go back"))
+	return b.String()
+}
+
+// viewBrowserTables renders the window of tables around the cursor
+func (c *ConfigForm) viewBrowserTables() string {
+	entries := c.browserEntries()
+	if len(entries) == 0 {
+		return c.styles.Blurred.Render("No tables match the filter") + "\n\n"
+	}
+
+	nameWidth := len("TABLE")
+	for _, e := range entries {
+		nameWidth = max(nameWidth, len(e.name))
+	}
+	nameWidth = min(nameWidth, 40)
+
+	rowsHeader := "ROWS"
+	if c.schema.Type != models.SQLite3 {
+		rowsHeader = "~ROWS"
+	}
+
+	var b strings.Builder
+	header := fmt.Sprintf("  %-*s %10s %4s %4s  %s", nameWidth, "TABLE", rowsHeader, "IN", "OUT", "PRIMARY KEY")
+	b.WriteString(c.styles.Blurred.Render(header))
+	b.WriteString("\n")
+
+	start := max(0, min(c.browserCursor-browserRows/2, len(entries)-browserRows))
+	end := min(len(entries), start+browserRows)
+	for i := start; i < end; i++ {
+		e := entries[i]
+		key := strings.Join(e.key, ", ")
+		if key == "" {
+			key = "-"
+		}
+		line := fmt.Sprintf("%-*s %10d %4d %4d  %s", nameWidth, truncate(e.name, nameWidth), e.rows, e.incoming, e.outgoing, key)
+		if i == c.browserCursor {
+			b.WriteString(c.styles.Focused.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(c.styles.Blurred.Render(fmt.Sprintf("%d of %d tables", len(entries), len(c.schema.Tables))))
+	b.WriteString("\n\n")
+	if c.browserCursor < len(entries) {
+		b.WriteString(c.viewRelations(entries[c.browserCursor].name))
+	}
+	return b.String()
+}
+
+// viewRelations lists the relationships of a table, marking the virtual ones
+func (c *ConfigForm) viewRelations(table string) string {
+	t := c.schema.Table(table)
+	if t == nil {
+		return ""
+	}
+
+	var lines []string
+	describe := func(arrow string, fk models.ForeignKey, other string) {
+		columns := strings.Join(fk.Columns, ", ")
+		if fk.JSONPath != "" {
+			columns = fk.Source()
+		}
+		line := fmt.Sprintf("%s %s (%s → %s)", arrow, other, columns, strings.Join(fk.RefColumns, ", "))
+		if fk.TypeColumn != "" {
+			line += fmt.Sprintf(" when %s = %s", fk.TypeColumn, fk.TypeValue)
+		}
+		if fk.Virtual {
+			line += c.styles.Info.Render("  virtual")
+		}
+		lines = append(lines, line)
+	}
+	for _, fk := range t.ForeignKeys {
+		describe("→", fk, fk.RefTable)
+	}
+	for _, fk := range c.schema.ReferencesTo(table) {
+		describe("←", fk, fk.Table)
+	}
+	if len(lines) == 0 {
+		return c.styles.Blurred.Render("No relationships") + "\n\n"
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i == relationRows {
+			b.WriteString(c.styles.Blurred.Render(fmt.Sprintf("  …and %d more", len(lines)-i)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// truncate shortens s to at most n characters, marking the cut with an ellipsis
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
+
+// sourceValidationError returns why the source connection is incomplete, or an empty string
+func sourceValidationError(cfg models.DatabaseConfig) string {
+	if cfg.Type == models.SQLite3 {
+		if cfg.FilePath == "" {
+			return "A database file path is required to browse tables"
+		}
+		return ""
+	}
+	if cfg.Host == "" || cfg.User == "" || cfg.Database == "" {
+		return "Host, username and database are required to browse tables"
+	}
+	return ""
+}
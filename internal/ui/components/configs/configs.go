@@ -15,6 +15,22 @@ func (c *ConfigForm) View() string {
 		return c.viewModeSelection()
 	case 2:
 		return c.viewTargetSelection()
+	case 3:
+		return c.viewSaveJob()
+	case 4:
+		return c.viewTargetConfig()
+	case 5:
+		return c.viewBrowser()
+	case 6:
+		return c.viewFinder()
+	case 7:
+		return c.viewTables()
+	case 8:
+		return c.viewInferred()
+	case 9:
+		return c.viewPreview()
+	case 10:
+		return c.viewExplain()
 	default:
 		return c.viewDatabaseConfig()
 	}
@@ -26,8 +42,41 @@ func (c *ConfigForm) Focus() tea.Cmd {
 	return c.inputs[0].Focus()
 }
 
+// Restart returns to the first step, keeping the values entered so far
+func (c *ConfigForm) Restart() tea.Cmd {
+	c.step = 0
+	c.status = ""
+	return c.Focus()
+}
+
 // Update handles configuration form updates
 func (c *ConfigForm) Update(msg tea.Msg) (*ConfigForm, tea.Cmd) {
+	if msg, ok := msg.(schemaLoadedMsg); ok {
+		return c.schemaLoaded(msg)
+	}
+	if msg, ok := msg.(inferredMsg); ok {
+		return c.inferenceDone(msg)
+	}
+
+	switch c.step {
+	case 3:
+		return c.updateSaveJob(msg)
+	case 4:
+		return c.updateTargetConfig(msg)
+	case 5:
+		return c.updateBrowser(msg)
+	case 6:
+		return c.updateFinder(msg)
+	case 7:
+		return c.updateTables(msg)
+	case 8:
+		return c.updateInferred(msg)
+	case 9:
+		return c.updatePreview(msg)
+	case 10:
+		return c.updateExplain(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -47,12 +96,23 @@ func (c *ConfigForm) Update(msg tea.Msg) (*ConfigForm, tea.Cmd) {
 			case 2: // Target selection
 				if msg.String() == "1" {
 					c.target = models.ToFile
+					return c.startPreview()
 				} else if msg.String() == "2" {
 					c.target = models.ToDatabase
+					return c.startTargetConfig()
 				}
-				if c.target == models.ToFile || c.validateTargetDbConfig() {
-					return c, c.submitConfig()
-				}
+			}
+		case "s":
+			if c.step == 2 {
+				return c.startSaveJob()
+			}
+		case "t":
+			if c.step == 2 {
+				return c.startTables()
+			}
+		case "r":
+			if c.step == 2 {
+				return c.startInference()
 			}
 		case "backspace":
 			if c.step > 0 {
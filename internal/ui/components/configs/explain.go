@@ -0,0 +1,96 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/engine"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startExplain drills down from the previewed plan into the rows of the
+// table under the cursor, showing how the traversal reached each of them
+func (c *ConfigForm) startExplain() (*ConfigForm, tea.Cmd) {
+	if c.preview == nil || c.preview.Subset == nil || len(c.preview.Tables) == 0 {
+		return c, nil
+	}
+	table := c.preview.Tables[c.previewCursor].Name
+	if c.preview.Subset.Rows(table).Len() == 0 {
+		c.status = fmt.Sprintf("No row of %s was reached from the seeds", table)
+		c.statusErr = false
+		return c, nil
+	}
+	c.step = 10
+	c.status = ""
+	c.explainTable = table
+	c.explainCursor = 0
+	return c, nil
+}
+
+// updateExplain handles input in the drill-down of reached rows
+func (c *ConfigForm) updateExplain(msg tea.Msg) (*ConfigForm, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return c, nil
+	}
+	rows := c.preview.Subset.Rows(c.explainTable).Len()
+	switch key.String() {
+	case "esc", "left":
+		c.step = 9
+	case "up":
+		c.explainCursor = max(0, c.explainCursor-1)
+	case "down":
+		c.explainCursor = max(0, min(rows-1, c.explainCursor+1))
+	case "pgup":
+		c.explainCursor = max(0, c.explainCursor-browserRows)
+	case "pgdown":
+		c.explainCursor = max(0, min(rows-1, c.explainCursor+browserRows))
+	}
+	return c, nil
+}
+
+// viewExplain renders the reached rows of a table with the chain of rows
+// each was first reached through, detailing the one under the cursor
+func (c *ConfigForm) viewExplain() string {
+	var b strings.Builder
+	subset := c.preview.Subset
+	keys := subset.Rows(c.explainTable).Keys()
+
+	b.WriteString(c.styles.Title.Render("Explain Reached Rows"))
+	b.WriteString("\n\n")
+
+	b.WriteString("Table: ")
+	b.WriteString(c.styles.Info.Render(c.explainTable))
+	b.WriteString("\n")
+	if c.preview.Mode == models.StructureAndDataExcluding {
+		b.WriteString(c.styles.Blurred.Render(fmt.Sprintf("These %d rows are left out of the export", len(keys))))
+	} else {
+		b.WriteString(c.styles.Blurred.Render(fmt.Sprintf("These %d rows are exported", len(keys))))
+	}
+	b.WriteString("\n\n")
+
+	start := max(0, min(c.explainCursor-browserRows/2, len(keys)-browserRows))
+	end := min(len(keys), start+browserRows)
+	for i := start; i < end; i++ {
+		line := engine.Explanation{Path: subset.Path(c.explainTable, keys[i])}.Chain()
+		if i == c.explainCursor {
+			b.WriteString(c.styles.Focused.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	for _, s := range subset.Path(c.explainTable, keys[c.explainCursor]) {
+		b.WriteString(c.styles.Info.Render(s.String()))
+		b.WriteString(c.styles.Blurred.Render("  " + s.Via()))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(c.viewStatus())
+
+	b.WriteString(c.styles.Help.Render("• ↑/↓ to navigate • Esc to go back to the plan"))
+	return b.String()
+}
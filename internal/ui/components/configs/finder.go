@@ -0,0 +1,381 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/engine"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// searchDelay is how long typing must pause before the rows are searched
+	searchDelay = 300 * time.Millisecond
+	// searchTimeout bounds a single row search
+	searchTimeout = 30 * time.Second
+	// finderRows is the number of rows shown at once in the row finder
+	finderRows = 10
+	// finderWidth is the width the result columns are fitted into
+	finderWidth = 100
+	// cellWidth is the widest a result column is rendered
+	cellWidth = 24
+)
+
+// searchDueMsg runs the search typed so far, unless newer input superseded it
+type searchDueMsg struct {
+	seq int
+}
+
+// searchResultMsg carries the rows found by a search
+type searchResultMsg struct {
+	seq     int
+	matches *engine.RowMatches
+	err     error
+}
+
+// newFinderTerm creates the search input of the row finder
+func newFinderTerm(c *ConfigForm) textinput.Model {
+	t := textinput.New()
+	t.Cursor.Style = c.styles.Cursor
+	t.CharLimit = 255
+	t.Placeholder = "Type to search, % for a LIKE pattern"
+	return t
+}
+
+// startFinder switches to the row finder of the root table, reading the
+// schema first when it is not loaded yet
+func (c *ConfigForm) startFinder() (*ConfigForm, tea.Cmd) {
+	src := c.buildConfig().SourceConfig
+	if msg := sourceValidationError(src); msg != "" {
+		c.status = msg
+		c.statusErr = true
+		return c, nil
+	}
+	if strings.TrimSpace(c.inputs[5].Value()) == "" {
+		c.status = "Choose a root table before searching its rows"
+		c.statusErr = true
+		return c, nil
+	}
+
+	c.step = 6
+	c.status = ""
+	c.finderTable = nil
+	c.finderMatches = nil
+	if c.schema != nil && c.schemaSource == src {
+		return c, c.initFinder()
+	}
+	c.schema = nil
+	c.loading = true
+	return c, loadSchema(c.buildConfig())
+}
+
+// initFinder prepares the search of the root table and lists its first rows
+func (c *ConfigForm) initFinder() tea.Cmd {
+	root := strings.TrimSpace(c.inputs[5].Value())
+	t := c.schema.Table(root)
+	switch {
+	case t == nil:
+		c.status = "Table " + root + " does not exist"
+		c.statusErr = true
+		return nil
+	case len(t.PrimaryKey) == 0:
+		c.status = "Table " + root + " has no primary key to pick rows by"
+		c.statusErr = true
+		return nil
+	}
+
+	c.finderTable = t
+	c.finderColumn = 0
+	for i, col := range t.Columns {
+		if !t.IsPrimaryKey(col.Name) && !adapters.IsNumericType(col.DataType) {
+			c.finderColumn = i
+			break
+		}
+	}
+	c.finderPicked = models.DumpConfig{RootPrimaryKey: c.inputs[6].Value()}.RootKeys()
+	c.finderCursor = 0
+	c.finderResults = false
+	c.finderTerm.SetValue("")
+	return tea.Batch(c.finderTerm.Focus(), c.search())
+}
+
+// updateFinder handles input in the row finder
+func (c *ConfigForm) updateFinder(msg tea.Msg) (*ConfigForm, tea.Cmd) {
+	switch msg := msg.(type) {
+	case searchDueMsg:
+		if msg.seq != c.searchSeq || c.finderTable == nil {
+			return c, nil
+		}
+		return c, c.search()
+	case searchResultMsg:
+		if msg.seq != c.searchSeq {
+			return c, nil
+		}
+		c.searching = false
+		if msg.err != nil {
+			c.status = msg.err.Error()
+			c.statusErr = true
+			return c, nil
+		}
+		c.status = ""
+		c.finderMatches = msg.matches
+		c.finderCursor = 0
+		return c, nil
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			c.step = 0
+			c.status = ""
+			return c, c.focusInput(6)
+		}
+		if c.finderTable == nil {
+			return c, nil
+		}
+		switch msg.String() {
+		case "tab", "shift+tab":
+			n := len(c.finderTable.Columns)
+			if msg.String() == "tab" {
+				c.finderColumn = (c.finderColumn + 1) % n
+			} else {
+				c.finderColumn = (c.finderColumn + n - 1) % n
+			}
+			if strings.TrimSpace(c.finderTerm.Value()) == "" {
+				return c, nil
+			}
+			return c, c.search()
+		}
+		if c.finderResults {
+			return c.updateFinderResults(msg)
+		}
+		switch msg.String() {
+		case "down", "enter":
+			if c.finderMatches != nil && len(c.finderMatches.Rows) > 0 {
+				c.finderResults = true
+				c.finderTerm.Blur()
+			}
+			return c, nil
+		}
+	}
+
+	if c.finderResults {
+		return c, nil
+	}
+	before := c.finderTerm.Value()
+	var cmd tea.Cmd
+	c.finderTerm, cmd = c.finderTerm.Update(msg)
+	if c.finderTerm.Value() != before {
+		c.searchSeq++
+		seq := c.searchSeq
+		return c, tea.Batch(cmd, tea.Tick(searchDelay, func(time.Time) tea.Msg {
+			return searchDueMsg{seq: seq}
+		}))
+	}
+	return c, cmd
+}
+
+// updateFinderResults handles keys while the focus is on the found rows
+func (c *ConfigForm) updateFinderResults(msg tea.KeyMsg) (*ConfigForm, tea.Cmd) {
+	n := len(c.finderMatches.Rows)
+	switch msg.String() {
+	case "up":
+		if c.finderCursor == 0 {
+			c.finderResults = false
+			return c, c.finderTerm.Focus()
+		}
+		c.finderCursor--
+	case "down":
+		c.finderCursor = min(n-1, c.finderCursor+1)
+	case "pgup":
+		c.finderCursor = max(0, c.finderCursor-finderRows)
+	case "pgdown":
+		c.finderCursor = min(n-1, c.finderCursor+finderRows)
+	case "/":
+		c.finderResults = false
+		return c, c.finderTerm.Focus()
+	case " ":
+		c.togglePicked(c.pickedKey(c.finderCursor))
+	case "enter":
+		picked := c.finderPicked
+		if len(picked) == 0 {
+			picked = []string{c.pickedKey(c.finderCursor)}
+		}
+		c.inputs[6].SetValue(strings.Join(picked, ","))
+		c.step = 0
+		c.status = ""
+		return c, c.focusInput(7)
+	}
+	return c, nil
+}
+
+// pickedKey renders the key of a found row as typed in the root key input:
+// the bare value, or col=value pairs for a composite primary key
+func (c *ConfigForm) pickedKey(row int) string {
+	key := c.finderMatches.Keys[row]
+	if len(key) == 1 {
+		return key.String()
+	}
+	values := make([]string, len(key))
+	for i, v := range key {
+		values[i] = engine.Key{v}.String()
+	}
+	return models.FormatKeyMap(c.finderTable.PrimaryKey, values)
+}
+
+// togglePicked adds a root key to the picked rows, or removes it when already picked
+func (c *ConfigForm) togglePicked(key string) {
+	if i := slices.Index(c.finderPicked, key); i >= 0 {
+		c.finderPicked = slices.Delete(c.finderPicked, i, i+1)
+		return
+	}
+	c.finderPicked = append(c.finderPicked, key)
+}
+
+// search runs the search typed so far in the background
+func (c *ConfigForm) search() tea.Cmd {
+	c.searchSeq++
+	c.searching = true
+	c.finderResults = false
+
+	seq := c.searchSeq
+	src := c.schemaSource
+	schema := c.schema
+	s := engine.RowSearch{
+		Table:  c.finderTable.Name,
+		Column: c.finderTable.Columns[c.finderColumn].Name,
+		Term:   c.finderTerm.Value(),
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+		defer cancel()
+
+		a, err := adapters.Open(ctx, src)
+		if err != nil {
+			return searchResultMsg{seq: seq, err: err}
+		}
+		defer a.Close()
+
+		matches, err := engine.FindRows(ctx, a, schema, s)
+		return searchResultMsg{seq: seq, matches: matches, err: err}
+	}
+}
+
+// viewFinder renders the row finder
+func (c *ConfigForm) viewFinder() string {
+	var b strings.Builder
+
+	title := "Find Root Rows"
+	if c.finderTable != nil {
+		title += " in " + c.finderTable.Name
+	}
+	b.WriteString(c.styles.Title.Render(title))
+	b.WriteString("\n\n")
+
+	if c.loading {
+		b.WriteString(c.styles.Info.Render("Reading schema..."))
+		b.WriteString("\n\n")
+	}
+	if c.finderTable != nil {
+		b.WriteString("Search by: ")
+		b.WriteString(c.styles.Focused.Render(c.finderTable.Columns[c.finderColumn].Name))
+		b.WriteString("\n")
+		b.WriteString(c.finderTerm.View())
+		b.WriteString("\n\n")
+		b.WriteString(c.viewFinderRows())
+	}
+	b.WriteString(c.viewStatus())
+
+	if c.finderResults {
+		b.WriteString(c.styles.Help.Render("• ↑/↓ to navigate • Space to pick rows • Enter to use the picked rows • / to search • Esc to go back"))
+	} else {
+		b.WriteString(c.styles.Help.Render("• Type to search • Tab to change column • ↓ to the results • Esc to go back"))
+	}
+	return b.String()
+}
+
+// viewFinderRows renders the window of found rows around the cursor
+func (c *ConfigForm) viewFinderRows() string {
+	m := c.finderMatches
+	switch {
+	case m == nil && c.searching:
+		return c.styles.Info.Render("Searching...") + "\n\n"
+	case m == nil:
+		return ""
+	case len(m.Rows) == 0:
+		return c.styles.Blurred.Render("No rows match the search") + "\n\n"
+	}
+
+	// Fit as many columns as the width allows
+	widths := make([]int, 0, len(m.Columns))
+	used := 4
+	for i, col := range m.Columns {
+		w := len(col)
+		for _, row := range m.Rows {
+			w = max(w, len(cellText(row[i])))
+		}
+		w = min(w, cellWidth)
+		if used+w > finderWidth && len(widths) > 0 {
+			break
+		}
+		widths = append(widths, w)
+		used += w + 2
+	}
+
+	var b strings.Builder
+	header := make([]string, len(widths))
+	for i, w := range widths {
+		header[i] = fmt.Sprintf("%-*s", w, truncate(m.Columns[i], w))
+	}
+	b.WriteString(c.styles.Blurred.Render("      " + strings.Join(header, "  ")))
+	b.WriteString("\n")
+
+	start := max(0, min(c.finderCursor-finderRows/2, len(m.Rows)-finderRows))
+	end := min(len(m.Rows), start+finderRows)
+	for r := start; r < end; r++ {
+		cells := make([]string, len(widths))
+		for i, w := range widths {
+			cells[i] = fmt.Sprintf("%-*s", w, truncate(cellText(m.Rows[r][i]), w))
+		}
+		mark := "[ ] "
+		if slices.Contains(c.finderPicked, c.pickedKey(r)) {
+			mark = "[x] "
+		}
+		line := mark + strings.Join(cells, "  ")
+		if c.finderResults && r == c.finderCursor {
+			b.WriteString(c.styles.Focused.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	summary := fmt.Sprintf("%d rows", len(m.Rows))
+	if m.More {
+		summary = fmt.Sprintf("first %d rows, refine the search to see others", len(m.Rows))
+	}
+	if len(c.finderPicked) > 0 {
+		summary += fmt.Sprintf(" • picked: %s", strings.Join(c.finderPicked, ", "))
+	}
+	b.WriteString("\n")
+	b.WriteString(c.styles.Blurred.Render(summary))
+	b.WriteString("\n\n")
+	return b.String()
+}
+
+// cellText renders a column value on a single line
+func cellText(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return fmt.Sprintf("<%d bytes>", len(val))
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05")
+	default:
+		return strings.Join(strings.Fields(fmt.Sprint(val)), " ")
+	}
+}
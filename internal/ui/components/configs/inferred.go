@@ -0,0 +1,208 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/engine"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// inferTimeout bounds the sampling of the inferred relationships
+const inferTimeout = 2 * time.Minute
+
+// inferredMsg carries the relationships inferred from the column names
+type inferredMsg struct {
+	source    models.DatabaseConfig
+	relations []engine.InferredRelation
+	err       error
+}
+
+// startInference switches to the review of inferred relationships, reading
+// the schema first when it is not loaded yet
+func (c *ConfigForm) startInference() (*ConfigForm, tea.Cmd) {
+	src := c.buildConfig().SourceConfig
+	if msg := sourceValidationError(src); msg != "" {
+		c.status = msg
+		c.statusErr = true
+		return c, nil
+	}
+
+	c.step = 8
+	c.status = ""
+	c.inferred = nil
+	c.inferAccepted = nil
+	c.inferCursor = 0
+	if c.schema != nil && c.schemaSource == src {
+		return c, c.infer()
+	}
+	c.schema = nil
+	c.loading = true
+	return c, loadSchema(c.buildConfig())
+}
+
+// infer samples the relationships proposed by the column names of the loaded schema
+func (c *ConfigForm) infer() tea.Cmd {
+	c.inferring = true
+	src := c.schemaSource
+	schema := c.schema
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), inferTimeout)
+		defer cancel()
+
+		a, err := adapters.Open(ctx, src)
+		if err != nil {
+			return inferredMsg{source: src, err: err}
+		}
+		defer a.Close()
+
+		relations, err := engine.New(a.DB(), a, schema).Infer(ctx, engine.InferOptions{})
+		return inferredMsg{source: src, relations: relations, err: err}
+	}
+}
+
+// inferenceDone keeps the inferred relationships, accepting the ones whose
+// sample check passed
+func (c *ConfigForm) inferenceDone(msg inferredMsg) (*ConfigForm, tea.Cmd) {
+	if msg.source != c.schemaSource || c.step != 8 {
+		return c, nil
+	}
+	c.inferring = false
+	if msg.err != nil {
+		c.status = msg.err.Error()
+		c.statusErr = true
+		return c, nil
+	}
+	c.inferred = msg.relations
+	c.inferAccepted = make([]bool, len(msg.relations))
+	for i, r := range msg.relations {
+		c.inferAccepted[i] = r.Valid
+	}
+	return c, nil
+}
+
+// updateInferred handles input in the review of inferred relationships
+func (c *ConfigForm) updateInferred(msg tea.Msg) (*ConfigForm, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return c, nil
+	}
+	switch key.String() {
+	case "esc":
+		c.step = 2
+		c.status = ""
+	case "enter":
+		if c.inferring || c.loading {
+			return c, nil
+		}
+		c.acceptInferred()
+		c.step = 2
+	case "up":
+		c.inferCursor = max(0, c.inferCursor-1)
+	case "down":
+		c.inferCursor = max(0, min(len(c.inferred)-1, c.inferCursor+1))
+	case "pgup":
+		c.inferCursor = max(0, c.inferCursor-browserRows)
+	case "pgdown":
+		c.inferCursor = max(0, min(len(c.inferred)-1, c.inferCursor+browserRows))
+	case " ":
+		if len(c.inferred) > 0 {
+			c.inferAccepted[c.inferCursor] = !c.inferAccepted[c.inferCursor]
+		}
+	}
+	return c, nil
+}
+
+// acceptInferred declares the accepted relationships as virtual foreign keys
+// of the form and of the loaded schema
+func (c *ConfigForm) acceptInferred() {
+	var decls []string
+	for i, r := range c.inferred {
+		if c.inferAccepted[i] {
+			decls = append(decls, r.ForeignKey.Declaration())
+		}
+	}
+	if len(decls) == 0 {
+		c.status = "No inferred relationships accepted"
+		c.statusErr = false
+		return
+	}
+	if err := c.schema.AddVirtualForeignKeys(decls); err != nil {
+		c.status = err.Error()
+		c.statusErr = true
+		return
+	}
+	c.virtual = append(c.virtual, decls...)
+	c.status = fmt.Sprintf("Added %d virtual foreign keys", len(decls))
+	c.statusErr = false
+}
+
+// viewInferred renders the review of inferred relationships
+func (c *ConfigForm) viewInferred() string {
+	var b strings.Builder
+
+	b.WriteString(c.styles.Title.Render("Review Inferred Relationships"))
+	b.WriteString("\n\n")
+
+	switch {
+	case c.loading:
+		b.WriteString(c.styles.Info.Render("Reading schema..."))
+		b.WriteString("\n\n")
+	case c.inferring:
+		b.WriteString(c.styles.Info.Render("Sampling column values..."))
+		b.WriteString("\n\n")
+	case c.inferAccepted != nil:
+		b.WriteString(c.viewInferredList())
+	}
+	b.WriteString(c.viewStatus())
+
+	b.WriteString(c.styles.Help.Render("• Space to accept or reject • ↑/↓ to navigate • Enter to add the accepted ones as virtual foreign keys • Esc to cancel"))
+	return b.String()
+}
+
+// viewInferredList renders the window of inferred relationships around the cursor
+func (c *ConfigForm) viewInferredList() string {
+	var b strings.Builder
+
+	if len(c.inferred) == 0 {
+		b.WriteString(c.styles.Blurred.Render("No column name suggests a relationship that is not declared yet"))
+		b.WriteString("\n\n")
+		return b.String()
+	}
+
+	start := max(0, min(c.inferCursor-browserRows/2, len(c.inferred)-browserRows))
+	end := min(len(c.inferred), start+browserRows)
+	accepted := 0
+	for _, ok := range c.inferAccepted {
+		if ok {
+			accepted++
+		}
+	}
+	for i := start; i < end; i++ {
+		mark := "[ ] "
+		if c.inferAccepted[i] {
+			mark = "[x] "
+		}
+		line := mark + c.inferred[i].ForeignKey.Declaration()
+		if i == c.inferCursor {
+			b.WriteString(c.styles.Focused.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		evidence := "  " + c.inferred[i].Evidence()
+		if c.inferred[i].Valid {
+			b.WriteString(c.styles.Info.Render(evidence))
+		} else {
+			b.WriteString(c.styles.Warning.Render(evidence))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("%d of %d relationships accepted", accepted, len(c.inferred)))
+	b.WriteString("\n\n")
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package configs
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/config"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newJobInputs creates the inputs of the save as job step
+func newJobInputs(c *ConfigForm) []textinput.Model {
+	inputs := make([]textinput.Model, 2)
+	for i := range inputs {
+		t := textinput.New()
+		t.Cursor.Style = c.styles.Cursor
+		t.CharLimit = 255
+		inputs[i] = t
+	}
+	inputs[0].Placeholder = "Job Name (e.g., customer-subset)"
+	inputs[1].Placeholder = "Job File (default: " + config.DefaultJobFiles[0] + ")"
+	return inputs
+}
+
+// Load pre-fills the form from a saved job
+func (c *ConfigForm) Load(cfg models.DumpConfig) {
+	c.SetDatabaseType(cfg.SourceConfig.Type)
+
+	src := cfg.SourceConfig
+	c.inputs[0].SetValue(src.Host)
+	c.inputs[1].SetValue(src.Port)
+	c.inputs[2].SetValue(src.User)
+	c.inputs[3].SetValue(src.Password)
+	if src.Type == models.SQLite3 && src.FilePath != "" {
+		c.inputs[4].SetValue(src.FilePath)
+	} else {
+		c.inputs[4].SetValue(src.Database)
+	}
+	c.inputs[5].SetValue(cfg.RootTable)
+	c.inputs[6].SetValue(cfg.RootPrimaryKey)
+	c.inputs[7].SetValue(cfg.OutputPath)
+
+	c.mode = cfg.Mode
+	c.target = cfg.Target
+	c.includeTables = slices.Clone(cfg.IncludeTables)
+	c.excludeTables = slices.Clone(cfg.ExcludeTables)
+	c.boundaryTables = slices.Clone(cfg.BoundaryTables)
+	c.lookupTables = slices.Clone(cfg.LookupTables)
+	c.seeds = slices.Clone(cfg.Seeds)
+	c.follow = maps.Clone(cfg.Follow)
+	c.virtual = slices.Clone(cfg.VirtualForeignKeys)
+	c.polymorphic = slices.Clone(cfg.Polymorphic)
+	c.snapshot = cfg.Snapshot
+	c.schema = nil
+	c.limits = models.DumpConfig{
+		MaxDepth:     cfg.MaxDepth,
+		DepthLimits:  maps.Clone(cfg.DepthLimits),
+		MaxRows:      cfg.MaxRows,
+		TableMaxRows: maps.Clone(cfg.TableMaxRows),
+		Workers:      cfg.Workers,
+		ChunkRows:    cfg.ChunkRows,
+		BufferRows:   cfg.BufferRows,
+	}
+	c.fileDialect = nil
+	if cfg.TargetConfig != nil {
+		if cfg.Target == models.ToDatabase {
+			c.loadTarget(*cfg.TargetConfig)
+		} else {
+			c.fileDialect = cfg.TargetConfig
+		}
+	}
+}
+
+// startSaveJob switches to the save as job step
+func (c *ConfigForm) startSaveJob() (*ConfigForm, tea.Cmd) {
+	if c.jobInputs == nil {
+		c.jobInputs = newJobInputs(c)
+	}
+	c.step = 3
+	c.status = ""
+	c.jobFocus = 0
+	c.jobInputs[1].Blur()
+	return c, c.jobInputs[0].Focus()
+}
+
+// updateSaveJob handles input in the save as job step
+func (c *ConfigForm) updateSaveJob(msg tea.Msg) (*ConfigForm, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "esc":
+			c.step = 2
+			return c, nil
+		case "tab", "shift+tab", "up", "down":
+			c.jobInputs[c.jobFocus].Blur()
+			c.jobFocus = (c.jobFocus + 1) % len(c.jobInputs)
+			return c, c.jobInputs[c.jobFocus].Focus()
+		case "enter":
+			c.saveJob()
+			return c, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	c.jobInputs[c.jobFocus], cmd = c.jobInputs[c.jobFocus].Update(msg)
+	return c, cmd
+}
+
+// saveJob writes the configuration built so far as a named job
+func (c *ConfigForm) saveJob() {
+	name := strings.TrimSpace(c.jobInputs[0].Value())
+	if name == "" {
+		c.status = "A job name is required"
+		c.statusErr = true
+		return
+	}
+	path := strings.TrimSpace(c.jobInputs[1].Value())
+	if path == "" {
+		path = config.DefaultJobFiles[0]
+	}
+
+	if err := config.SaveJob(path, name, config.WithPasswordRefs(c.buildConfig())); err != nil {
+		c.status = err.Error()
+		c.statusErr = true
+		return
+	}
+	c.status = "Saved job " + name + " to " + path
+	c.statusErr = false
+	c.step = 2
+}
+
+// viewSaveJob renders the save as job step
+func (c *ConfigForm) viewSaveJob() string {
+	var b strings.Builder
+
+	b.WriteString(c.styles.Title.Render("Save Configuration as Job"))
+	b.WriteString("\n\n")
+
+	labels := []string{"Job Name:", "Job File:"}
+	for i := range c.jobInputs {
+		b.WriteString(labels[i] + "\n")
+		b.WriteString(c.jobInputs[i].View())
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(c.viewStatus())
+	b.WriteString(c.styles.Help.Render("• Passwords are saved as $RELTRACE_PASSWORD references"))
+	b.WriteString("\n")
+	b.WriteString(c.styles.Help.Render("• Tab to navigate • Enter to save • Esc to go back"))
+	return b.String()
+}
+
+// viewStatus renders the outcome of the last save, if any
+func (c *ConfigForm) viewStatus() string {
+	if c.status == "" {
+		return ""
+	}
+	if c.statusErr {
+		return c.styles.Error.Render("✗ "+c.status) + "\n\n"
+	}
+	return c.styles.Success.Render("✓ "+c.status) + "\n\n"
+}
@@ -1,6 +1,7 @@
 package configs
 
 import (
+	"github.com/antoniosarro/reltrace/internal/database/engine"
 	"github.com/antoniosarro/reltrace/internal/database/models"
 	"github.com/antoniosarro/reltrace/internal/ui/styles"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -19,7 +20,71 @@ type ConfigForm struct {
 	dbType     models.DatabaseType
 	mode       models.DumpMode
 	target     models.DumpTarget
-	step       int // 0: db config, 1: mode selection, 2: target selection
+	step       int // 0: db config, 1: mode selection, 2: target selection, 3: save as job, 4: target db config, 5: schema browser, 6: row finder, 7: table checklist, 8: inferred relationships, 9: plan preview, 10: row provenance
+
+	// Target database step
+	targetType   models.DatabaseType
+	targetInputs []textinput.Model
+	targetFocus  int
+	checking     bool
+
+	// SQL dialect of file exports and traversal settings, carried over from a loaded job
+	fileDialect *models.DatabaseConfig
+	seeds       []models.Seed
+	follow      map[string]models.FollowRule
+	limits      models.DumpConfig // depth limits, row budgets and extraction workers
+	virtual     []string          // virtual foreign keys, applied to the loaded schema
+	polymorphic []models.PolymorphicRelation
+	snapshot    models.SnapshotMode
+
+	// Schema browser step
+	schema        *models.Schema
+	schemaSource  models.DatabaseConfig // connection the schema was read from
+	estimates     map[string]int64
+	loading       bool
+	browserFilter textinput.Model
+	browserCursor int
+
+	// Row finder step
+	finderTable   *models.Table
+	finderColumn  int
+	finderTerm    textinput.Model
+	finderMatches *engine.RowMatches
+	finderCursor  int
+	finderResults bool     // focus is on the found rows rather than the search input
+	finderPicked  []string // root keys picked so far
+	searchSeq     int
+	searching     bool
+
+	// Table checklist step
+	includeTables  []string
+	excludeTables  []string
+	boundaryTables []string
+	lookupTables   []string
+	tablesFilter   textinput.Model
+	tablesCursor   int
+
+	// Inferred relationships step
+	inferred      []engine.InferredRelation
+	inferAccepted []bool
+	inferCursor   int
+	inferring     bool
+
+	// Plan preview step
+	preview       *engine.Plan
+	previewCursor int
+	previewing    bool
+	previewSeq    int
+
+	// Row provenance step
+	explainTable  string
+	explainCursor int
+
+	// Save as job step
+	jobInputs []textinput.Model
+	jobFocus  int
+	status    string
+	statusErr bool
 }
 
 // NewConfigForm creates a new configuration form
@@ -54,10 +119,15 @@ func NewConfigForm(s *styles.Styles) *ConfigForm {
 
 		inputs[i] = t
 	}
-	return &ConfigForm{
+	c := &ConfigForm{
 		styles: s,
 		inputs: inputs,
 		mode:   models.StructureAndData, // Default mode
 		target: models.ToFile,           // Default target
 	}
+	c.targetInputs = newTargetInputs(c)
+	c.browserFilter = newBrowserFilter(c)
+	c.finderTerm = newFinderTerm(c)
+	c.tablesFilter = newTablesFilter(c)
+	return c
 }
@@ -0,0 +1,194 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/engine"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// previewTimeout bounds the traversal computing the plan of a dump
+const previewTimeout = 2 * time.Minute
+
+// previewMsg carries the plan of the configuration being previewed
+type previewMsg struct {
+	seq  int
+	plan *engine.Plan
+	err  error
+}
+
+// startPreview switches to the plan of the dump, computed from key columns
+// only, which is confirmed before the dump starts
+func (c *ConfigForm) startPreview() (*ConfigForm, tea.Cmd) {
+	c.step = 9
+	c.status = ""
+	c.preview = nil
+	c.previewCursor = 0
+	c.previewing = true
+	c.previewSeq++
+	return c, planDump(c.previewSeq, c.buildConfig())
+}
+
+// planDump computes the plan of a dump configuration
+func planDump(seq int, cfg models.DumpConfig) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), previewTimeout)
+		defer cancel()
+
+		sess, err := engine.OpenDump(ctx, cfg)
+		if err != nil {
+			return previewMsg{seq: seq, err: err}
+		}
+		defer sess.Close()
+		sess.Engine.RecordOrigins(true)
+
+		plan, err := sess.Plan(ctx, cfg)
+		return previewMsg{seq: seq, plan: plan, err: err}
+	}
+}
+
+// updatePreview handles input in the plan preview
+func (c *ConfigForm) updatePreview(msg tea.Msg) (*ConfigForm, tea.Cmd) {
+	switch msg := msg.(type) {
+	case previewMsg:
+		if msg.seq != c.previewSeq {
+			return c, nil
+		}
+		c.previewing = false
+		if msg.err != nil {
+			c.status = msg.err.Error()
+			c.statusErr = true
+			return c, nil
+		}
+		c.preview = msg.plan
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			c.previewSeq++
+			c.previewing = false
+			c.status = ""
+			if c.target == models.ToDatabase {
+				return c.startTargetConfig()
+			}
+			c.step = 2
+		case "enter":
+			if c.preview == nil {
+				return c, nil
+			}
+			return c, c.submitConfig()
+		case "right", "e":
+			return c.startExplain()
+		case "up":
+			c.previewCursor = max(0, c.previewCursor-1)
+		case "down":
+			c.previewCursor = max(0, min(c.previewRows()-1, c.previewCursor+1))
+		case "pgup":
+			c.previewCursor = max(0, c.previewCursor-browserRows)
+		case "pgdown":
+			c.previewCursor = max(0, min(c.previewRows()-1, c.previewCursor+browserRows))
+		}
+	}
+	return c, nil
+}
+
+// previewRows returns the number of tables in the previewed plan
+func (c *ConfigForm) previewRows() int {
+	if c.preview == nil {
+		return 0
+	}
+	return len(c.preview.Tables)
+}
+
+// viewPreview renders the plan preview
+func (c *ConfigForm) viewPreview() string {
+	var b strings.Builder
+
+	b.WriteString(c.styles.Title.Render("Preview Export Plan"))
+	b.WriteString("\n\n")
+
+	b.WriteString("Selected Mode: ")
+	b.WriteString(c.styles.Info.Render(c.mode.String()))
+	b.WriteString("\n\n")
+
+	switch {
+	case c.previewing:
+		b.WriteString(c.styles.Info.Render("Following relationships..."))
+		b.WriteString("\n\n")
+	case c.preview != nil:
+		b.WriteString(c.viewPlan())
+	}
+	b.WriteString(c.viewStatus())
+
+	help := "• ↑/↓ to navigate • Enter to start the export • Esc to go back"
+	if c.preview != nil && c.preview.Subset != nil {
+		help = "• ↑/↓ to navigate • → to explain the reached rows of a table • Enter to start the export • Esc to go back"
+	}
+	b.WriteString(c.styles.Help.Render(help))
+	return b.String()
+}
+
+// viewPlan renders the window of previewed tables and the plan totals
+func (c *ConfigForm) viewPlan() string {
+	var b strings.Builder
+	plan := c.preview
+	excluding := plan.Mode == models.StructureAndDataExcluding
+
+	width := len("TABLE")
+	for _, t := range plan.Tables {
+		width = max(width, len(t.Name))
+	}
+	header := fmt.Sprintf("  %-*s  %-6s  %8s", width, "TABLE", "FILTER", "ROWS")
+	if excluding {
+		header += fmt.Sprintf("  %8s", "LEFT OUT")
+	}
+	header += fmt.Sprintf("  %5s  %10s", "DEPTH", "EST. SIZE")
+	b.WriteString(c.styles.Blurred.Render(header))
+	b.WriteString("\n")
+
+	start := max(0, min(c.previewCursor-browserRows/2, len(plan.Tables)-browserRows))
+	end := min(len(plan.Tables), start+browserRows)
+	for i := start; i < end; i++ {
+		t := plan.Tables[i]
+		depth := "-"
+		if t.Depth >= 0 {
+			depth = fmt.Sprint(t.Depth)
+		}
+		line := fmt.Sprintf("%-*s  %-6s  %8d", width, t.Name, t.Filter, t.Rows)
+		if excluding {
+			line += fmt.Sprintf("  %8d", t.Excluded)
+		}
+		line += fmt.Sprintf("  %5s  %10s", depth, engine.FormatBytes(t.Bytes))
+		if i == c.previewCursor {
+			b.WriteString(c.styles.Focused.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	summary := fmt.Sprintf("%d rows from %d tables, about %s", plan.Rows, len(plan.Tables), engine.FormatBytes(plan.Bytes))
+	if excluding {
+		summary += fmt.Sprintf(", %d rows left out", plan.Excluded)
+	}
+	b.WriteString(c.styles.Info.Render(summary))
+	b.WriteString("\n")
+	if len(plan.Edges) > 0 {
+		b.WriteString(fmt.Sprintf("%d relationships followed\n", len(plan.Edges)))
+	}
+	for i, w := range plan.Warnings {
+		if i == scopeWarnings {
+			b.WriteString(c.styles.Warning.Render(fmt.Sprintf("⚠ …and %d more", len(plan.Warnings)-i)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString(c.styles.Warning.Render("⚠ " + w))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -2,6 +2,8 @@ package configs
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/antoniosarro/reltrace/internal/database/models"
@@ -32,7 +34,11 @@ func (c *ConfigForm) viewDatabaseConfig() string {
 			continue
 		}
 
-		b.WriteString(labels[i] + "\n")
+		label := labels[i]
+		if i == 6 {
+			label = c.rootKeyLabel()
+		}
+		b.WriteString(label + "\n")
 		b.WriteString(c.inputs[i].View())
 		b.WriteString("\n\n")
 	}
@@ -45,10 +51,30 @@ func (c *ConfigForm) viewDatabaseConfig() string {
 	}
 	b.WriteString(button + "\n\n")
 
-	b.WriteString(c.styles.Help.Render("• Tab to navigate • Enter to continue • Ctrl+C to quit"))
+	b.WriteString(c.viewStatus())
+	b.WriteString(c.styles.Help.Render("• Tab to navigate • Enter on Root Table or Primary Key to browse tables and rows • Enter to continue • Ctrl+C to quit"))
 	return b.String()
 }
 
+// rootKeyLabel labels the primary key input with the key columns of the root table, once known
+func (c *ConfigForm) rootKeyLabel() string {
+	key, ok := c.rootKeyColumns()
+	switch {
+	case !ok:
+		return "Primary Key:"
+	case len(key) == 0:
+		return "Primary Key (table has no primary key):"
+	case len(key) > 1:
+		pairs := make([]string, len(key))
+		for i, col := range key {
+			pairs[i] = col + "=…"
+		}
+		return "Primary Key (" + strings.Join(pairs, models.KeyPairSeparator) + "):"
+	default:
+		return "Primary Key (" + strings.Join(key, ", ") + "):"
+	}
+}
+
 // viewModeSelection renders the mode selection step
 func (c *ConfigForm) viewModeSelection() string {
 	var b strings.Builder
@@ -91,7 +117,34 @@ func (c *ConfigForm) viewTargetSelection() string {
 
 	b.WriteString("Selected Mode: ")
 	b.WriteString(c.styles.Info.Render(c.mode.String()))
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	b.WriteString("Tables: ")
+	b.WriteString(c.styles.Info.Render(c.tablesSummary()))
+	b.WriteString("\n")
+	if len(c.seeds) > 0 && (c.mode == models.StructureAndDataExcluding || c.mode == models.StructureAndDataIncludingOnly) {
+		seeds := make([]string, len(c.seeds))
+		for i, s := range c.seeds {
+			seeds[i] = s.String()
+		}
+		b.WriteString("Further seeds: ")
+		b.WriteString(c.styles.Info.Render(strings.Join(seeds, "; ")))
+		b.WriteString("\n")
+	}
+	if len(c.follow) > 0 && (c.mode == models.StructureAndDataExcluding || c.mode == models.StructureAndDataIncludingOnly) {
+		rules := make([]string, 0, len(c.follow))
+		for _, name := range slices.Sorted(maps.Keys(c.follow)) {
+			rules = append(rules, name+"="+c.follow[name].String())
+		}
+		b.WriteString("Follow rules: ")
+		b.WriteString(c.styles.Info.Render(strings.Join(rules, "; ")))
+		b.WriteString("\n")
+	}
+	if len(c.virtual) > 0 && (c.mode == models.StructureAndDataExcluding || c.mode == models.StructureAndDataIncludingOnly) {
+		b.WriteString("Virtual foreign keys: ")
+		b.WriteString(c.styles.Info.Render(strings.Join(c.virtual, "; ")))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
 	targets := []struct {
 		target models.DumpTarget
@@ -113,6 +166,7 @@ func (c *ConfigForm) viewTargetSelection() string {
 	}
 
 	b.WriteString("\n")
-	b.WriteString(c.styles.Help.Render("• Press 1-2 to select target • Backspace to go back"))
+	b.WriteString(c.viewStatus())
+	b.WriteString(c.styles.Help.Render("• Press 1-2 to select target • T to choose tables • R to review inferred relationships • S to save as job • Backspace to go back"))
 	return b.String()
 }
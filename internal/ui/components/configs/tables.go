@@ -0,0 +1,336 @@
+package configs
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/antoniosarro/reltrace/internal/database/engine"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// scopeWarnings is the number of scope warnings shown below the checklist
+const scopeWarnings = 4
+
+// newTablesFilter creates the filter input of the table checklist
+func newTablesFilter(c *ConfigForm) textinput.Model {
+	t := textinput.New()
+	t.Cursor.Style = c.styles.Cursor
+	t.CharLimit = 255
+	t.Placeholder = "Type to filter, or a glob such as audit_*"
+	t.Prompt = "/ "
+	return t
+}
+
+// startTables switches to the table checklist, reading the schema first
+// when it is not loaded yet
+func (c *ConfigForm) startTables() (*ConfigForm, tea.Cmd) {
+	src := c.buildConfig().SourceConfig
+	if msg := sourceValidationError(src); msg != "" {
+		c.status = msg
+		c.statusErr = true
+		return c, nil
+	}
+
+	c.step = 7
+	c.status = ""
+	c.tablesFilter.SetValue("")
+	c.tablesCursor = 0
+	cmd := c.tablesFilter.Focus()
+	if c.schema != nil && c.schemaSource == src {
+		return c, cmd
+	}
+	c.schema = nil
+	c.loading = true
+	return c, tea.Batch(cmd, loadSchema(c.buildConfig()))
+}
+
+// updateTables handles input in the table checklist
+func (c *ConfigForm) updateTables(msg tea.Msg) (*ConfigForm, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		names := c.filteredTables()
+		switch msg.String() {
+		case "esc", "enter":
+			c.step = 2
+			c.status = ""
+			return c, nil
+		case "up":
+			c.tablesCursor = max(0, c.tablesCursor-1)
+			return c, nil
+		case "down":
+			c.tablesCursor = max(0, min(len(names)-1, c.tablesCursor+1))
+			return c, nil
+		case "pgup":
+			c.tablesCursor = max(0, c.tablesCursor-browserRows)
+			return c, nil
+		case "pgdown":
+			c.tablesCursor = max(0, min(len(names)-1, c.tablesCursor+browserRows))
+			return c, nil
+		case " ":
+			if len(names) > 0 {
+				c.toggleTable(names[c.tablesCursor])
+			}
+			return c, nil
+		case "ctrl+e":
+			c.addTablePattern(&c.excludeTables)
+			return c, nil
+		case "ctrl+o":
+			c.addTablePattern(&c.includeTables)
+			return c, nil
+		case "ctrl+b":
+			if len(names) > 0 {
+				c.toggleMarker(&c.boundaryTables, "boundary", names[c.tablesCursor])
+			}
+			return c, nil
+		case "ctrl+l":
+			if len(names) > 0 {
+				c.toggleMarker(&c.lookupTables, "lookup", names[c.tablesCursor])
+			}
+			return c, nil
+		case "ctrl+r":
+			c.includeTables = nil
+			c.excludeTables = nil
+			c.boundaryTables = nil
+			c.lookupTables = nil
+			c.status = ""
+			return c, nil
+		}
+	}
+
+	before := c.tablesFilter.Value()
+	var cmd tea.Cmd
+	c.tablesFilter, cmd = c.tablesFilter.Update(msg)
+	if c.tablesFilter.Value() != before {
+		c.tablesCursor = 0
+	}
+	return c, cmd
+}
+
+// tableDumped reports whether a table is covered by the include and exclude lists
+func (c *ConfigForm) tableDumped(name string) bool {
+	return (len(c.includeTables) == 0 || models.MatchTable(c.includeTables, name)) &&
+		!models.MatchTable(c.excludeTables, name)
+}
+
+// toggleTable includes or excludes a single table, editing the lists as little as possible
+func (c *ConfigForm) toggleTable(name string) {
+	c.status = ""
+	if c.tableDumped(name) {
+		if i := slices.Index(c.includeTables, name); i >= 0 && len(c.includeTables) > 1 {
+			c.includeTables = slices.Delete(c.includeTables, i, i+1)
+			return
+		}
+		c.excludeTables = append(c.excludeTables, name)
+		return
+	}
+
+	if i := slices.Index(c.excludeTables, name); i >= 0 {
+		c.excludeTables = slices.Delete(c.excludeTables, i, i+1)
+	}
+	for _, p := range c.excludeTables {
+		if models.MatchTable([]string{p}, name) {
+			c.status = fmt.Sprintf("%s is excluded by the pattern %s; press Ctrl+R to reset the lists", name, p)
+			c.statusErr = true
+			return
+		}
+	}
+	if !c.tableDumped(name) {
+		c.includeTables = append(c.includeTables, name)
+	}
+}
+
+// toggleMarker marks a table as boundary or lookup table, or removes the mark
+func (c *ConfigForm) toggleMarker(list *[]string, marker, name string) {
+	c.status = ""
+	if i := slices.Index(*list, name); i >= 0 {
+		*list = slices.Delete(*list, i, i+1)
+		return
+	}
+	if c.mode == models.StructureAndDataExcluding {
+		c.status = fmt.Sprintf("%s tables apply to the including mode only: the excluding mode leaves out every row depending on the seeds", marker)
+		c.statusErr = true
+		return
+	}
+	for _, p := range *list {
+		if models.MatchTable([]string{p}, name) {
+			c.status = fmt.Sprintf("%s is a %s table by the pattern %s; press Ctrl+R to reset the lists", name, marker, p)
+			c.statusErr = true
+			return
+		}
+	}
+	*list = append(*list, name)
+}
+
+// addTablePattern adds the filter text to a pattern list
+func (c *ConfigForm) addTablePattern(list *[]string) {
+	pattern := strings.TrimSpace(c.tablesFilter.Value())
+	if pattern == "" {
+		c.status = "Type a table name or glob pattern first"
+		c.statusErr = true
+		return
+	}
+	if err := models.ValidateTablePatterns([]string{pattern}); err != nil {
+		c.status = err.Error()
+		c.statusErr = true
+		return
+	}
+	if !slices.Contains(*list, pattern) {
+		*list = append(*list, pattern)
+	}
+	c.tablesFilter.SetValue("")
+	c.tablesCursor = 0
+	c.status = ""
+}
+
+// filteredTables returns the names of the tables matching the filter, which
+// is a glob when it holds wildcards and a substring otherwise
+func (c *ConfigForm) filteredTables() []string {
+	if c.schema == nil {
+		return nil
+	}
+	filter := strings.TrimSpace(c.tablesFilter.Value())
+	glob := strings.ContainsAny(filter, "*?[")
+
+	var names []string
+	for _, t := range c.schema.Tables {
+		switch {
+		case filter == "":
+		case glob:
+			if ok, _ := path.Match(filter, t.Name); !ok {
+				continue
+			}
+		case !strings.Contains(strings.ToLower(t.Name), strings.ToLower(filter)):
+			continue
+		}
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// tablesSummary describes the tables the dump covers, for the target step
+func (c *ConfigForm) tablesSummary() string {
+	if len(c.includeTables) == 0 && len(c.excludeTables) == 0 && len(c.boundaryTables) == 0 && len(c.lookupTables) == 0 {
+		return "all"
+	}
+	var parts []string
+	if len(c.includeTables) > 0 {
+		parts = append(parts, "only "+strings.Join(c.includeTables, ", "))
+	}
+	if len(c.excludeTables) > 0 {
+		parts = append(parts, "except "+strings.Join(c.excludeTables, ", "))
+	}
+	if len(c.boundaryTables) > 0 {
+		parts = append(parts, "boundary "+strings.Join(c.boundaryTables, ", "))
+	}
+	if len(c.lookupTables) > 0 {
+		parts = append(parts, "lookup "+strings.Join(c.lookupTables, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// viewTables renders the table checklist
+func (c *ConfigForm) viewTables() string {
+	var b strings.Builder
+
+	b.WriteString(c.styles.Title.Render("Choose Tables"))
+	b.WriteString("\n\n")
+
+	if c.loading {
+		b.WriteString(c.styles.Info.Render("Reading schema..."))
+		b.WriteString("\n\n")
+	}
+	if c.schema != nil {
+		b.WriteString(c.tablesFilter.View())
+		b.WriteString("\n\n")
+		b.WriteString(c.viewTableChecklist())
+	}
+	b.WriteString(c.viewStatus())
+
+	b.WriteString(c.styles.Help.Render("• Space to toggle • Ctrl+E to exclude the pattern • Ctrl+O to include only the pattern • Ctrl+R to reset"))
+	b.WriteString("\n")
+	b.WriteString(c.styles.Help.Render("• Ctrl+B to mark a boundary table, dumped where referenced but not expanded • Ctrl+L to mark a lookup table, dumped in full"))
+	b.WriteString("\n")
+	b.WriteString(c.styles.Help.Render("• Type to filter • ↑/↓ to navigate • Enter or Esc when done"))
+	return b.String()
+}
+
+// viewTableChecklist renders the window of tables around the cursor and the
+// consequences of the current lists
+func (c *ConfigForm) viewTableChecklist() string {
+	var b strings.Builder
+
+	names := c.filteredTables()
+	start := max(0, min(c.tablesCursor-browserRows/2, len(names)-browserRows))
+	end := min(len(names), start+browserRows)
+	for i := start; i < end; i++ {
+		mark := "[ ] "
+		if c.tableDumped(names[i]) {
+			mark = "[x] "
+		}
+		if i == c.tablesCursor {
+			b.WriteString(c.styles.Focused.Render("> " + mark + names[i]))
+		} else {
+			b.WriteString("  " + mark + names[i])
+		}
+		switch {
+		case models.MatchTable(c.lookupTables, names[i]):
+			b.WriteString(c.styles.Info.Render("  lookup"))
+		case models.MatchTable(c.boundaryTables, names[i]):
+			b.WriteString(c.styles.Info.Render("  boundary"))
+		}
+		b.WriteString("\n")
+	}
+	if len(names) == 0 {
+		b.WriteString(c.styles.Blurred.Render("No tables match the filter"))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	scope, err := engine.NewScope(c.schema, c.includeTables, c.excludeTables)
+	if err != nil {
+		return b.String() + c.styles.Error.Render("✗ "+err.Error()) + "\n\n"
+	}
+	warnings := scope.Warnings
+	if len(c.boundaryTables) > 0 || len(c.lookupTables) > 0 {
+		policy, err := engine.NewPolicy(engine.NewGraph(c.schema), scope, models.DumpConfig{
+			BoundaryTables: c.boundaryTables,
+			LookupTables:   c.lookupTables,
+		})
+		if err != nil {
+			return b.String() + c.styles.Error.Render("✗ "+err.Error()) + "\n\n"
+		}
+		warnings = append(slices.Clone(warnings), policy.Warnings...)
+	}
+	b.WriteString(fmt.Sprintf("%d of %d tables dumped", len(scope.Tables), len(c.schema.Tables)))
+	b.WriteString("\n")
+	if len(c.includeTables) > 0 {
+		b.WriteString(c.styles.Blurred.Render("Include: " + strings.Join(c.includeTables, ", ")))
+		b.WriteString("\n")
+	}
+	if len(c.excludeTables) > 0 {
+		b.WriteString(c.styles.Blurred.Render("Exclude: " + strings.Join(c.excludeTables, ", ")))
+		b.WriteString("\n")
+	}
+	if len(c.boundaryTables) > 0 {
+		b.WriteString(c.styles.Blurred.Render("Boundary: " + strings.Join(c.boundaryTables, ", ")))
+		b.WriteString("\n")
+	}
+	if len(c.lookupTables) > 0 {
+		b.WriteString(c.styles.Blurred.Render("Lookup: " + strings.Join(c.lookupTables, ", ")))
+		b.WriteString("\n")
+	}
+	for i, w := range warnings {
+		if i == scopeWarnings {
+			b.WriteString(c.styles.Warning.Render(fmt.Sprintf("⚠ …and %d more", len(warnings)-i)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString(c.styles.Warning.Render("⚠ " + w))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
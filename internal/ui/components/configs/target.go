@@ -0,0 +1,292 @@
+package configs
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/adapters"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// targetCheckTimeout bounds the connectivity check of the target database
+const targetCheckTimeout = 10 * time.Second
+
+// targetTypes are the engines a dump can be imported into
+var targetTypes = []models.DatabaseType{models.MySQL, models.PostgreSQL, models.SQLite3}
+
+// targetTypeFocus is the focus position of the engine selector; the inputs
+// follow it and the button comes last
+const targetTypeFocus = 0
+
+// targetCheckedMsg carries the outcome of the target connectivity check
+type targetCheckedMsg struct {
+	err error
+}
+
+// newTargetInputs creates the inputs of the target database step
+func newTargetInputs(c *ConfigForm) []textinput.Model {
+	inputs := make([]textinput.Model, 5)
+	for i := range inputs {
+		t := textinput.New()
+		t.Cursor.Style = c.styles.Cursor
+		t.CharLimit = 255
+		inputs[i] = t
+	}
+	inputs[3].EchoMode = textinput.EchoPassword
+	inputs[3].EchoCharacter = '•'
+	return inputs
+}
+
+// startTargetConfig switches to the target database step
+func (c *ConfigForm) startTargetConfig() (*ConfigForm, tea.Cmd) {
+	if c.targetType == "" {
+		c.targetType = c.dbType
+	}
+	c.updateTargetPlaceholders()
+	c.step = 4
+	c.status = ""
+	c.targetFocus = targetTypeFocus
+	return c, c.focusTarget()
+}
+
+// updateTargetConfig handles input in the target database step
+func (c *ConfigForm) updateTargetConfig(msg tea.Msg) (*ConfigForm, tea.Cmd) {
+	switch msg := msg.(type) {
+	case targetCheckedMsg:
+		c.checking = false
+		if msg.err != nil {
+			c.status = msg.err.Error()
+			c.statusErr = true
+			return c, nil
+		}
+		return c.startPreview()
+	case tea.KeyMsg:
+		if c.checking {
+			return c, nil
+		}
+		switch msg.String() {
+		case "esc":
+			c.step = 2
+			c.status = ""
+			return c, nil
+		case "left", "right":
+			if c.targetFocus == targetTypeFocus {
+				c.cycleTargetType(msg.String() == "right")
+				return c, nil
+			}
+		case "tab", "down":
+			return c, c.moveTargetFocus(1)
+		case "shift+tab", "up":
+			return c, c.moveTargetFocus(-1)
+		case "enter":
+			if c.targetFocus != c.targetButtonFocus() {
+				return c, c.moveTargetFocus(1)
+			}
+			if !c.validateTargetDbConfig() {
+				return c, nil
+			}
+			c.checking = true
+			c.status = ""
+			return c, checkTarget(c.buildTargetConfig())
+		}
+	}
+
+	if i := c.targetFocus - 1; i >= 0 && i < len(c.targetInputs) {
+		var cmd tea.Cmd
+		c.targetInputs[i], cmd = c.targetInputs[i].Update(msg)
+		return c, cmd
+	}
+	return c, nil
+}
+
+// cycleTargetType selects the next or previous target engine
+func (c *ConfigForm) cycleTargetType(forward bool) {
+	current := 0
+	for i, t := range targetTypes {
+		if t == c.targetType {
+			current = i
+		}
+	}
+	step := len(targetTypes) - 1
+	if forward {
+		step = 1
+	}
+	c.targetType = targetTypes[(current+step)%len(targetTypes)]
+	c.updateTargetPlaceholders()
+}
+
+// moveTargetFocus moves the focus of the target step, skipping the inputs SQLite does not use
+func (c *ConfigForm) moveTargetFocus(delta int) tea.Cmd {
+	positions := c.targetButtonFocus() + 1
+	for {
+		c.targetFocus = (c.targetFocus + delta + positions) % positions
+		if i := c.targetFocus - 1; i < 0 || i >= len(c.targetInputs) || !c.targetInputHidden(i) {
+			break
+		}
+	}
+	return c.focusTarget()
+}
+
+// focusTarget focuses the input under the target focus and blurs the others
+func (c *ConfigForm) focusTarget() tea.Cmd {
+	var cmd tea.Cmd
+	for i := range c.targetInputs {
+		if i == c.targetFocus-1 {
+			cmd = c.targetInputs[i].Focus()
+			c.targetInputs[i].PromptStyle = c.styles.Focused
+			c.targetInputs[i].TextStyle = c.styles.Focused
+		} else {
+			c.targetInputs[i].Blur()
+			c.targetInputs[i].PromptStyle = c.styles.Blurred
+			c.targetInputs[i].TextStyle = c.styles.Blurred
+		}
+	}
+	return cmd
+}
+
+func (c *ConfigForm) targetButtonFocus() int {
+	return len(c.targetInputs) + 1
+}
+
+// targetInputHidden reports whether a target input is irrelevant for the selected engine
+func (c *ConfigForm) targetInputHidden(i int) bool {
+	return c.targetType == models.SQLite3 && i < 4
+}
+
+// updateTargetPlaceholders updates the target placeholders for the selected engine
+func (c *ConfigForm) updateTargetPlaceholders() {
+	c.targetInputs[0].Placeholder = "Host (e.g., localhost)"
+	c.targetInputs[1].Placeholder = "Port (default: " + c.targetType.DefaultPort() + ")"
+	c.targetInputs[2].Placeholder = "Username"
+	c.targetInputs[3].Placeholder = "Password"
+	c.targetInputs[4].Placeholder = "Database Name"
+	if c.targetType == models.SQLite3 {
+		c.targetInputs[4].Placeholder = "SQLite File Path (e.g., ./copy.db)"
+	}
+}
+
+// loadTarget pre-fills the target step from a saved connection
+func (c *ConfigForm) loadTarget(cfg models.DatabaseConfig) {
+	c.targetType = cfg.Type
+	c.targetInputs[0].SetValue(cfg.Host)
+	c.targetInputs[1].SetValue(cfg.Port)
+	c.targetInputs[2].SetValue(cfg.User)
+	c.targetInputs[3].SetValue(cfg.Password)
+	if cfg.Type == models.SQLite3 && cfg.FilePath != "" {
+		c.targetInputs[4].SetValue(cfg.FilePath)
+	} else {
+		c.targetInputs[4].SetValue(cfg.Database)
+	}
+	c.updateTargetPlaceholders()
+}
+
+// buildTargetConfig returns the target connection entered in the form
+func (c *ConfigForm) buildTargetConfig() models.DatabaseConfig {
+	cfg := models.DatabaseConfig{Type: c.targetType}
+	if c.targetType == models.SQLite3 {
+		cfg.FilePath = strings.TrimSpace(c.targetInputs[4].Value())
+		return cfg
+	}
+
+	cfg.Host = strings.TrimSpace(c.targetInputs[0].Value())
+	cfg.Port = strings.TrimSpace(c.targetInputs[1].Value())
+	cfg.User = strings.TrimSpace(c.targetInputs[2].Value())
+	cfg.Password = c.targetInputs[3].Value()
+	cfg.Database = strings.TrimSpace(c.targetInputs[4].Value())
+	if cfg.Port == "" {
+		cfg.Port = c.targetType.DefaultPort()
+	}
+	return cfg
+}
+
+// checkTarget connects to the target database and reports whether it is reachable
+func checkTarget(cfg models.DatabaseConfig) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), targetCheckTimeout)
+		defer cancel()
+
+		target, err := adapters.Open(ctx, cfg)
+		if err != nil {
+			return targetCheckedMsg{err: err}
+		}
+		return targetCheckedMsg{err: target.Close()}
+	}
+}
+
+// viewTargetConfig renders the target database step
+func (c *ConfigForm) viewTargetConfig() string {
+	var b strings.Builder
+
+	b.WriteString(c.styles.Title.Render("Configure Target Database"))
+	b.WriteString("\n\n")
+
+	b.WriteString("Engine:\n")
+	for _, t := range targetTypes {
+		name := strings.ToUpper(string(t))
+		switch {
+		case t == c.targetType && c.targetFocus == targetTypeFocus:
+			b.WriteString(c.styles.GetDatabaseStyle(string(t)).Render("> " + name))
+		case t == c.targetType:
+			b.WriteString(c.styles.Focused.Render("> " + name))
+		default:
+			b.WriteString(c.styles.Blurred.Render("  " + name))
+		}
+		b.WriteString("  ")
+	}
+	b.WriteString("\n\n")
+
+	labels := []string{"Host:", "Port:", "Username:", "Password:", "Database/File:"}
+	for i := range c.targetInputs {
+		if c.targetInputHidden(i) {
+			continue
+		}
+		b.WriteString(labels[i] + "\n")
+		b.WriteString(c.targetInputs[i].View())
+		b.WriteString("\n\n")
+	}
+
+	label := "[ Connect & Preview ]"
+	switch {
+	case c.checking:
+		b.WriteString(c.styles.Info.Render("Checking connection..."))
+	case c.targetFocus == c.targetButtonFocus():
+		b.WriteString(c.styles.Button.Render(label))
+	default:
+		b.WriteString(c.styles.Blurred.Render(label))
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString(c.viewStatus())
+	b.WriteString(c.styles.Help.Render("• ←/→ to change engine • Tab to navigate • Enter to continue • Esc to go back"))
+	return b.String()
+}
+
+// targetValidationError returns why the target connection is incomplete, or an empty string
+func (c *ConfigForm) targetValidationError() string {
+	cfg := c.buildTargetConfig()
+	if cfg.Type == models.SQLite3 {
+		if cfg.FilePath == "" {
+			return "A target file path is required"
+		}
+	} else {
+		if cfg.Host == "" {
+			return "A target host is required"
+		}
+		if cfg.User == "" {
+			return "A target username is required"
+		}
+		if cfg.Database == "" {
+			return "A target database name is required"
+		}
+	}
+
+	src := c.buildConfig().SourceConfig
+	if cfg.Type == src.Type && cfg.Host == src.Host && cfg.Port == src.Port &&
+		cfg.Database == src.Database && cfg.FilePath == src.FilePath {
+		return "The target database must differ from the source database"
+	}
+	return ""
+}
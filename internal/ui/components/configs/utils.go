@@ -1,6 +1,9 @@
 package configs
 
 import (
+	"maps"
+	"slices"
+
 	"github.com/antoniosarro/reltrace/internal/database/models"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -13,6 +16,12 @@ func (c *ConfigForm) handleNavigation(key string) (*ConfigForm, tea.Cmd) {
 
 	switch key {
 	case "enter":
+		switch c.focusIndex {
+		case 5:
+			return c.startBrowser()
+		case 6:
+			return c.startFinder()
+		}
 		if c.focusIndex == len(c.inputs) {
 			if c.validateInputs() {
 				c.step = 1 // Move to mode selection
@@ -32,6 +41,12 @@ func (c *ConfigForm) handleNavigation(key string) (*ConfigForm, tea.Cmd) {
 		c.focusIndex = maxIndex
 	}
 
+	return c, c.focusInput(c.focusIndex)
+}
+
+// focusInput focuses the i-th input, or the button past the last one, and blurs the others
+func (c *ConfigForm) focusInput(i int) tea.Cmd {
+	c.focusIndex = i
 	cmds := make([]tea.Cmd, len(c.inputs))
 	for i := 0; i < len(c.inputs); i++ {
 		if i == c.focusIndex {
@@ -44,7 +59,7 @@ func (c *ConfigForm) handleNavigation(key string) (*ConfigForm, tea.Cmd) {
 			c.inputs[i].TextStyle = c.styles.Blurred
 		}
 	}
-	return c, tea.Batch(cmds...)
+	return tea.Batch(cmds...)
 }
 
 // validateInputs validates the form inputs
@@ -62,7 +77,7 @@ func (c *ConfigForm) validateInputs() bool {
 
 		// Root table/key required for specific modes
 		if c.mode == models.StructureAndDataExcluding || c.mode == models.StructureAndDataIncludingOnly {
-			if c.inputs[5].Value() == "" || c.inputs[6].Value() == "" {
+			if (c.inputs[5].Value() == "" || c.inputs[6].Value() == "") && len(c.seeds) == 0 {
 				return false
 			}
 		}
@@ -73,8 +88,11 @@ func (c *ConfigForm) validateInputs() bool {
 
 // validateTargetDbConfig validates target database configuration
 func (c *ConfigForm) validateTargetDbConfig() bool {
-	// For now, assume target DB config is valid
-	// In a full implementation, collect target DB details
+	if msg := c.targetValidationError(); msg != "" {
+		c.status = msg
+		c.statusErr = true
+		return false
+	}
 	return true
 }
 
@@ -102,26 +120,49 @@ func (c *ConfigForm) buildConfig() models.DumpConfig {
 		sourceConfig.Database = c.inputs[4].Value()
 		// Set default ports
 		if sourceConfig.Port == "" {
-			switch c.dbType {
-			case models.MySQL:
-				sourceConfig.Port = "3306"
-			case models.PostgreSQL:
-				sourceConfig.Port = "5432"
-			}
+			sourceConfig.Port = c.dbType.DefaultPort()
 		}
 	}
 
 	config := models.DumpConfig{
-		SourceConfig: sourceConfig,
-		Mode:         c.mode,
-		Target:       c.target,
-		OutputPath:   c.inputs[7].Value(),
+		SourceConfig:       sourceConfig,
+		Mode:               c.mode,
+		Target:             c.target,
+		OutputPath:         c.inputs[7].Value(),
+		IncludeTables:      slices.Clone(c.includeTables),
+		ExcludeTables:      slices.Clone(c.excludeTables),
+		Polymorphic:        slices.Clone(c.polymorphic),
+		VirtualForeignKeys: slices.Clone(c.virtual),
+		Snapshot:           c.snapshot,
+		Workers:            c.limits.Workers,
+		ChunkRows:          c.limits.ChunkRows,
+		BufferRows:         c.limits.BufferRows,
+	}
+
+	switch c.target {
+	case models.ToDatabase:
+		target := c.buildTargetConfig()
+		config.TargetConfig = &target
+	case models.ToFile:
+		config.TargetConfig = c.fileDialect
 	}
 
 	// Add root table/key for modes that need them
 	if c.mode == models.StructureAndDataExcluding || c.mode == models.StructureAndDataIncludingOnly {
 		config.RootTable = c.inputs[5].Value()
 		config.RootPrimaryKey = c.inputs[6].Value()
+		config.Seeds = slices.Clone(c.seeds)
+	}
+	// The excluding mode leaves out every row depending on the seeds, which
+	// rules, boundaries and limits would keep from being reached
+	if c.mode == models.StructureAndDataIncludingOnly {
+		config.BoundaryTables = slices.Clone(c.boundaryTables)
+		config.LookupTables = slices.Clone(c.lookupTables)
+		config.Follow = maps.Clone(c.follow)
+		config.MaxDepth = c.limits.MaxDepth
+		config.DepthLimits = maps.Clone(c.limits.DepthLimits)
+		config.MaxRows = c.limits.MaxRows
+		config.TableMaxRows = maps.Clone(c.limits.TableMaxRows)
 	}
 
 	return config
@@ -0,0 +1,79 @@
+package processing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/engine"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"github.com/antoniosarro/reltrace/internal/ui/styles"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// refreshInterval is how often the screen is redrawn while a dump runs
+const refreshInterval = 100 * time.Millisecond
+
+// DumpFinishedMsg is sent when a dump completes, fails or is cancelled
+type DumpFinishedMsg struct {
+	Config models.DumpConfig
+	Result *engine.Result
+	Err    error
+}
+
+// tickMsg triggers a redraw of the running dump
+type tickMsg time.Time
+
+// tableProgress holds the latest progress of one table
+type tableProgress struct {
+	name     string
+	rows     int64
+	expected int64
+	done     bool
+}
+
+// state is the progress of a dump as last reported
+type state struct {
+	phase   engine.Phase
+	message string
+	table   string
+	total   int
+	tables  []*tableProgress
+}
+
+// tracker collects the progress reported by the dump goroutine
+type tracker struct {
+	mu    sync.Mutex
+	state state
+	index map[string]*tableProgress
+}
+
+// Monitor runs a dump in the background and renders its progress
+type Monitor struct {
+	styles *styles.Styles
+	bar    progress.Model
+
+	config  models.DumpConfig
+	started time.Time
+	cancel  context.CancelFunc
+	done    chan DumpFinishedMsg
+	track   *tracker
+
+	confirming bool // waiting for the user to confirm the cancellation
+	canceling  bool // cancellation requested, waiting for the dump to stop
+	running    bool
+}
+
+// NewMonitor creates a new dump monitor
+func NewMonitor(s *styles.Styles) *Monitor {
+	fill := "#7D56F4"
+	if c, ok := s.Progress.GetForeground().(lipgloss.Color); ok {
+		fill = string(c)
+	}
+
+	return &Monitor{
+		styles: s,
+		bar:    progress.New(progress.WithSolidFill(fill), progress.WithoutPercentage(), progress.WithWidth(30)),
+	}
+}
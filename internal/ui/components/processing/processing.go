@@ -0,0 +1,240 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/engine"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// visibleTables is the number of table rows shown at once
+const visibleTables = 12
+
+// phases are the dump phases in the order they are displayed
+var phases = []engine.Phase{
+	engine.PhaseIntrospection,
+	engine.PhaseTraversal,
+	engine.PhaseExtraction,
+	engine.PhaseWriting,
+}
+
+// Start runs a dump in the background and begins reporting its progress. The
+// JSON report of the dump is written to reportPath unless it is empty.
+func (m *Monitor) Start(cfg models.DumpConfig, reportPath string) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.config = cfg
+	m.started = time.Now()
+	m.cancel = cancel
+	m.done = make(chan DumpFinishedMsg, 1)
+	m.track = &tracker{index: make(map[string]*tableProgress)}
+	m.confirming = false
+	m.canceling = false
+	m.running = true
+
+	go func(done chan<- DumpFinishedMsg, track *tracker) {
+		res, err := engine.Run(ctx, cfg, engine.Options{Progress: track.update, ReportPath: reportPath})
+		cancel()
+		done <- DumpFinishedMsg{Config: cfg, Result: res, Err: err}
+	}(m.done, m.track)
+
+	return tea.Batch(tick(), wait(m.done))
+}
+
+// Running reports whether a dump is in progress
+func (m *Monitor) Running() bool {
+	return m.running
+}
+
+// Update handles monitor updates
+func (m *Monitor) Update(msg tea.Msg) (*Monitor, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.bar.Width = max(10, min(40, msg.Width/3))
+	case tickMsg:
+		if m.running {
+			return m, tick()
+		}
+	case DumpFinishedMsg:
+		m.running = false
+		m.confirming = false
+	case tea.KeyMsg:
+		if !m.running || m.canceling {
+			return m, nil
+		}
+		if m.confirming {
+			switch msg.String() {
+			case "y", "Y":
+				m.confirming = false
+				m.canceling = true
+				m.cancel()
+			case "n", "N", "esc":
+				m.confirming = false
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "esc", "q", "ctrl+c":
+			m.confirming = true
+		}
+	}
+	return m, nil
+}
+
+// View renders the progress of the running dump
+func (m *Monitor) View() string {
+	if m.track == nil {
+		return ""
+	}
+	snap := m.track.snapshot()
+
+	var b strings.Builder
+	title := fmt.Sprintf("Dumping %s to %s", m.config.SourceConfig.Type, m.config.Target)
+	b.WriteString(m.styles.Title.Render(title))
+	b.WriteString("\n\n")
+
+	// Phase strip
+	for i, p := range phases {
+		if i > 0 {
+			b.WriteString(m.styles.Blurred.Render(" → "))
+		}
+		if p == snap.phase {
+			b.WriteString(m.styles.Focused.Render("● " + p.String()))
+		} else {
+			b.WriteString(m.styles.Blurred.Render("○ " + p.String()))
+		}
+	}
+	b.WriteString("\n")
+	if snap.message != "" || snap.table != "" {
+		b.WriteString(m.styles.Info.Render(strings.TrimSpace(snap.table + " " + snap.message)))
+	}
+	b.WriteString("\n\n")
+
+	// Per table progress
+	var rows int64
+	done := 0
+	for _, t := range snap.tables {
+		rows += t.rows
+		if t.done {
+			done++
+		}
+	}
+	start := max(0, len(snap.tables)-visibleTables)
+	if start > 0 {
+		b.WriteString(m.styles.Blurred.Render(fmt.Sprintf("  … %d more tables", start)))
+		b.WriteString("\n")
+	}
+	nameWidth := 0
+	for _, t := range snap.tables[start:] {
+		nameWidth = max(nameWidth, len(t.name))
+	}
+	for _, t := range snap.tables[start:] {
+		mark := "  "
+		if t.done {
+			mark = m.styles.Success.Render("✓ ")
+		}
+		b.WriteString(mark)
+		b.WriteString(fmt.Sprintf("%-*s ", nameWidth, t.name))
+		b.WriteString(m.bar.ViewAs(fraction(t.rows, t.expected)))
+		b.WriteString(fmt.Sprintf(" %d/%d rows\n", t.rows, t.expected))
+	}
+	if len(snap.tables) > 0 {
+		b.WriteString("\n")
+	}
+
+	// Totals
+	elapsed := time.Since(m.started)
+	throughput := 0.0
+	if secs := elapsed.Seconds(); secs > 0 {
+		throughput = float64(rows) / secs
+	}
+	b.WriteString(fmt.Sprintf("Tables: %d/%d   Rows: %d   Elapsed: %s   Throughput: %.0f rows/s\n\n",
+		done, snap.total, rows, elapsed.Round(time.Second), throughput))
+
+	switch {
+	case m.canceling:
+		b.WriteString(m.styles.Warning.Render("Cancelling, waiting for the dump to stop..."))
+	case m.confirming:
+		b.WriteString(m.styles.Warning.Render("Cancel the running dump? Partial output is left behind. (y/n)"))
+	default:
+		b.WriteString(m.styles.Help.Render("• Esc to cancel"))
+	}
+
+	return m.styles.Progress.Render(b.String())
+}
+
+// update records a progress report; it runs on the dump goroutine
+func (t *tracker) update(p engine.Progress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := &t.state
+	s.phase = p.Phase
+	s.message = p.Message
+	s.table = p.Table
+	if p.Total > 0 {
+		s.total = p.Total
+	}
+
+	switch p.Phase {
+	case engine.PhaseExtraction:
+		if p.Table == "" {
+			return
+		}
+		tp, ok := t.index[p.Table]
+		if !ok {
+			// Moving to a new table means the previous one is complete
+			if n := len(s.tables); n > 0 {
+				s.tables[n-1].done = true
+			}
+			tp = &tableProgress{name: p.Table}
+			t.index[p.Table] = tp
+			s.tables = append(s.tables, tp)
+		}
+		tp.rows = p.Rows
+		tp.expected = p.Expected
+	case engine.PhaseWriting:
+		for _, tp := range s.tables {
+			tp.done = true
+		}
+	}
+}
+
+// snapshot returns a copy of the progress safe to render
+func (t *tracker) snapshot() state {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snap := t.state
+	snap.tables = make([]*tableProgress, len(t.state.tables))
+	for i, tp := range t.state.tables {
+		c := *tp
+		snap.tables[i] = &c
+	}
+	return snap
+}
+
+// fraction returns the completed share of a table, for the progress bars
+func fraction(rows, expected int64) float64 {
+	if expected <= 0 {
+		return 1
+	}
+	return min(1, float64(rows)/float64(expected))
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// wait delivers the outcome of the dump once it finishes
+func wait(done <-chan DumpFinishedMsg) tea.Cmd {
+	return func() tea.Msg {
+		return <-done
+	}
+}
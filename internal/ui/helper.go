@@ -13,3 +13,15 @@ func (m *Model) updateConfigForm(msg tea.Msg) (*Model, tea.Cmd) {
 	m.configForm, cmd = m.configForm.Update(msg)
 	return m, cmd
 }
+
+func (m *Model) updateMonitor(msg tea.Msg) (*Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.monitor, cmd = m.monitor.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) updateSummary(msg tea.Msg) (*Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.summary, cmd = m.summary.Update(msg)
+	return m, cmd
+}
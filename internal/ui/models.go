@@ -2,8 +2,11 @@ package ui
 
 import (
 	"github.com/antoniosarro/reltrace/internal/config"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"github.com/antoniosarro/reltrace/internal/ui/components/completion"
 	"github.com/antoniosarro/reltrace/internal/ui/components/configs"
 	"github.com/antoniosarro/reltrace/internal/ui/components/database"
+	"github.com/antoniosarro/reltrace/internal/ui/components/processing"
 	"github.com/antoniosarro/reltrace/internal/ui/styles"
 )
 
@@ -26,6 +29,8 @@ type Model struct {
 	// Components
 	configForm *configs.ConfigForm
 	dbSelector *database.DatabaseSelector
+	monitor    *processing.Monitor
+	summary    *completion.Summary
 
 	// State
 	error string
@@ -45,5 +50,14 @@ func New(config config.AppConfig) *Model {
 		styles:     s,
 		configForm: configs.NewConfigForm(s),
 		dbSelector: database.NewDatabaseSelector(s),
+		monitor:    processing.NewMonitor(s),
+		summary:    completion.NewSummary(s),
 	}
 }
+
+// Prefill skips the database selection and opens the configuration form
+// filled with a saved job
+func (m *Model) Prefill(job models.DumpConfig) {
+	m.configForm.Load(job)
+	m.state = ConfigurationView
+}
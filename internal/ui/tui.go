@@ -1,12 +1,24 @@
 package ui
 
 import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/antoniosarro/reltrace/internal/database/engine"
+	"github.com/antoniosarro/reltrace/internal/database/models"
+	"github.com/antoniosarro/reltrace/internal/ui/components/completion"
+	"github.com/antoniosarro/reltrace/internal/ui/components/configs"
 	"github.com/antoniosarro/reltrace/internal/ui/components/database"
+	"github.com/antoniosarro/reltrace/internal/ui/components/processing"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Init initializes the UI model
 func (m *Model) Init() tea.Cmd {
+	if m.state == ConfigurationView {
+		return m.configForm.Focus()
+	}
 	return m.dbSelector.Init()
 }
 
@@ -15,16 +27,35 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		// Handle window size changes
+		m.width, m.height = msg.Width, msg.Height
+		m.monitor, _ = m.monitor.Update(msg)
+		m.summary, _ = m.summary.Update(msg)
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
-			return m, tea.Quit
+			// A running dump asks for confirmation instead of quitting
+			if m.state != ProcessingView || !m.monitor.Running() {
+				return m, tea.Quit
+			}
 		}
 	case database.DatabaseSelectedMsg:
 		// Update the config form with selected database type
 		m.configForm.SetDatabaseType(msg.DatabaseType)
 		m.state = ConfigurationView
 		return m, m.configForm.Focus()
+	case configs.ConfigCompletedMsg:
+		cfg := m.withDefaults(msg.Config)
+		m.state = ProcessingView
+		return m, m.monitor.Start(cfg, m.reportPath(cfg))
+	case processing.DumpFinishedMsg:
+		m.monitor, _ = m.monitor.Update(msg)
+		m.summary.Show(msg)
+		m.state = CompletionView
+		return m, nil
+	case completion.RestartMsg:
+		// Start over, keeping the configuration entered so far
+		m.state = ConfigurationView
+		return m, m.configForm.Restart()
 	}
 
 	// Update current view
@@ -33,6 +64,10 @@ func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 		return m.updateDatabaseSelector(msg)
 	case ConfigurationView:
 		return m.updateConfigForm(msg)
+	case ProcessingView:
+		return m.updateMonitor(msg)
+	case CompletionView:
+		return m.updateSummary(msg)
 	}
 
 	return m, nil
@@ -45,6 +80,36 @@ func (m *Model) View() string {
 		return m.dbSelector.View()
 	case ConfigurationView:
 		return m.configForm.View()
+	case ProcessingView:
+		return m.monitor.View()
+	case CompletionView:
+		return m.summary.View()
 	}
 	return ""
 }
+
+// withDefaults fills the settings the form leaves optional
+func (m *Model) withDefaults(cfg models.DumpConfig) models.DumpConfig {
+	if cfg.Target == models.ToFile && cfg.OutputPath == "" {
+		name := cfg.SourceConfig.Database
+		if name == "" {
+			name = cfg.SourceConfig.FilePath
+		}
+		cfg.OutputPath = m.config.Output.DumpPath(name, time.Now())
+	}
+	return cfg
+}
+
+// reportPath returns where the JSON report of a dump is written: next to the
+// dump file, or in the output directory for direct database imports
+func (m *Model) reportPath(cfg models.DumpConfig) string {
+	if path := engine.DefaultReportPath(cfg); path != "" || cfg.TargetConfig == nil {
+		return path
+	}
+	name := cfg.TargetConfig.Database
+	if name == "" {
+		name = cfg.TargetConfig.FilePath
+	}
+	path := m.config.Output.DumpPath(name, time.Now())
+	return strings.TrimSuffix(path, filepath.Ext(path)) + engine.ReportSuffix
+}